@@ -0,0 +1,174 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:35:03
+//
+// File Name: allocate.go
+// Description:
+//
+//	Allocate the files of torrents on disk
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Errors
+var (
+	ErrAllocateFiles = errors.New("Failed to allocate files")
+)
+
+// Allocation mode, the same values as TransmissionSettings.Preallocation
+const (
+	AllocateOnWrite = 0 // Files are created and preallocated on the first write, see AllocateOnWriteFile
+	AllocateSparse  = 1 // Files are created as sparse files with full length
+	AllocateFull    = 2 // The disk space is reserved (fallocate where supported), to avoid fragmentation and running out of space
+)
+
+// AllocateTorrentFiles creates the files of torrent in dir (the parent of the torrent name) by the allocation mode.
+// Existing files are extended but never truncated. Padding files and symlinks are skipped. Nothing is done for
+// AllocateOnWrite, the files are written by AllocateOnWriteFile instead.
+func AllocateTorrentFiles(t *TorrentFile, dir string, mode int) error {
+	if mode == AllocateOnWrite {
+		return nil
+	}
+	r, err := newTorrentContentReader(t, dir)
+	if err != nil {
+		return err
+	}
+	for i, file := range r.Files {
+		if file.Padding || (i < len(t.Info.Files) && t.Info.Files[i].IsSymlink()) {
+			continue
+		}
+		if err := allocateFile(file.Path, file.Length, mode); err != nil {
+			return fmt.Errorf("%w: [%v] %v", ErrAllocateFiles, file.Path, err)
+		}
+	}
+	return nil
+}
+
+func allocateFile(path string, length int64, mode int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	switch mode {
+	case AllocateSparse:
+		if info.Size() < length {
+			return f.Truncate(length)
+		}
+		return nil
+	case AllocateFull:
+		return fallocate(f, info.Size(), length)
+	default:
+		return fmt.Errorf("Unknown allocation mode [%v]", mode)
+	}
+}
+
+// writeZeros writes zeros from size to length, which forces the file system to allocate the blocks
+func writeZeros(f *os.File, size, length int64) error {
+	zeros := make([]byte, 1<<20)
+	for offset := size; offset < length; offset += int64(len(zeros)) {
+		n := min(int64(len(zeros)), length-offset)
+		if _, err := f.WriteAt(zeros[:n], offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AllocateOnWriteFile creates the file and reserves its full length (as AllocateFull) on the first write, so the
+// files never written (e.g. unwanted) take no space while the written ones are not fragmented. It implements
+// io.ReaderAt and io.WriterAt, reads before the first write see io.EOF if the file doesn't exist. It's safe for
+// concurrent use, Close must be called.
+type AllocateOnWriteFile struct {
+	Path   string
+	Length int64
+
+	mutex     sync.Mutex
+	file      *os.File
+	allocated bool
+}
+
+// NewAllocateOnWriteFile creates a new AllocateOnWriteFile
+func NewAllocateOnWriteFile(path string, length int64) *AllocateOnWriteFile {
+	return &AllocateOnWriteFile{Path: path, Length: length}
+}
+
+// WriteAt implements io.WriterAt
+func (f *AllocateOnWriteFile) WriteAt(p []byte, off int64) (int, error) {
+	file, err := f.open(true)
+	if err != nil {
+		return 0, err
+	}
+	return file.WriteAt(p, off)
+}
+
+// ReadAt implements io.ReaderAt
+func (f *AllocateOnWriteFile) ReadAt(p []byte, off int64) (int, error) {
+	file, err := f.open(false)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, io.EOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	return file.ReadAt(p, off)
+}
+
+// Close closes the file if it's opened
+func (f *AllocateOnWriteFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file, f.allocated = nil, false
+	return err
+}
+
+// open opens the file, which is created and allocated if write is set
+func (f *AllocateOnWriteFile) open(write bool) (*os.File, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		flag := os.O_RDWR
+		if write {
+			if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+				return nil, fmt.Errorf("%w: [%v] %v", ErrAllocateFiles, f.Path, err)
+			}
+			flag |= os.O_CREATE
+		}
+		file, err := os.OpenFile(f.Path, flag, 0644)
+		if err != nil {
+			return nil, err
+		}
+		f.file = file
+	}
+	if write && !f.allocated {
+		info, err := f.file.Stat()
+		if err == nil {
+			err = fallocate(f.file, info.Size(), f.Length)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: [%v] %v", ErrAllocateFiles, f.Path, err)
+		}
+		f.allocated = true
+	}
+	return f.file, nil
+}
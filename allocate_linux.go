@@ -0,0 +1,30 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:35:03
+//
+// File Name: allocate_linux.go
+// Description:
+//
+//	Reserve disk space by fallocate
+//
+
+//go:build linux
+
+package transmission
+
+import (
+	"os"
+	"syscall"
+)
+
+// fallocate reserves the disk space of file up to length
+func fallocate(f *os.File, size, length int64) error {
+	if length <= 0 {
+		return nil
+	}
+	err := syscall.Fallocate(int(f.Fd()), 0, 0, length)
+	if err == syscall.EOPNOTSUPP || err == syscall.ENOSYS {
+		// The file system doesn't support fallocate
+		return writeZeros(f, size, length)
+	}
+	return err
+}
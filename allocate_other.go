@@ -0,0 +1,21 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:35:03
+//
+// File Name: allocate_other.go
+// Description:
+//
+//	Reserve disk space by writing zeros where fallocate is not available
+//
+
+//go:build !linux
+
+package transmission
+
+import (
+	"os"
+)
+
+// fallocate reserves the disk space of file up to length
+func fallocate(f *os.File, size, length int64) error {
+	return writeZeros(f, size, length)
+}
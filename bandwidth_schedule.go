@@ -0,0 +1,146 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:15:41
+//
+// File Name: bandwidth_schedule.go
+// Description:
+//
+//	Weekly bandwidth scheduler (like transmission's alt-speed scheduler)
+//
+//	Reference:
+//
+//		https://github.com/transmission/transmission/blob/main/docs/Editing-Configuration-Files.md
+//
+
+package transmission
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Schedule days (Same as transmission's alt-speed-time-day)
+const (
+	ScheduleSunday    = 1 << 0
+	ScheduleMonday    = 1 << 1
+	ScheduleTuesday   = 1 << 2
+	ScheduleWednesday = 1 << 3
+	ScheduleThursday  = 1 << 4
+	ScheduleFriday    = 1 << 5
+	ScheduleSaturday  = 1 << 6
+
+	ScheduleWeekdays = ScheduleMonday | ScheduleTuesday | ScheduleWednesday | ScheduleThursday | ScheduleFriday
+	ScheduleWeekends = ScheduleSunday | ScheduleSaturday
+	ScheduleAllDays  = ScheduleWeekdays | ScheduleWeekends
+)
+
+// BandwidthLimits defines the rate limits and connection settings
+type BandwidthLimits struct {
+	DownloadLimit  int // KB/s, 0 means unlimited
+	UploadLimit    int // KB/s, 0 means unlimited
+	MaxConnections int // 0 means unchanged
+}
+
+// SessionSetArguments returns the arguments of transmission rpc session-set method
+func (l BandwidthLimits) SessionSetArguments() map[string]interface{} {
+	args := map[string]interface{}{
+		"speed-limit-down-enabled": l.DownloadLimit > 0,
+		"speed-limit-up-enabled":   l.UploadLimit > 0,
+	}
+	if l.DownloadLimit > 0 {
+		args["speed-limit-down"] = l.DownloadLimit
+	}
+	if l.UploadLimit > 0 {
+		args["speed-limit-up"] = l.UploadLimit
+	}
+	if l.MaxConnections > 0 {
+		args["peer-limit-global"] = l.MaxConnections
+	}
+	return args
+}
+
+// BandwidthScheduleRule defines a rule of the weekly timetable
+type BandwidthScheduleRule struct {
+	Days   int // Bitmask of ScheduleXXX
+	Begin  int // Minutes after midnight
+	End    int // Minutes after midnight. If End < Begin the rule spans midnight
+	Limits BandwidthLimits
+}
+
+func (r BandwidthScheduleRule) match(t time.Time) bool {
+	var (
+		day    = 1 << uint(t.Weekday())
+		prev   = 1 << uint((t.Weekday()+6)%7)
+		minute = t.Hour()*60 + t.Minute()
+	)
+	if r.Begin <= r.End {
+		return r.Days&day != 0 && minute >= r.Begin && minute < r.End
+	}
+	return (r.Days&day != 0 && minute >= r.Begin) || (r.Days&prev != 0 && minute < r.End)
+}
+
+// BandwidthScheduler switches bandwidth limits on a weekly timetable.
+// The first matched rule wins, Default is used when no rule matches.
+type BandwidthScheduler struct {
+	Default BandwidthLimits
+	Rules   []BandwidthScheduleRule
+}
+
+// LimitsAt returns the limits at time t
+func (s *BandwidthScheduler) LimitsAt(t time.Time) BandwidthLimits {
+	for _, rule := range s.Rules {
+		if rule.match(t) {
+			return rule.Limits
+		}
+	}
+	return s.Default
+}
+
+// NextChange returns the next time after t when the limits change, returns zero time if they never change
+func (s *BandwidthScheduler) NextChange(t time.Time) time.Time {
+	var candidates []time.Time
+	for offset := 0; offset <= 8; offset++ {
+		for _, rule := range s.Rules {
+			for _, minute := range []int{rule.Begin, rule.End} {
+				c := time.Date(t.Year(), t.Month(), t.Day()+offset, minute/60, minute%60, 0, 0, t.Location())
+				if c.After(t) {
+					candidates = append(candidates, c)
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	current := s.LimitsAt(t)
+	for _, c := range candidates {
+		if s.LimitsAt(c) != current {
+			return c
+		}
+	}
+	return time.Time{}
+}
+
+// Run calls apply with the current limits and then every time the limits change, until ctx is done
+func (s *BandwidthScheduler) Run(ctx context.Context, apply func(limits BandwidthLimits)) error {
+	now := time.Now()
+	apply(s.LimitsAt(now))
+	for {
+		next := s.NextChange(now)
+		if next.IsZero() {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		now = time.Now()
+		if now.Before(next) {
+			now = next
+		}
+		apply(s.LimitsAt(now))
+	}
+}
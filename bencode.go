@@ -0,0 +1,203 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:17:36
+//
+// File Name: bencode.go
+// Description:
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0003.html#bencoding
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Errors
+var (
+	ErrMalformedBencode = errors.New("Malformed bencode")
+)
+
+// maxBencodeDepth defines the max nesting of lists and dictionaries, deeper data is rejected rather than exhausting
+// the stack
+const maxBencodeDepth = 256
+
+// DecodeBencode decodes bencoded data.
+// The decoded value is one of: int64, string, []interface{}, map[string]interface{}
+func DecodeBencode(data []byte) (interface{}, error) {
+	d := bencodeDecoder{data: data}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("%w: Trailing data at %v", ErrMalformedBencode, d.pos)
+	}
+	return v, nil
+}
+
+// decodeBencodeDictRaw decodes the top level dictionary and returns the raw bytes of each value
+func decodeBencodeDictRaw(data []byte) (map[string][]byte, error) {
+	d := bencodeDecoder{data: data, depth: 1}
+	if d.pos >= len(d.data) || d.data[d.pos] != 'd' {
+		return nil, fmt.Errorf("%w: Not a dictionary", ErrMalformedBencode)
+	}
+	d.pos++
+	values := make(map[string][]byte)
+	for {
+		if d.pos >= len(d.data) {
+			return nil, fmt.Errorf("%w: Unexpected end", ErrMalformedBencode)
+		}
+		if d.data[d.pos] == 'e' {
+			d.pos++
+			break
+		}
+		key, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		start := d.pos
+		if _, err := d.value(); err != nil {
+			return nil, err
+		}
+		values[key] = d.data[start:d.pos]
+	}
+	return values, nil
+}
+
+type bencodeDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func (d *bencodeDecoder) value() (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("%w: Unexpected end", ErrMalformedBencode)
+	}
+	if c := d.data[d.pos]; c == 'l' || c == 'd' {
+		if d.depth >= maxBencodeDepth {
+			return nil, fmt.Errorf("%w: Nested too deep at %v", ErrMalformedBencode, d.pos)
+		}
+		d.depth++
+		defer func() { d.depth-- }()
+	}
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		return d.int()
+	case c == 'l':
+		d.pos++
+		list := []interface{}{}
+		for {
+			if d.pos >= len(d.data) {
+				return nil, fmt.Errorf("%w: Unexpected end", ErrMalformedBencode)
+			}
+			if d.data[d.pos] == 'e' {
+				d.pos++
+				return list, nil
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	case c == 'd':
+		d.pos++
+		dict := make(map[string]interface{})
+		for {
+			if d.pos >= len(d.data) {
+				return nil, fmt.Errorf("%w: Unexpected end", ErrMalformedBencode)
+			}
+			if d.data[d.pos] == 'e' {
+				d.pos++
+				return dict, nil
+			}
+			key, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = v
+		}
+	case c >= '0' && c <= '9':
+		return d.string()
+	default:
+		return nil, fmt.Errorf("%w: Unexpected character [%q] at %v", ErrMalformedBencode, c, d.pos)
+	}
+}
+
+func (d *bencodeDecoder) int() (int64, error) {
+	// Skip 'i'
+	start := d.pos + 1
+	end := start
+	for end < len(d.data) && d.data[end] != 'e' {
+		end++
+	}
+	if end >= len(d.data) {
+		return 0, fmt.Errorf("%w: Unterminated integer", ErrMalformedBencode)
+	}
+	num, err := strconv.ParseInt(string(d.data[start:end]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: Invalid integer [%v]", ErrMalformedBencode, err)
+	}
+	d.pos = end + 1
+	return num, nil
+}
+
+func (d *bencodeDecoder) string() (string, error) {
+	colon := d.pos
+	for colon < len(d.data) && d.data[colon] != ':' {
+		colon++
+	}
+	if colon >= len(d.data) {
+		return "", fmt.Errorf("%w: Invalid string length", ErrMalformedBencode)
+	}
+	length, err := strconv.Atoi(string(d.data[d.pos:colon]))
+	if err != nil || length < 0 {
+		return "", fmt.Errorf("%w: Invalid string length", ErrMalformedBencode)
+	}
+	start := colon + 1
+	if length > len(d.data)-start {
+		return "", fmt.Errorf("%w: String out of range", ErrMalformedBencode)
+	}
+	d.pos = start + length
+	return string(d.data[start:d.pos]), nil
+}
+
+func bencodeDictString(dict map[string]interface{}, key string) string {
+	s, _ := dict[key].(string)
+	return s
+}
+
+func bencodeDictInt(dict map[string]interface{}, key string) int64 {
+	n, _ := dict[key].(int64)
+	return n
+}
+
+func bencodeDictList(dict map[string]interface{}, key string) []interface{} {
+	l, _ := dict[key].([]interface{})
+	return l
+}
+
+func bencodeDictDict(dict map[string]interface{}, key string) map[string]interface{} {
+	d, _ := dict[key].(map[string]interface{})
+	return d
+}
+
+func bencodeStringList(list []interface{}) []string {
+	var strs []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
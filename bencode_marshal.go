@@ -0,0 +1,266 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:16:05
+//
+// File Name: bencode_marshal.go
+// Description:
+//
+//	Bencode encoding and struct mapping by the `bencode:"key,omitempty"` field tag
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0003.html#bencoding
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var (
+	ErrUnsupportedBencodeType = errors.New("Unsupported bencode type")
+)
+
+// MarshalBencode encodes v. Supported values are integers, bools (as 0 or 1), strings, byte slices, slices, maps of
+// string keys and structs. Struct fields are encoded as dictionary keys by the tag `bencode:"key,omitempty"` (the
+// field name if there's no tag, "-" skips the field), nil pointers and interfaces are omitted from dictionaries.
+func MarshalBencode(v interface{}) ([]byte, error) {
+	return appendBencode(nil, reflect.ValueOf(v))
+}
+
+// UnmarshalBencode decodes data into v, which must be a non-nil pointer. Unknown dictionary keys are ignored, so
+// newer versions of a message can add keys.
+func UnmarshalBencode(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("%w: Not a non-nil pointer [%T]", ErrUnsupportedBencodeType, v)
+	}
+	decoded, err := DecodeBencode(data)
+	if err != nil {
+		return err
+	}
+	return assignBencode(rv.Elem(), decoded)
+}
+
+func appendBencode(b []byte, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, fmt.Errorf("%w: Nil value", ErrUnsupportedBencodeType)
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, fmt.Errorf("%w: Nil value", ErrUnsupportedBencodeType)
+		}
+		return appendBencode(b, v.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendBencodeInt(b, strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendBencodeInt(b, strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, "i1e"...), nil
+		}
+		return append(b, "i0e"...), nil
+	case reflect.String:
+		return appendBencodeString(b, v.String()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice {
+				return appendBencodeString(b, string(v.Bytes())), nil
+			}
+			raw := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(raw), v)
+			return appendBencodeString(b, string(raw)), nil
+		}
+		b = append(b, 'l')
+		for i := 0; i < v.Len(); i++ {
+			var err error
+			if b, err = appendBencode(b, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, 'e'), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("%w: Map key [%v]", ErrUnsupportedBencodeType, v.Type().Key())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		b = append(b, 'd')
+		for _, key := range keys {
+			value := v.MapIndex(key)
+			if isNilBencodeValue(value) {
+				continue
+			}
+			b = appendBencodeString(b, key.String())
+			var err error
+			if b, err = appendBencode(b, value); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, 'e'), nil
+	case reflect.Struct:
+		fields := bencodeStructFields(v.Type())
+		b = append(b, 'd')
+		for _, field := range fields {
+			value := v.Field(field.index)
+			if isNilBencodeValue(value) || (field.omitEmpty && value.IsZero()) {
+				continue
+			}
+			b = appendBencodeString(b, field.key)
+			var err error
+			if b, err = appendBencode(b, value); err != nil {
+				return nil, err
+			}
+		}
+		return append(b, 'e'), nil
+	default:
+		return nil, fmt.Errorf("%w: [%v]", ErrUnsupportedBencodeType, v.Type())
+	}
+}
+
+func appendBencodeInt(b []byte, s string) []byte {
+	b = append(b, 'i')
+	b = append(b, s...)
+	return append(b, 'e')
+}
+
+func appendBencodeString(b []byte, s string) []byte {
+	b = strconv.AppendInt(b, int64(len(s)), 10)
+	b = append(b, ':')
+	return append(b, s...)
+}
+
+func isNilBencodeValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// bencodeField defines a struct field mapped to a dictionary key
+type bencodeField struct {
+	key       string
+	index     int
+	omitEmpty bool
+}
+
+// bencodeStructFields returns the fields of struct type sorted by key, as dictionary keys must be sorted
+func bencodeStructFields(t reflect.Type) []bencodeField {
+	var fields []bencodeField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("bencode")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, bencodeField{key: name, index: i, omitEmpty: options == "omitempty"})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	return fields
+}
+
+// assignBencode assigns the decoded value (see DecodeBencode) to v
+func assignBencode(v reflect.Value, decoded interface{}) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignBencode(v.Elem(), decoded)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("%w: [%v]", ErrUnsupportedBencodeType, v.Type())
+		}
+		v.Set(reflect.ValueOf(decoded))
+		return nil
+	}
+	switch value := decoded.(type) {
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(value) {
+				return fmt.Errorf("%w: Integer overflows [%v] [%v]", ErrMalformedBencode, value, v.Type())
+			}
+			v.SetInt(value)
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if value < 0 || v.OverflowUint(uint64(value)) {
+				return fmt.Errorf("%w: Integer overflows [%v] [%v]", ErrMalformedBencode, value, v.Type())
+			}
+			v.SetUint(uint64(value))
+			return nil
+		case reflect.Bool:
+			v.SetBool(value != 0)
+			return nil
+		}
+	case string:
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(value)
+			return nil
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes([]byte(value))
+			return nil
+		case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+			if len(value) != v.Len() {
+				return fmt.Errorf("%w: String length [%v] [%v]", ErrMalformedBencode, len(value), v.Type())
+			}
+			reflect.Copy(v, reflect.ValueOf([]byte(value)))
+			return nil
+		}
+	case []interface{}:
+		if v.Kind() == reflect.Slice {
+			list := reflect.MakeSlice(v.Type(), len(value), len(value))
+			for i, item := range value {
+				if err := assignBencode(list.Index(i), item); err != nil {
+					return err
+				}
+			}
+			v.Set(list)
+			return nil
+		}
+	case map[string]interface{}:
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				break
+			}
+			dict := reflect.MakeMapWithSize(v.Type(), len(value))
+			for key, item := range value {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := assignBencode(elem, item); err != nil {
+					return err
+				}
+				dict.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+			}
+			v.Set(dict)
+			return nil
+		case reflect.Struct:
+			for _, field := range bencodeStructFields(v.Type()) {
+				item, ok := value[field.key]
+				if !ok {
+					continue
+				}
+				if err := assignBencode(v.Field(field.index), item); err != nil {
+					return fmt.Errorf("%w [%v]", err, field.key)
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: Cannot assign [%T] to [%v]", ErrMalformedBencode, decoded, v.Type())
+}
@@ -0,0 +1,198 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:20:31
+//
+// File Name: cache.go
+// Description:
+//
+//	Cache for fetched metadata and tracker lists
+//
+
+package transmission
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache defines the cache interface
+type Cache interface {
+	// Get returns the value, the bool is false if the key doesn't exist or is expired
+	Get(key string) ([]byte, bool)
+	// Set sets the value with ttl, zero ttl means never expire
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+//
+//
+//
+// Memory cache
+//
+//
+//
+
+// Memory cache defaults
+const (
+	DefaultMemoryCacheMaxEntries    = 4096
+	DefaultMemoryCacheSweepInterval = time.Minute
+)
+
+// MemoryCache implements in-memory Cache. Expired entries are swept every DefaultMemoryCacheSweepInterval on Set,
+// and the entries expiring soonest are evicted when MaxEntries is reached. Values are copied in and out.
+type MemoryCache struct {
+	MaxEntries int // DefaultMemoryCacheMaxEntries if zero
+
+	mutex     sync.Mutex
+	entries   map[string]memoryCacheEntry
+	nextSweep time.Time
+}
+
+type memoryCacheEntry struct {
+	Value     []byte
+	ExpiresAt time.Time
+}
+
+// NewMemoryCache creates a new MemoryCache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+// Get implements Cache
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !entry.ExpiresAt.IsZero() && !time.Now().Before(entry.ExpiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return bytes.Clone(entry.Value), true
+}
+
+// Set implements Cache
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	now := time.Now()
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]memoryCacheEntry)
+	}
+	maxEntries := c.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMemoryCacheMaxEntries
+	}
+	if _, ok := c.entries[key]; (!ok && len(c.entries) >= maxEntries) || !now.Before(c.nextSweep) {
+		c.sweep(now)
+	}
+	if _, ok := c.entries[key]; !ok {
+		for len(c.entries) >= maxEntries {
+			c.evict()
+		}
+	}
+	c.entries[key] = memoryCacheEntry{bytes.Clone(value), expiresAt}
+	return nil
+}
+
+// sweep removes the expired entries
+func (c *MemoryCache) sweep(now time.Time) {
+	for key, entry := range c.entries {
+		if !entry.ExpiresAt.IsZero() && !now.Before(entry.ExpiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.nextSweep = now.Add(DefaultMemoryCacheSweepInterval)
+}
+
+// evict removes the entry expiring soonest, the entries never expire are the last
+func (c *MemoryCache) evict() {
+	var (
+		evicted   string
+		expiresAt time.Time
+		found     bool
+	)
+	for key, entry := range c.entries {
+		if !found || (!entry.ExpiresAt.IsZero() && (expiresAt.IsZero() || entry.ExpiresAt.Before(expiresAt))) {
+			evicted, expiresAt, found = key, entry.ExpiresAt, true
+		}
+	}
+	delete(c.entries, evicted)
+}
+
+//
+//
+//
+// File cache
+//
+//
+//
+
+// FileCache implements Cache backed by files in a directory, so cached values survive restarts.
+// Each entry is stored in a file named by the sha1 of key, prefixed by the expiry time.
+type FileCache struct {
+	Dir string
+}
+
+// NewFileCache creates a new FileCache, the directory is created if not exists
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileCache{Dir: dir}, nil
+}
+
+func (c *FileCache) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:]))
+}
+
+// Get implements Cache
+func (c *FileCache) Get(key string) ([]byte, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) < 8 {
+		return nil, false
+	}
+	if expiresAt := int64(binary.BigEndian.Uint64(data[:8])); expiresAt != 0 && time.Now().UnixNano() >= expiresAt {
+		os.Remove(path)
+		return nil, false
+	}
+	return data[8:], true
+}
+
+// Set implements Cache
+func (c *FileCache) Set(key string, value []byte, ttl time.Duration) error {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(data[:8], uint64(expiresAt))
+	copy(data[8:], value)
+
+	f, err := os.CreateTemp(c.Dir, ".cache.*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path(key))
+}
@@ -0,0 +1,92 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:19:25
+//
+// File Name: charset.go
+// Description:
+//
+//	Charset detection and conversion to utf-8
+//
+//	NOTE:
+//
+//		Only latin-1 (windows-1252) is built in. Decoders of multi-byte charsets (GBK, Shift-JIS, ...) can be plugged by
+//		NewCharsetDecoder, e.g. with golang.org/x/text/encoding/simplifiedchinese.GBK.NewDecoder().Bytes
+//
+
+package transmission
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Charset names
+const (
+	CharsetUTF8   = "utf-8"
+	CharsetLatin1 = "latin-1"
+)
+
+// CharsetDecoder decodes bytes of a charset to utf-8 string
+type CharsetDecoder interface {
+	Charset() string
+	Decode(b []byte) (string, error)
+}
+
+type charsetDecoder struct {
+	charset string
+	decode  func(b []byte) ([]byte, error)
+}
+
+func (d charsetDecoder) Charset() string {
+	return d.charset
+}
+
+func (d charsetDecoder) Decode(b []byte) (string, error) {
+	out, err := d.decode(b)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// NewCharsetDecoder creates a new CharsetDecoder by a decode function
+func NewCharsetDecoder(charset string, decode func(b []byte) ([]byte, error)) CharsetDecoder {
+	return charsetDecoder{charset, decode}
+}
+
+// windows1252 maps 0x80 - 0x9f of windows-1252, zero means undefined
+var windows1252 = [32]rune{
+	0x20ac, 0, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021, 0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017d, 0,
+	0, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014, 0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0, 0x017e, 0x0178,
+}
+
+// Latin1CharsetDecoder decodes latin-1 bytes (as windows-1252, what browsers do), it never fails
+var Latin1CharsetDecoder CharsetDecoder = NewCharsetDecoder(CharsetLatin1, func(b []byte) ([]byte, error) {
+	var builder strings.Builder
+	builder.Grow(len(b) * 2)
+	for _, c := range b {
+		if c >= 0x80 && c < 0xa0 && windows1252[c-0x80] != 0 {
+			builder.WriteRune(windows1252[c-0x80])
+		} else {
+			builder.WriteRune(rune(c))
+		}
+	}
+	return []byte(builder.String()), nil
+})
+
+// DecodeCharset converts b to a valid utf-8 string, returns the string and the detected charset.
+// b is returned as is if it's valid utf-8, otherwise decoders are tried in order and the first one producing valid text wins.
+// Latin-1 is the final fallback.
+func DecodeCharset(b []byte, decoders ...CharsetDecoder) (string, string) {
+	if utf8.Valid(b) {
+		return string(b), CharsetUTF8
+	}
+	for _, decoder := range decoders {
+		s, err := decoder.Decode(b)
+		if err != nil || !utf8.ValidString(s) || strings.ContainsRune(s, utf8.RuneError) {
+			continue
+		}
+		return s, decoder.Charset()
+	}
+	s, _ := Latin1CharsetDecoder.Decode(b)
+	return s, CharsetLatin1
+}
@@ -0,0 +1,184 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:47:37
+//
+// File Name: choker.go
+// Description:
+//
+//	Choking algorithms which decide the peers to upload to. The standard tit-for-tat is provided, others can be
+//	plugged in by implementing Choker.
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0003.html
+//		https://wiki.theory.org/BitTorrentSpecification#Choking_and_Optimistic_Unchoking
+//
+
+package transmission
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Choking defaults
+const (
+	DefaultRechokeInterval           = 10 * time.Second
+	DefaultUnchokeSlots              = 4
+	DefaultOptimisticUnchokeInterval = 30 * time.Second
+)
+
+// The newly connected peers are more likely to be optimistically unchoked, since they have nothing to upload
+const (
+	optimisticUnchokeNewPeerAge       = time.Minute
+	optimisticUnchokeNewPeerWeight    = 3
+	optimisticUnchokeNormalPeerWeight = 1
+)
+
+// ChokePeer defines the state of a peer to decide choking
+type ChokePeer struct {
+	ID           PeerConnID
+	Interested   bool      // The peer is interested in our pieces
+	DownloadRate float64   // From the peer, bytes per second
+	UploadRate   float64   // To the peer, bytes per second
+	Connected    time.Time // When the connection is established
+}
+
+// Choker decides the peers of a torrent to unchoke. It's called every rechoke interval (DefaultRechokeInterval)
+// and when peers are connected or disconnected, the peers not returned are choked.
+type Choker interface {
+	Unchoke(peers []ChokePeer, seeding bool, now time.Time) []PeerConnID
+}
+
+// ChokerFunc implements Choker by function
+type ChokerFunc func(peers []ChokePeer, seeding bool, now time.Time) []PeerConnID
+
+// Unchoke implements Choker
+func (f ChokerFunc) Unchoke(peers []ChokePeer, seeding bool, now time.Time) []PeerConnID {
+	return f(peers, seeding, now)
+}
+
+// TitForTatChoker implements the standard choking algorithm: the interested peers with the best rates (download
+// rate when downloading, upload rate when seeding) are unchoked, plus an optimistic unchoke rotated every
+// OptimisticInterval. The peers which are not interested but have better rates are also unchoked, so they can
+// start downloading at once when they become interested. It's safe for concurrent use, use one per torrent.
+type TitForTatChoker struct {
+	Slots              int           // DefaultUnchokeSlots if zero, including the optimistic unchoke
+	OptimisticInterval time.Duration // DefaultOptimisticUnchokeInterval if zero
+	Rand               *rand.Rand    // The global source is used if nil
+
+	mutex        sync.Mutex
+	optimistic   PeerConnID
+	optimisticAt time.Time
+}
+
+// NewTitForTatChoker creates a new TitForTatChoker
+func NewTitForTatChoker(slots int) *TitForTatChoker {
+	return &TitForTatChoker{Slots: slots}
+}
+
+// Unchoke implements Choker
+func (c *TitForTatChoker) Unchoke(peers []ChokePeer, seeding bool, now time.Time) []PeerConnID {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	slots := c.Slots
+	if slots <= 0 {
+		slots = DefaultUnchokeSlots
+	}
+	interval := c.OptimisticInterval
+	if interval <= 0 {
+		interval = DefaultOptimisticUnchokeInterval
+	}
+	rate := func(p *ChokePeer) float64 {
+		if seeding {
+			return p.UploadRate
+		}
+		return p.DownloadRate
+	}
+
+	sorted := make([]*ChokePeer, len(peers))
+	for i := range peers {
+		sorted[i] = &peers[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rate(sorted[i]) > rate(sorted[j])
+	})
+
+	var (
+		unchoked = make(map[PeerConnID]bool)
+		result   []PeerConnID
+		regular  = slots - 1 // One slot is for the optimistic unchoke
+		minRate  float64
+	)
+	for _, p := range sorted {
+		if !p.Interested || regular <= 0 {
+			continue
+		}
+		unchoked[p.ID] = true
+		result = append(result, p.ID)
+		minRate = rate(p)
+		regular--
+	}
+	for _, p := range sorted {
+		if !p.Interested && !unchoked[p.ID] && len(unchoked) > 0 && rate(p) > minRate {
+			unchoked[p.ID] = true
+			result = append(result, p.ID)
+		}
+	}
+
+	// Keep the optimistic unchoke in the interval if it's still choked by the rates
+	var optimistic *ChokePeer
+	for _, p := range sorted {
+		if p.ID == c.optimistic && !unchoked[p.ID] && now.Sub(c.optimisticAt) < interval {
+			optimistic = p
+		}
+	}
+	if optimistic == nil {
+		optimistic = c.pickOptimistic(sorted, unchoked, now)
+		if optimistic != nil {
+			c.optimistic, c.optimisticAt = optimistic.ID, now
+		}
+	}
+	if optimistic != nil {
+		result = append(result, optimistic.ID)
+	}
+	return result
+}
+
+// pickOptimistic picks a random choked interested peer, the newly connected peers are 3 times as likely
+func (c *TitForTatChoker) pickOptimistic(peers []*ChokePeer, unchoked map[PeerConnID]bool, now time.Time) *ChokePeer {
+	var (
+		candidates []*ChokePeer
+		weights    []int
+		total      int
+	)
+	for _, p := range peers {
+		if !p.Interested || unchoked[p.ID] {
+			continue
+		}
+		weight := optimisticUnchokeNormalPeerWeight
+		if now.Sub(p.Connected) < optimisticUnchokeNewPeerAge {
+			weight = optimisticUnchokeNewPeerWeight
+		}
+		candidates = append(candidates, p)
+		weights = append(weights, weight)
+		total += weight
+	}
+	if total == 0 {
+		return nil
+	}
+	var n int
+	if c.Rand != nil {
+		n = c.Rand.Intn(total)
+	} else {
+		n = rand.Intn(total)
+	}
+	for i, weight := range weights {
+		if n < weight {
+			return candidates[i]
+		}
+		n -= weight
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:39:25
+//
+// File Name: collection.go
+// Description:
+//
+//	Export / import torrent collections as json manifests, e.g. to migrate torrents between machines
+//
+
+package transmission
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Errors
+var (
+	ErrMalformedCollection = errors.New("Malformed collection manifest")
+)
+
+// CollectionManifestVersion defines the version of collection manifest
+const CollectionManifestVersion = 1
+
+// CollectionManifest defines the json manifest of a torrent collection
+type CollectionManifest struct {
+	Version  int                 `json:"version"`
+	Exported time.Time           `json:"exported"`
+	Torrents []CollectionTorrent `json:"torrents"`
+}
+
+// CollectionTorrent defines a torrent in collection
+type CollectionTorrent struct {
+	InfoHash    string   `json:"infoHash,omitempty"`   // Hex of v1 info hash
+	InfoHashV2  string   `json:"infoHashV2,omitempty"` // Hex of v2 info hash
+	Name        string   `json:"name,omitempty"`
+	Trackers    []string `json:"trackers,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	DownloadDir string   `json:"downloadDir,omitempty"`
+	Paused      bool     `json:"paused,omitempty"`
+}
+
+// MagnetLink returns the magnet link to re-add the torrent
+func (c *CollectionTorrent) MagnetLink() *MagnetLink {
+	var l MagnetLink
+	if c.InfoHash != "" {
+		l.Xt = append(l.Xt, Urn{"btih", c.InfoHash})
+	}
+	if c.InfoHashV2 != "" {
+		l.Xt = append(l.Xt, Urn{"btmh", "1220" + c.InfoHashV2})
+	}
+	if c.Name != "" {
+		l.Dn = []string{c.Name}
+	}
+	l.Tr = append(l.Tr, c.Trackers...)
+	return &l
+}
+
+// NewCollectionTorrent creates the collection torrent of torrent file
+func NewCollectionTorrent(t *TorrentFile) CollectionTorrent {
+	c := CollectionTorrent{Name: t.Info.Name, Trackers: t.Trackers()}
+	setCollectionInfoHashs(&c, t.InfoHashs)
+	return c
+}
+
+// CollectionFromTransmissionState creates the collection of transmission torrents (see ReadTransmissionState).
+// Torrents without info hash (neither metadata nor magnet link) are skipped.
+func CollectionFromTransmissionState(states []TransmissionTorrentState) []CollectionTorrent {
+	var torrents []CollectionTorrent
+	for _, state := range states {
+		var c CollectionTorrent
+		if state.Torrent != nil {
+			c = NewCollectionTorrent(state.Torrent)
+		} else if state.MagnetLink != nil {
+			l, err := state.MagnetLink.AsTorrent()
+			if err != nil {
+				continue
+			}
+			setCollectionInfoHashs(&c, l.InfoHashs)
+			if len(l.Dn) > 0 {
+				c.Name = l.Dn[0]
+			}
+			c.Trackers = l.Tr
+		}
+		if c.InfoHash == "" && c.InfoHashV2 == "" {
+			continue
+		}
+		if state.Resume != nil {
+			if state.Resume.Name != "" {
+				c.Name = state.Resume.Name
+			}
+			c.Labels = state.Resume.Labels
+			c.DownloadDir = state.Resume.Destination
+			c.Paused = state.Resume.Paused
+		}
+		torrents = append(torrents, c)
+	}
+	return torrents
+}
+
+func setCollectionInfoHashs(c *CollectionTorrent, infoHashs []HashValue) {
+	for _, infoHash := range infoHashs {
+		switch infoHash.Type {
+		case HashSHA1:
+			c.InfoHash = hex.EncodeToString(infoHash.Value)
+		case HashSHA256:
+			c.InfoHashV2 = hex.EncodeToString(infoHash.Value)
+		}
+	}
+}
+
+// ExportCollection writes the torrents as json manifest
+func ExportCollection(w io.Writer, torrents []CollectionTorrent) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(CollectionManifest{
+		Version:  CollectionManifestVersion,
+		Exported: time.Now().UTC().Truncate(time.Second),
+		Torrents: torrents,
+	})
+}
+
+// ImportCollection reads the json manifest written by ExportCollection, the info hashs are validated
+func ImportCollection(r io.Reader) ([]CollectionTorrent, error) {
+	var manifest CollectionManifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedCollection, err)
+	}
+	if manifest.Version < 1 || manifest.Version > CollectionManifestVersion {
+		return nil, fmt.Errorf("%w: Unsupported version [%v]", ErrMalformedCollection, manifest.Version)
+	}
+	for i, c := range manifest.Torrents {
+		if c.InfoHash == "" && c.InfoHashV2 == "" {
+			return nil, fmt.Errorf("%w: Torrent [%v] has no info hash", ErrMalformedCollection, i)
+		}
+		if b, err := hex.DecodeString(c.InfoHash); c.InfoHash != "" && (err != nil || len(b) != 20) {
+			return nil, fmt.Errorf("%w: Torrent [%v] has invalid info hash", ErrMalformedCollection, i)
+		}
+		if b, err := hex.DecodeString(c.InfoHashV2); c.InfoHashV2 != "" && (err != nil || len(b) != 32) {
+			return nil, fmt.Errorf("%w: Torrent [%v] has invalid v2 info hash", ErrMalformedCollection, i)
+		}
+	}
+	return manifest.Torrents, nil
+}
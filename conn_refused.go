@@ -0,0 +1,22 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:29:21
+//
+// File Name: conn_refused.go
+// Description:
+//
+//	Detect connection refused errors
+//
+
+//go:build !plan9
+
+package transmission
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isConnRefused checks if err is caused by a refused connection
+func isConnRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,21 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:29:21
+//
+// File Name: conn_refused_plan9.go
+// Description:
+//
+//	Detect connection refused errors, plan9 has no errno so the error string is checked
+//
+
+//go:build plan9
+
+package transmission
+
+import (
+	"strings"
+)
+
+// isConnRefused checks if err is caused by a refused connection
+func isConnRefused(err error) bool {
+	return strings.Contains(err.Error(), "connection refused")
+}
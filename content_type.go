@@ -0,0 +1,142 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:24:28
+//
+// File Name: content_type.go
+// Description:
+//
+//	Classify torrent content by file extensions and names
+//
+
+package transmission
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Content type
+const (
+	ContentTypeUnknown  = "unknown"
+	ContentTypeVideo    = "video"
+	ContentTypeAudio    = "audio"
+	ContentTypeImage    = "image"
+	ContentTypeSoftware = "software"
+	ContentTypeArchive  = "archive"
+	ContentTypeDocument = "document"
+)
+
+// contentTypeExtensions maps file extensions to content types
+var contentTypeExtensions = map[string]string{
+	// Video
+	".mkv": ContentTypeVideo, ".mp4": ContentTypeVideo, ".avi": ContentTypeVideo, ".mov": ContentTypeVideo,
+	".wmv": ContentTypeVideo, ".m4v": ContentTypeVideo, ".ts": ContentTypeVideo, ".m2ts": ContentTypeVideo,
+	".webm": ContentTypeVideo, ".flv": ContentTypeVideo, ".mpg": ContentTypeVideo, ".mpeg": ContentTypeVideo,
+	".vob": ContentTypeVideo, ".rmvb": ContentTypeVideo,
+	// Audio
+	".mp3": ContentTypeAudio, ".flac": ContentTypeAudio, ".aac": ContentTypeAudio, ".ogg": ContentTypeAudio,
+	".opus": ContentTypeAudio, ".wav": ContentTypeAudio, ".m4a": ContentTypeAudio, ".ape": ContentTypeAudio,
+	".wma": ContentTypeAudio, ".alac": ContentTypeAudio, ".dsf": ContentTypeAudio,
+	// Image
+	".jpg": ContentTypeImage, ".jpeg": ContentTypeImage, ".png": ContentTypeImage, ".gif": ContentTypeImage,
+	".webp": ContentTypeImage, ".bmp": ContentTypeImage, ".tiff": ContentTypeImage,
+	// Software
+	".exe": ContentTypeSoftware, ".msi": ContentTypeSoftware, ".dmg": ContentTypeSoftware, ".pkg": ContentTypeSoftware,
+	".deb": ContentTypeSoftware, ".rpm": ContentTypeSoftware, ".apk": ContentTypeSoftware, ".iso": ContentTypeSoftware,
+	".img": ContentTypeSoftware, ".appimage": ContentTypeSoftware, ".bin": ContentTypeSoftware,
+	// Archive
+	".zip": ContentTypeArchive, ".rar": ContentTypeArchive, ".7z": ContentTypeArchive, ".tar": ContentTypeArchive,
+	".gz": ContentTypeArchive, ".tgz": ContentTypeArchive, ".bz2": ContentTypeArchive, ".xz": ContentTypeArchive,
+	".zst": ContentTypeArchive,
+	// Document
+	".pdf": ContentTypeDocument, ".epub": ContentTypeDocument, ".mobi": ContentTypeDocument, ".azw3": ContentTypeDocument,
+	".djvu": ContentTypeDocument, ".doc": ContentTypeDocument, ".docx": ContentTypeDocument, ".cbz": ContentTypeDocument,
+	".cbr": ContentTypeDocument, ".txt": ContentTypeDocument,
+}
+
+// contentTypeKeywords maps the keywords commonly seen in release names to content types
+var contentTypeKeywords = map[string]string{
+	"1080p": ContentTypeVideo, "720p": ContentTypeVideo, "2160p": ContentTypeVideo, "4k": ContentTypeVideo,
+	"x264": ContentTypeVideo, "x265": ContentTypeVideo, "h264": ContentTypeVideo, "h265": ContentTypeVideo,
+	"hevc": ContentTypeVideo, "bluray": ContentTypeVideo, "webrip": ContentTypeVideo, "web-dl": ContentTypeVideo,
+	"hdtv": ContentTypeVideo, "dvdrip": ContentTypeVideo, "bdrip": ContentTypeVideo,
+	"mp3": ContentTypeAudio, "flac": ContentTypeAudio, "320kbps": ContentTypeAudio, "album": ContentTypeAudio,
+	"discography": ContentTypeAudio, "ost": ContentTypeAudio, "lossless": ContentTypeAudio,
+	"iso": ContentTypeSoftware, "x64": ContentTypeSoftware, "x86": ContentTypeSoftware, "crack": ContentTypeSoftware,
+	"setup": ContentTypeSoftware, "portable": ContentTypeSoftware, "linux": ContentTypeSoftware,
+	"ebook": ContentTypeDocument, "pdf": ContentTypeDocument, "epub": ContentTypeDocument, "comic": ContentTypeDocument,
+}
+
+// ContentClassification defines the classification result
+type ContentClassification struct {
+	Type       string             // One of ContentTypeXXX
+	Confidence float64            // [0, 1]
+	Scores     map[string]float64 // The share of each content type
+}
+
+// ClassifyTorrent classifies the content of torrent file by the extensions of files, weighted by file sizes
+func ClassifyTorrent(t *TorrentFile) ContentClassification {
+	weights := make(map[string]float64)
+	for _, file := range torrentFilePaths(t) {
+		if file.Padding {
+			continue
+		}
+		contentType := contentTypeExtensions[strings.ToLower(path.Ext(file.Path))]
+		if contentType == "" {
+			contentType = ContentTypeUnknown
+		}
+		length := float64(file.Length)
+		if length <= 0 {
+			length = 1
+		}
+		weights[contentType] += length
+	}
+	return newContentClassification(weights, 1)
+}
+
+var contentTypeTokenSeparator = regexp.MustCompile(`[\s._\[\]()+,]+`)
+
+// ClassifyMagnetLink classifies the content of magnet link by the extension and the words of display names and keywords.
+// It's a heuristic so the confidence is lower than ClassifyTorrent.
+func ClassifyMagnetLink(l *MagnetLink) ContentClassification {
+	weights := make(map[string]float64)
+	for _, dn := range l.Dn {
+		// An extension in display name (single file torrent) is a strong signal
+		if contentType := contentTypeExtensions[strings.ToLower(path.Ext(dn))]; contentType != "" {
+			weights[contentType] += 3
+		}
+		for _, token := range contentTypeTokenSeparator.Split(strings.ToLower(dn), -1) {
+			if contentType := contentTypeKeywords[token]; contentType != "" {
+				weights[contentType]++
+			}
+		}
+	}
+	for _, keyword := range l.Keywords() {
+		if contentType := contentTypeKeywords[keyword]; contentType != "" {
+			weights[contentType]++
+		}
+	}
+	return newContentClassification(weights, 0.8)
+}
+
+func newContentClassification(weights map[string]float64, maxConfidence float64) ContentClassification {
+	c := ContentClassification{Type: ContentTypeUnknown, Scores: make(map[string]float64)}
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return c
+	}
+	var best float64
+	for contentType, weight := range weights {
+		score := weight / total
+		c.Scores[contentType] = score
+		if contentType != ContentTypeUnknown && (score > best || (score == best && contentType < c.Type)) {
+			best = score
+			c.Type = contentType
+		}
+	}
+	c.Confidence = best * maxConfidence
+	return c
+}
@@ -0,0 +1,17 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:30:50
+//
+// File Name: cross_device_other.go
+// Description:
+//
+//	Detect renames across filesystems, not available on this platform
+//
+
+//go:build !unix && !windows
+
+package transmission
+
+// isCrossDevice checks if the rename error is caused by source and target on different filesystems
+func isCrossDevice(err error) bool {
+	return false
+}
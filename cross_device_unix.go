@@ -0,0 +1,22 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:30:50
+//
+// File Name: cross_device_unix.go
+// Description:
+//
+//	Detect renames across filesystems
+//
+
+//go:build unix
+
+package transmission
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDevice checks if the rename error is caused by source and target on different filesystems
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,25 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:30:50
+//
+// File Name: cross_device_windows.go
+// Description:
+//
+//	Detect renames across volumes
+//
+
+//go:build windows
+
+package transmission
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice defines ERROR_NOT_SAME_DEVICE returned by MoveFileEx without MOVEFILE_COPY_ALLOWED
+const errorNotSameDevice syscall.Errno = 17
+
+// isCrossDevice checks if the rename error is caused by source and target on different volumes
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
@@ -0,0 +1,107 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:31:31
+//
+// File Name: derived_cache.go
+// Description:
+//
+//	Lazily cached derived values of magnet links and torrent files.
+//
+//	The values are computed once on the first call and are safe for concurrent use. They are not updated when
+//	the fields are modified directly, call ResetCache after modifying. The caches are held by pointers, so the
+//	values stay copyable and the copies share the cache until either side calls ResetCache.
+//
+
+package transmission
+
+import (
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// magnetLinkCache defines the cached derived values of MagnetLink
+type magnetLinkCache struct {
+	canonicalOnce sync.Once
+	canonical     string
+	soOnce        sync.Once
+	so            []NumRange
+}
+
+// Canonical returns the canonical uri of magnet link (see String), which is cached
+func (l *MagnetLink) Canonical() string {
+	cache := loadCache(&l.cache)
+	cache.canonicalOnce.Do(func() {
+		cache.canonical = l.String()
+	})
+	return cache.canonical
+}
+
+// MergedSo returns the merged select only ranges (see MergeNumRanges), which is cached
+func (l *MagnetLink) MergedSo() []NumRange {
+	cache := loadCache(&l.cache)
+	cache.soOnce.Do(func() {
+		cache.so = MergeNumRanges(l.So)
+	})
+	return cache.so
+}
+
+// ResetCache drops the cached derived values, it must be called after the fields are modified
+func (l *MagnetLink) ResetCache() {
+	resetCache(&l.cache)
+}
+
+// infoHashCache defines the cached hex forms of info hashs
+type infoHashCache struct {
+	once sync.Once
+	hexs []string
+}
+
+func (c *infoHashCache) get(infoHashs []HashValue) []string {
+	c.once.Do(func() {
+		c.hexs = make([]string, len(infoHashs))
+		for i, infoHash := range infoHashs {
+			c.hexs[i] = hex.EncodeToString(infoHash.Value)
+		}
+	})
+	return c.hexs
+}
+
+// InfoHashHexs returns the hex encoded info hashs (the same order as InfoHashs), which is cached
+func (l *TorrentMagnetLink) InfoHashHexs() []string {
+	return loadCache(&l.infoHashCache).get(l.InfoHashs)
+}
+
+// ResetCache drops the cached derived values, it must be called after the fields are modified
+func (l *TorrentMagnetLink) ResetCache() {
+	l.MagnetLink.ResetCache()
+	resetCache(&l.infoHashCache)
+}
+
+// InfoHashHexs returns the hex encoded info hashs (the same order as InfoHashs), which is cached
+func (t *TorrentFile) InfoHashHexs() []string {
+	return loadCache(&t.infoHashCache).get(t.InfoHashs)
+}
+
+// ResetCache drops the cached derived values, it must be called after the fields are modified
+func (t *TorrentFile) ResetCache() {
+	resetCache(&t.infoHashCache)
+}
+
+// loadCache returns the cache p points to, which is created if nil
+func loadCache[T any](p **T) *T {
+	ptr := (*unsafe.Pointer)(unsafe.Pointer(p))
+	for {
+		if c := atomic.LoadPointer(ptr); c != nil {
+			return (*T)(c)
+		}
+		if c := new(T); atomic.CompareAndSwapPointer(ptr, nil, unsafe.Pointer(c)) {
+			return c
+		}
+	}
+}
+
+// resetCache drops the cache p points to, the readers holding it keep using the old values
+func resetCache[T any](p **T) {
+	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(p)), nil)
+}
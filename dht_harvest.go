@@ -0,0 +1,332 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:57:29
+//
+// File Name: dht_harvest.go
+// Description:
+//
+//	Info hash harvesting from DHT messages: parsing of the passively received queries (get_peers, announce_peer)
+//	and the BEP 51 sample_infohashes responses, virtual node ids, and the deduplicated, rate limited output
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0005.html
+//		https://www.bittorrent.org/beps/bep_0051.html
+//
+
+package transmission
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// DHT harvest source
+const (
+	DHTHarvestGetPeers     = "get_peers"
+	DHTHarvestAnnouncePeer = "announce_peer"
+	DHTHarvestSample       = "sample_infohashes"
+)
+
+// DHTNodeIDSize defines the size of DHT node id
+const DHTNodeIDSize = 20
+
+// Errors
+var (
+	ErrMalformedDHTMessage = errors.New("Malformed DHT message")
+)
+
+// HarvestedInfoHash defines an info hash harvested from DHT
+type HarvestedInfoHash struct {
+	InfoHash HashValue
+	Source   string // DHTHarvestXXX
+	Addr     string // The address of the node sent the message
+	Time     time.Time
+}
+
+// DHTQuery defines the info hash related fields of a DHT query
+type DHTQuery struct {
+	TransactionID string
+	Method        string // e.g. get_peers, announce_peer
+	NodeID        []byte
+	InfoHash      []byte // Set for get_peers and announce_peer
+	Port          int    // Set for announce_peer, the port of the udp source if implied_port is set
+	ImpliedPort   bool
+	ReadOnly      bool // The sender is a read-only node (BEP 43), it must not be added to the routing table
+}
+
+// ParseDHTQuery parses a KRPC query message
+func ParseDHTQuery(b []byte) (DHTQuery, error) {
+	v, err := DecodeBencode(b)
+	if err != nil {
+		return DHTQuery{}, fmt.Errorf("%w: %v", ErrMalformedDHTMessage, err)
+	}
+	msg, ok := v.(map[string]interface{})
+	if !ok || bencodeDictString(msg, "y") != "q" {
+		return DHTQuery{}, fmt.Errorf("%w: Not a query", ErrMalformedDHTMessage)
+	}
+	args := bencodeDictDict(msg, "a")
+	query := DHTQuery{
+		TransactionID: bencodeDictString(msg, "t"),
+		Method:        bencodeDictString(msg, "q"),
+		NodeID:        []byte(bencodeDictString(args, "id")),
+		InfoHash:      []byte(bencodeDictString(args, "info_hash")),
+		Port:          int(bencodeDictInt(args, "port")),
+		ImpliedPort:   bencodeDictInt(args, "implied_port") != 0,
+		ReadOnly:      bencodeDictInt(msg, "ro") != 0,
+	}
+	if len(query.NodeID) != DHTNodeIDSize {
+		return DHTQuery{}, fmt.Errorf("%w: Bad node id length [%v]", ErrMalformedDHTMessage, len(query.NodeID))
+	}
+	switch query.Method {
+	case DHTHarvestGetPeers, DHTHarvestAnnouncePeer:
+		if len(query.InfoHash) != sha1.Size {
+			return DHTQuery{}, fmt.Errorf("%w: Bad info hash length [%v]", ErrMalformedDHTMessage, len(query.InfoHash))
+		}
+	default:
+		query.InfoHash = nil
+	}
+	return query, nil
+}
+
+// DHTNode defines a DHT node of compact node info
+type DHTNode struct {
+	ID   []byte
+	Addr *net.UDPAddr
+}
+
+// DHTSamples defines a sample_infohashes response
+type DHTSamples struct {
+	NodeID   []byte
+	Interval time.Duration // The min interval before sampling the node again
+	Num      int           // The number of info hashes in the storage of the node
+	Samples  []HashValue
+	Nodes    []DHTNode // The closer nodes to the target, for traversing the keyspace
+}
+
+// ParseDHTSamples parses a sample_infohashes response message
+func ParseDHTSamples(b []byte) (DHTSamples, error) {
+	v, err := DecodeBencode(b)
+	if err != nil {
+		return DHTSamples{}, fmt.Errorf("%w: %v", ErrMalformedDHTMessage, err)
+	}
+	msg, ok := v.(map[string]interface{})
+	if !ok || bencodeDictString(msg, "y") != "r" {
+		return DHTSamples{}, fmt.Errorf("%w: Not a response", ErrMalformedDHTMessage)
+	}
+	r := bencodeDictDict(msg, "r")
+	samples := DHTSamples{
+		NodeID:   []byte(bencodeDictString(r, "id")),
+		Interval: time.Duration(bencodeDictInt(r, "interval")) * time.Second,
+		Num:      int(bencodeDictInt(r, "num")),
+	}
+	raw := bencodeDictString(r, "samples")
+	if len(raw)%sha1.Size != 0 {
+		return DHTSamples{}, fmt.Errorf("%w: Bad samples length [%v]", ErrMalformedDHTMessage, len(raw))
+	}
+	for i := 0; i < len(raw); i += sha1.Size {
+		samples.Samples = append(samples.Samples, HashValue{HashSHA1, []byte(raw[i : i+sha1.Size])})
+	}
+	if samples.Nodes, err = ParseCompactNodes([]byte(bencodeDictString(r, "nodes"))); err != nil {
+		return DHTSamples{}, err
+	}
+	return samples, nil
+}
+
+// ParseCompactNodes parses the compact IPv4 node info (20 bytes id, 4 bytes ip and 2 bytes port of each node)
+func ParseCompactNodes(b []byte) ([]DHTNode, error) {
+	return parseCompactNodes(b, net.IPv4len)
+}
+
+// ParseCompactNodes6 parses the compact IPv6 node info (20 bytes id, 16 bytes ip and 2 bytes port of each node, BEP 32)
+func ParseCompactNodes6(b []byte) ([]DHTNode, error) {
+	return parseCompactNodes(b, net.IPv6len)
+}
+
+func parseCompactNodes(b []byte, ipLen int) ([]DHTNode, error) {
+	size := DHTNodeIDSize + ipLen + 2
+	if len(b)%size != 0 {
+		return nil, fmt.Errorf("%w: Bad compact nodes length [%v]", ErrMalformedDHTMessage, len(b))
+	}
+	var nodes []DHTNode
+	for i := 0; i < len(b); i += size {
+		node := b[i : i+size]
+		nodes = append(nodes, DHTNode{
+			ID: append([]byte(nil), node[:DHTNodeIDSize]...),
+			Addr: &net.UDPAddr{
+				IP:   net.IP(append([]byte(nil), node[DHTNodeIDSize:DHTNodeIDSize+ipLen]...)),
+				Port: int(binary.BigEndian.Uint16(node[DHTNodeIDSize+ipLen:])),
+			},
+		})
+	}
+	return nodes, nil
+}
+
+// VirtualNodeIDs generates n node ids spread evenly over the keyspace (by the first 2 bytes), so a crawler joining
+// the DHT with them is close to every part of the keyspace
+func VirtualNodeIDs(n int, r *rand.Rand) [][]byte {
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	ids := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		id := make([]byte, DHTNodeIDSize)
+		r.Read(id)
+		binary.BigEndian.PutUint16(id, uint16(i*0x10000/n))
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// NeighborNodeID returns a node id sharing the first 15 bytes with target, to answer the queries of target as one
+// of the closest nodes
+func NeighborNodeID(target, self []byte) []byte {
+	id := make([]byte, DHTNodeIDSize)
+	copy(id, self)
+	copy(id, target[:min(len(target), 15)])
+	return id
+}
+
+//
+//
+//
+// Harvester
+//
+//
+//
+
+// DHTHarvestStats defines the stats of harvester
+type DHTHarvestStats struct {
+	Received    int64 // Submitted info hashes
+	Duplicates  int64 // Seen in index
+	RateLimited int64
+	Dropped     int64 // Dropped since the channel is full
+	Emitted     int64
+}
+
+// DHTHarvester deduplicates the harvested info hashes by index and emits the new ones on a channel. The info hashes
+// exceeding the rate limit, or when the channel is full, are dropped and not added to index, so they can be
+// harvested again later. It's safe for concurrent use.
+type DHTHarvester struct {
+	index     *Index
+	rateLimit float64 // Info hashes per second, unlimited if zero
+	burst     float64
+	out       chan HarvestedInfoHash
+
+	mutex    sync.Mutex
+	tokens   float64
+	last     time.Time
+	reserved int // The slots of out reserved by the submits adding to index
+	stats    DHTHarvestStats
+	closed   bool
+}
+
+// NewDHTHarvester creates a new DHTHarvester with the channel buffer size. At most rateLimit info hashes (bursts up
+// to burst) are emitted per second, unlimited if zero.
+func NewDHTHarvester(index *Index, buffer int, rateLimit float64, burst int) *DHTHarvester {
+	return &DHTHarvester{
+		index:     index,
+		rateLimit: rateLimit,
+		burst:     float64(max(burst, 1)),
+		tokens:    float64(max(burst, 1)),
+		out:       make(chan HarvestedInfoHash, buffer),
+	}
+}
+
+// C returns the channel of new info hashes, it's closed by Close
+func (h *DHTHarvester) C() <-chan HarvestedInfoHash {
+	return h.out
+}
+
+// Submit submits a harvested info hash, returns true if it's emitted. Time defaults to now if zero.
+func (h *DHTHarvester) Submit(ctx context.Context, harvested HarvestedInfoHash) (bool, error) {
+	if harvested.Time.IsZero() {
+		harvested.Time = time.Now()
+	}
+	h.mutex.Lock()
+	h.stats.Received++
+	h.mutex.Unlock()
+
+	seen, err := h.index.Seen(ctx, harvested.InfoHash)
+	if err != nil {
+		return false, err
+	}
+
+	// Reserve a slot of out, the index is not locked while adding
+	h.mutex.Lock()
+	if seen {
+		h.stats.Duplicates++
+		h.mutex.Unlock()
+		return false, nil
+	}
+	if h.closed {
+		h.mutex.Unlock()
+		return false, nil
+	}
+	if !h.take(harvested.Time) {
+		h.stats.RateLimited++
+		h.mutex.Unlock()
+		return false, nil
+	}
+	if len(h.out)+h.reserved >= cap(h.out) {
+		h.stats.Dropped++
+		h.mutex.Unlock()
+		return false, nil
+	}
+	h.reserved++
+	h.mutex.Unlock()
+
+	added, err := h.index.Add(ctx, harvested.InfoHash, IndexMeta{Source: "dht:" + harvested.Source, AddedDate: harvested.Time})
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.reserved--
+	if err != nil || !added || h.closed {
+		// Added concurrently, or closed while adding
+		return false, err
+	}
+	h.out <- harvested
+	h.stats.Emitted++
+	return true, nil
+}
+
+// Close closes the channel
+func (h *DHTHarvester) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if !h.closed {
+		h.closed = true
+		close(h.out)
+	}
+}
+
+// Stats returns the stats
+func (h *DHTHarvester) Stats() DHTHarvestStats {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.stats
+}
+
+// take takes a token of the rate limit at now
+func (h *DHTHarvester) take(now time.Time) bool {
+	if h.rateLimit <= 0 {
+		return true
+	}
+	if !h.last.IsZero() && now.After(h.last) {
+		h.tokens = min(h.burst, h.tokens+now.Sub(h.last).Seconds()*h.rateLimit)
+	}
+	if now.After(h.last) {
+		h.last = now
+	}
+	if h.tokens < 1 {
+		return false
+	}
+	h.tokens--
+	return true
+}
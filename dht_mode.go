@@ -0,0 +1,206 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:47:01
+//
+// File Name: dht_mode.go
+// Description:
+//
+//	DHT operating modes: the full (server) node answers queries, the read-only node (BEP 43) never does. The
+//	inbound queries of the server node are rate limited per source ip, and the nodes sending bad messages are banned.
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0043.html
+//
+
+package transmission
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DHTMode defines the operating mode of DHT node
+type DHTMode int
+
+// DHT mode
+const (
+	DHTModeServer   DHTMode = iota // Answers queries and is added to the routing tables of other nodes
+	DHTModeReadOnly                // Sends queries with "ro" set and never answers, e.g. behind a NAT or on mobile
+)
+
+// String returns the name of mode
+func (m DHTMode) String() string {
+	switch m {
+	case DHTModeServer:
+		return "server"
+	case DHTModeReadOnly:
+		return "read-only"
+	}
+	return fmt.Sprintf("DHTMode(%d)", int(m))
+}
+
+// DHT query guard defaults
+const (
+	DefaultDHTQueryRate    = 5.0 // Queries per second of a source ip
+	DefaultDHTQueryBurst   = 20
+	DefaultDHTBanThreshold = 5 // Bad messages in DefaultDHTBanWindow
+	DefaultDHTBanWindow    = 10 * time.Minute
+	DefaultDHTBanDuration  = time.Hour
+)
+
+// The idle sources are swept every interval
+const dhtQueryGuardSweepEvery = time.Minute
+
+// DHTQueryVerdict defines how to handle an inbound query
+type DHTQueryVerdict int
+
+// DHT query verdict
+const (
+	DHTQueryAnswer      DHTQueryVerdict = iota
+	DHTQueryReadOnly                    // Dropped silently, the node is read-only
+	DHTQueryRateLimited                 // Dropped, the source exceeds the rate limit
+	DHTQueryBanned                      // Dropped, the source is banned
+)
+
+// MarkDHTReadOnly sets "ro" of the KRPC query message, as read-only nodes must do for every query (BEP 43)
+func MarkDHTReadOnly(msg []byte) ([]byte, error) {
+	v, err := DecodeBencode(msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedDHTMessage, err)
+	}
+	dict, ok := v.(map[string]interface{})
+	if !ok || bencodeDictString(dict, "y") != "q" {
+		return nil, fmt.Errorf("%w: Not a query", ErrMalformedDHTMessage)
+	}
+	dict["ro"] = int64(1)
+	return MarshalBencode(dict)
+}
+
+// DHTQueryGuard decides the inbound queries of DHT node by the mode, the per source ip rate limit and the bans.
+// The idle sources are swept, so the memory doesn't grow with the sources seen. It's safe for concurrent use.
+type DHTQueryGuard struct {
+	Mode         DHTMode
+	Rate         float64       // DefaultDHTQueryRate if zero
+	Burst        int           // DefaultDHTQueryBurst if zero
+	BanThreshold int           // DefaultDHTBanThreshold if zero
+	BanWindow    time.Duration // DefaultDHTBanWindow if zero
+	BanDuration  time.Duration // DefaultDHTBanDuration if zero
+
+	mutex     sync.Mutex
+	sources   map[string]*dhtQuerySource
+	nextSweep time.Time
+}
+
+type dhtQuerySource struct {
+	tokens      float64
+	last        time.Time
+	bad         int
+	badSince    time.Time
+	bannedUntil time.Time
+}
+
+// NewDHTQueryGuard creates a new DHTQueryGuard
+func NewDHTQueryGuard(mode DHTMode) *DHTQueryGuard {
+	return &DHTQueryGuard{Mode: mode, sources: make(map[string]*dhtQuerySource)}
+}
+
+// Allow decides the query from the source ip at now. A query of a read-only sender (see DHTQuery.ReadOnly) may be
+// answered, but the sender must not be added to the routing table.
+func (g *DHTQueryGuard) Allow(ip string, now time.Time) DHTQueryVerdict {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.Mode == DHTModeReadOnly {
+		return DHTQueryReadOnly
+	}
+	g.sweep(now)
+	source := g.source(ip, now)
+	if now.Before(source.bannedUntil) {
+		return DHTQueryBanned
+	}
+	rate, burst := g.Rate, float64(g.Burst)
+	if rate <= 0 {
+		rate = DefaultDHTQueryRate
+	}
+	if burst <= 0 {
+		burst = DefaultDHTQueryBurst
+	}
+	if now.After(source.last) {
+		source.tokens = min(burst, source.tokens+now.Sub(source.last).Seconds()*rate)
+		source.last = now
+	}
+	if source.tokens < 1 {
+		return DHTQueryRateLimited
+	}
+	source.tokens--
+	return DHTQueryAnswer
+}
+
+// ReportBad reports a bad message from the source ip, e.g. a malformed message, a wrong token of announce_peer or
+// a reply with bogus nodes. Returns true if the source is banned.
+func (g *DHTQueryGuard) ReportBad(ip string, now time.Time) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	threshold, window, duration := g.BanThreshold, g.BanWindow, g.BanDuration
+	if threshold <= 0 {
+		threshold = DefaultDHTBanThreshold
+	}
+	if window <= 0 {
+		window = DefaultDHTBanWindow
+	}
+	if duration <= 0 {
+		duration = DefaultDHTBanDuration
+	}
+	source := g.source(ip, now)
+	if source.bad == 0 || now.Sub(source.badSince) > window {
+		source.bad, source.badSince = 0, now
+	}
+	source.bad++
+	if source.bad >= threshold {
+		source.bad = 0
+		source.bannedUntil = now.Add(duration)
+	}
+	return now.Before(source.bannedUntil)
+}
+
+// Banned checks if the source ip is banned at now, e.g. to ignore its replies and drop it from the routing table
+func (g *DHTQueryGuard) Banned(ip string, now time.Time) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	source, ok := g.sources[ip]
+	return ok && now.Before(source.bannedUntil)
+}
+
+func (g *DHTQueryGuard) source(ip string, now time.Time) *dhtQuerySource {
+	if g.sources == nil {
+		g.sources = make(map[string]*dhtQuerySource)
+	}
+	source, ok := g.sources[ip]
+	if !ok {
+		burst := g.Burst
+		if burst <= 0 {
+			burst = DefaultDHTQueryBurst
+		}
+		source = &dhtQuerySource{tokens: float64(burst), last: now}
+		g.sources[ip] = source
+	}
+	return source
+}
+
+// sweep removes the sources which are idle for the sweep interval and neither banned nor have recent bad messages
+func (g *DHTQueryGuard) sweep(now time.Time) {
+	if now.Before(g.nextSweep) {
+		return
+	}
+	g.nextSweep = now.Add(dhtQueryGuardSweepEvery)
+	window := g.BanWindow
+	if window <= 0 {
+		window = DefaultDHTBanWindow
+	}
+	for ip, source := range g.sources {
+		if !now.Before(source.bannedUntil) && (source.bad == 0 || now.Sub(source.badSince) > window) &&
+			now.Sub(source.last) >= dhtQueryGuardSweepEvery {
+			delete(g.sources, ip)
+		}
+	}
+}
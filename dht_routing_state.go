@@ -0,0 +1,182 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:46:22
+//
+// File Name: dht_routing_state.go
+// Description:
+//
+//	Persistent DHT routing table: the node id and the known nodes are saved to disk and restored on startup, the
+//	stale nodes are evicted, so restarts don't need a full bootstrap. The file is compatible with the dht.dat of
+//	transmission (id, nodes and nodes6), the last seen times are kept in extra keys.
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0005.html
+//		https://www.bittorrent.org/beps/bep_0032.html
+//		https://github.com/transmission/transmission/blob/3.00/libtransmission/tr-dht.c
+//
+
+package transmission
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DHT routing state defaults
+const (
+	DHTStateFileName     = "dht.dat"      // In the config dir of transmission
+	DefaultDHTNodeMaxAge = 24 * time.Hour // The nodes not seen longer are evicted on loading
+)
+
+// Errors
+var (
+	ErrMalformedDHTState = errors.New("Malformed DHT state")
+)
+
+// DHTRoutingNode defines a node of the routing table
+type DHTRoutingNode struct {
+	DHTNode
+	LastSeen time.Time // When the last reply of the node is received
+}
+
+// DHTRoutingState defines the persistent state of a DHT node
+type DHTRoutingState struct {
+	NodeID []byte
+	Nodes  []DHTRoutingNode
+}
+
+// MarshalBinary encodes the state as a bencoded dict. The IPv4 and IPv6 nodes are in nodes and nodes6 (the compact
+// node info), the last seen unix times (4 bytes each, in the same order) in seen and seen6.
+func (s DHTRoutingState) MarshalBinary() ([]byte, error) {
+	if len(s.NodeID) != DHTNodeIDSize {
+		return nil, fmt.Errorf("%w: Bad node id length [%v]", ErrMalformedDHTState, len(s.NodeID))
+	}
+	var nodes, nodes6, seen, seen6 []byte
+	for _, node := range s.Nodes {
+		if len(node.ID) != DHTNodeIDSize || node.Addr == nil {
+			continue
+		}
+		if ip := node.Addr.IP.To4(); ip != nil {
+			nodes = appendCompactNode(nodes, node.ID, ip, node.Addr.Port)
+			seen = binary.BigEndian.AppendUint32(seen, uint32(max(node.LastSeen.Unix(), 0)))
+		} else if ip := node.Addr.IP.To16(); ip != nil {
+			nodes6 = appendCompactNode(nodes6, node.ID, ip, node.Addr.Port)
+			seen6 = binary.BigEndian.AppendUint32(seen6, uint32(max(node.LastSeen.Unix(), 0)))
+		}
+	}
+	dict := map[string][]byte{"id": s.NodeID, "nodes": nodes, "nodes6": nodes6, "seen": seen, "seen6": seen6}
+	for key, value := range dict {
+		if len(value) == 0 {
+			delete(dict, key)
+		}
+	}
+	return MarshalBencode(dict)
+}
+
+// ParseDHTRoutingState parses the state encoded by MarshalBinary. The nodes without last seen times (e.g. saved by
+// transmission) are seen at defaultSeen.
+func ParseDHTRoutingState(b []byte, defaultSeen time.Time) (DHTRoutingState, error) {
+	v, err := DecodeBencode(b)
+	if err != nil {
+		return DHTRoutingState{}, fmt.Errorf("%w: %v", ErrMalformedDHTState, err)
+	}
+	dict, ok := v.(map[string]interface{})
+	if !ok {
+		return DHTRoutingState{}, fmt.Errorf("%w: Not a dict", ErrMalformedDHTState)
+	}
+	state := DHTRoutingState{NodeID: []byte(bencodeDictString(dict, "id"))}
+	if len(state.NodeID) != DHTNodeIDSize {
+		return DHTRoutingState{}, fmt.Errorf("%w: Bad node id length [%v]", ErrMalformedDHTState, len(state.NodeID))
+	}
+	for _, family := range []struct {
+		nodes, seen string
+		ipLen       int
+	}{{"nodes", "seen", net.IPv4len}, {"nodes6", "seen6", net.IPv6len}} {
+		nodes, err := parseCompactNodes([]byte(bencodeDictString(dict, family.nodes)), family.ipLen)
+		if err != nil {
+			return DHTRoutingState{}, fmt.Errorf("%w: [%v] %v", ErrMalformedDHTState, family.nodes, err)
+		}
+		seen := []byte(bencodeDictString(dict, family.seen))
+		for i, node := range nodes {
+			lastSeen := defaultSeen
+			if len(seen) >= (i+1)*4 {
+				lastSeen = time.Unix(int64(binary.BigEndian.Uint32(seen[i*4:])), 0)
+			}
+			state.Nodes = append(state.Nodes, DHTRoutingNode{node, lastSeen})
+		}
+	}
+	return state, nil
+}
+
+// Evict drops the nodes not seen in maxAge before now (DefaultDHTNodeMaxAge if zero) and returns the number of them
+func (s *DHTRoutingState) Evict(maxAge time.Duration, now time.Time) int {
+	if maxAge <= 0 {
+		maxAge = DefaultDHTNodeMaxAge
+	}
+	var (
+		nodes   []DHTRoutingNode
+		evicted int
+	)
+	for _, node := range s.Nodes {
+		if now.Sub(node.LastSeen) > maxAge {
+			evicted++
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	s.Nodes = nodes
+	return evicted
+}
+
+// SaveDHTRoutingState writes the state to path atomically
+func SaveDHTRoutingState(path string, s DHTRoutingState) error {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".dht.*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// LoadDHTRoutingState reads the state from path and evicts the stale nodes (see Evict). The nodes without last
+// seen times are seen at the modification time of the file. Returns an error satisfying errors.Is(err,
+// fs.ErrNotExist) if there's no saved state, a new node id should be generated then.
+func LoadDHTRoutingState(path string, maxAge time.Duration, now time.Time) (DHTRoutingState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return DHTRoutingState{}, err
+	}
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	state, err := ParseDHTRoutingState(data, modTime)
+	if err != nil {
+		return DHTRoutingState{}, fmt.Errorf("%w [%v]", err, path)
+	}
+	state.Evict(maxAge, now)
+	return state, nil
+}
+
+func appendCompactNode(b, id []byte, ip net.IP, port int) []byte {
+	b = append(b, id...)
+	b = append(b, ip...)
+	return binary.BigEndian.AppendUint16(b, uint16(port))
+}
@@ -0,0 +1,212 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:13:48
+//
+// File Name: diagnose.go
+// Description:
+//
+//	Self diagnostics: disk write test, blocklist freshness, clock skew and peer port reachability
+//
+
+package transmission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Diagnostic defaults
+const (
+	DefaultPortCheckURL     = "https://portcheck.transmissionbt.com/" // The port is appended, replies 1 if reachable
+	DefaultMaxClockSkew     = 30 * time.Second
+	DefaultBlocklistMaxAge  = 7 * 24 * time.Hour
+	DefaultTransmissionPort = 51413
+)
+
+// DiagnosticStatus defines the status of a diagnostic check
+type DiagnosticStatus string
+
+// Diagnostic status
+const (
+	DiagnosticOK      DiagnosticStatus = "ok"
+	DiagnosticWarning DiagnosticStatus = "warning"
+	DiagnosticFailed  DiagnosticStatus = "failed"
+	DiagnosticSkipped DiagnosticStatus = "skipped"
+)
+
+// DiagnosticCheck defines a named check, Run returns the status and a human readable detail
+type DiagnosticCheck struct {
+	Name string
+	Run  func(ctx context.Context) (DiagnosticStatus, string)
+}
+
+// DiagnosticResult defines the result of a check
+type DiagnosticResult struct {
+	Name     string           `json:"name"`
+	Status   DiagnosticStatus `json:"status"`
+	Detail   string           `json:"detail,omitempty"`
+	Duration time.Duration    `json:"duration"`
+}
+
+// Diagnose runs the checks concurrently and returns the results in the order of checks
+func Diagnose(ctx context.Context, checks []DiagnosticCheck) []DiagnosticResult {
+	results := make([]DiagnosticResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check DiagnosticCheck) {
+			defer wg.Done()
+			start := time.Now()
+			status, detail := check.Run(ctx)
+			results[i] = DiagnosticResult{Name: check.Name, Status: status, Detail: detail, Duration: time.Since(start)}
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// DiagnosticsHealthy returns false if any check failed, warnings and skipped checks are healthy
+func DiagnosticsHealthy(results []DiagnosticResult) bool {
+	for _, r := range results {
+		if r.Status == DiagnosticFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// TransmissionDiagnosticChecks returns the checks of a transmission daemon by its config dir and settings: the
+// download and incomplete dirs are writable, the blocklist is fresh if enabled, the clock and the peer port are
+// checked by the transmission port check service. The client is http.DefaultClient if nil.
+func TransmissionDiagnosticChecks(configDir string, s *TransmissionSettings, client *http.Client) []DiagnosticCheck {
+	var checks []DiagnosticCheck
+	if s.DownloadDir != nil {
+		checks = append(checks, DiskWriteCheck("download-dir", *s.DownloadDir))
+	}
+	if s.IncompleteDir != nil && s.IncompleteDirEnabled != nil && *s.IncompleteDirEnabled {
+		checks = append(checks, DiskWriteCheck("incomplete-dir", *s.IncompleteDir))
+	}
+	if s.BlocklistEnabled != nil && *s.BlocklistEnabled {
+		checks = append(checks, BlocklistCheck(configDir, DefaultBlocklistMaxAge))
+	}
+	checks = append(checks, ClockSkewCheck(client, DefaultPortCheckURL, DefaultMaxClockSkew))
+	port := DefaultTransmissionPort
+	if s.PeerPort != nil {
+		port = *s.PeerPort
+	}
+	if s.PeerPortRandomOnStart != nil && *s.PeerPortRandomOnStart {
+		// The port in settings is not the one in use
+		checks = append(checks, DiagnosticCheck{Name: "peer-port", Run: func(ctx context.Context) (DiagnosticStatus, string) {
+			return DiagnosticSkipped, "Random port on start"
+		}})
+	} else {
+		checks = append(checks, PortReachabilityCheck(client, DefaultPortCheckURL, port))
+	}
+	return checks
+}
+
+// DiskWriteCheck checks that a file can be written, synced and removed in dir
+func DiskWriteCheck(name, dir string) DiagnosticCheck {
+	return DiagnosticCheck{Name: name, Run: func(ctx context.Context) (DiagnosticStatus, string) {
+		f, err := os.CreateTemp(dir, ".diagnose-*")
+		if err != nil {
+			return DiagnosticFailed, err.Error()
+		}
+		defer os.Remove(f.Name())
+		_, err = f.Write(make([]byte, 4096))
+		if err == nil {
+			err = f.Sync()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return DiagnosticFailed, err.Error()
+		}
+		return DiagnosticOK, dir
+	}}
+}
+
+// BlocklistCheck checks the compiled blocklists (blocklists/*.bin) in transmission's config dir were updated within
+// maxAge
+func BlocklistCheck(configDir string, maxAge time.Duration) DiagnosticCheck {
+	return DiagnosticCheck{Name: "blocklist", Run: func(ctx context.Context) (DiagnosticStatus, string) {
+		files, err := filepath.Glob(filepath.Join(configDir, "blocklists", "*.bin"))
+		if err != nil {
+			return DiagnosticFailed, err.Error()
+		}
+		var updated time.Time
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return DiagnosticFailed, err.Error()
+			}
+			if info.ModTime().After(updated) {
+				updated = info.ModTime()
+			}
+		}
+		if updated.IsZero() {
+			return DiagnosticWarning, "No blocklist"
+		}
+		age := time.Since(updated)
+		if age > maxAge {
+			return DiagnosticWarning, fmt.Sprintf("Updated %v ago", age.Round(time.Hour))
+		}
+		return DiagnosticOK, fmt.Sprintf("Updated at %v", updated.Format(time.RFC3339))
+	}}
+}
+
+// ClockSkewCheck compares the local clock with the Date header of url. The skew matters to tracker announces and
+// TLS certificate validation.
+func ClockSkewCheck(client *http.Client, url string, maxSkew time.Duration) DiagnosticCheck {
+	return DiagnosticCheck{Name: "clock", Run: func(ctx context.Context) (DiagnosticStatus, string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return DiagnosticFailed, err.Error()
+		}
+		start := time.Now()
+		resp, err := httpClientOrDefault(client).Do(req)
+		if err != nil {
+			return DiagnosticSkipped, err.Error()
+		}
+		resp.Body.Close()
+		end := time.Now()
+		date, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return DiagnosticSkipped, "No date in response"
+		}
+		// The date has a resolution of one second, compare it with the middle of the request
+		skew := start.Add(end.Sub(start) / 2).Sub(date)
+		if skew.Abs() > maxSkew+time.Second {
+			return DiagnosticWarning, fmt.Sprintf("Clock skew %v", skew.Round(time.Second))
+		}
+		return DiagnosticOK, fmt.Sprintf("Clock skew %v", skew.Round(time.Second))
+	}}
+}
+
+// PortReachabilityCheck checks the peer port is reachable from the internet by a port check service (see
+// TestPortReachable)
+func PortReachabilityCheck(client *http.Client, checkURL string, port int) DiagnosticCheck {
+	return DiagnosticCheck{Name: "peer-port", Run: func(ctx context.Context) (DiagnosticStatus, string) {
+		reachable, err := TestPortReachable(ctx, client, checkURL, port)
+		if err != nil {
+			return DiagnosticSkipped, err.Error()
+		}
+		if !reachable {
+			return DiagnosticFailed, fmt.Sprintf("Port %v is not reachable", port)
+		}
+		return DiagnosticOK, fmt.Sprintf("Port %v is reachable", port)
+	}}
+}
+
+// httpClientOrDefault returns client or http.DefaultClient if nil
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
@@ -0,0 +1,221 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:45:38
+//
+// File Name: endgame.go
+// Description:
+//
+//	Endgame mode: once every block is requested, the outstanding blocks are requested from more peers so a slow peer
+//	doesn't stall the last pieces, and the duplicates are cancelled when a block is received
+//
+//	Reference:
+//
+//		https://wiki.theory.org/BitTorrentSpecification#End_Game
+//
+
+package transmission
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Endgame defaults
+const (
+	DefaultEndgameMaxRequesters = 3 // The peers a block is requested from at most
+)
+
+// PeerBlockRequest defines a block request sent to a peer
+type PeerBlockRequest struct {
+	Peer    PeerConnID
+	Request BlockRequest
+}
+
+// EndgameStats defines the stats of endgame mode
+type EndgameStats struct {
+	Active            bool
+	Entered           time.Time // Zero if not entered
+	Received          int64     // Blocks received in endgame mode
+	DuplicateRequests int64     // Requests of the blocks already requested from another peer
+	Cancels           int64     // Cancel messages of the duplicates
+	WastedBlocks      int64     // Blocks received after another peer delivered them
+	WastedBytes       int64
+}
+
+// Efficiency returns the share of the received blocks in endgame mode which are not wasted, 1 if none is received
+func (s EndgameStats) Efficiency() float64 {
+	if s.Received+s.WastedBlocks == 0 {
+		return 1
+	}
+	return float64(s.Received) / float64(s.Received+s.WastedBlocks)
+}
+
+// Endgame does the bookkeeping of the outstanding block requests of a torrent and decides the duplicate requests
+// in endgame mode, which is entered once no block is left to request (see Update). Every sent request must be
+// recorded by Requested, every received block by Received and every dropped request (rejected, or choked without
+// the fast extension, or disconnected) by Drop or RemovePeer. It's safe for concurrent use.
+type Endgame struct {
+	MaxRequesters int // DefaultEndgameMaxRequesters if zero
+
+	mutex       sync.Mutex
+	outstanding map[BlockRequest]map[PeerConnID]bool
+	stats       EndgameStats
+}
+
+// NewEndgame creates a new Endgame
+func NewEndgame() *Endgame {
+	return &Endgame{outstanding: make(map[BlockRequest]map[PeerConnID]bool)}
+}
+
+// Update enters endgame mode if unrequested (the blocks of the wanted pieces which are neither received nor
+// requested) is zero and blocks are outstanding, and leaves it once new blocks are to request (e.g. a piece failed
+// the hash check or a file is wanted again)
+func (e *Endgame) Update(unrequested int, now time.Time) bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	switch {
+	case !e.stats.Active && unrequested == 0 && len(e.outstanding) > 0:
+		e.stats.Active, e.stats.Entered = true, now
+	case e.stats.Active && unrequested > 0:
+		e.stats.Active = false
+	}
+	return e.stats.Active
+}
+
+// Active checks if in endgame mode
+func (e *Endgame) Active() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.stats.Active
+}
+
+// Requested records a request sent to peer
+func (e *Endgame) Requested(peer PeerConnID, request BlockRequest) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.request(peer, request)
+}
+
+// Duplicates returns at most n outstanding blocks to request from peer in endgame mode and records them as
+// requested. The blocks with the fewest requesters come first, has tells if the peer has the piece. Nothing is
+// returned if not in endgame mode.
+func (e *Endgame) Duplicates(peer PeerConnID, has func(piece int) bool, n int) []BlockRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if !e.stats.Active || n <= 0 {
+		return nil
+	}
+	maxRequesters := e.MaxRequesters
+	if maxRequesters <= 0 {
+		maxRequesters = DefaultEndgameMaxRequesters
+	}
+	var candidates []BlockRequest
+	for request, peers := range e.outstanding {
+		if !peers[peer] && len(peers) < maxRequesters && (has == nil || has(int(request.Piece))) {
+			candidates = append(candidates, request)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if len(e.outstanding[a]) != len(e.outstanding[b]) {
+			return len(e.outstanding[a]) < len(e.outstanding[b])
+		}
+		if a.Piece != b.Piece {
+			return a.Piece < b.Piece
+		}
+		return a.Begin < b.Begin
+	})
+	candidates = candidates[:min(n, len(candidates))]
+	for _, request := range candidates {
+		e.request(peer, request)
+		e.stats.DuplicateRequests++
+	}
+	return candidates
+}
+
+// Received records a block received from peer and returns the requests of the block to cancel on the other peers
+func (e *Endgame) Received(peer PeerConnID, request BlockRequest) []PeerBlockRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	peers, ok := e.outstanding[request]
+	if !ok {
+		// Delivered by another peer, the cancel didn't arrive in time
+		e.stats.WastedBlocks++
+		e.stats.WastedBytes += int64(request.Length)
+		return nil
+	}
+	delete(e.outstanding, request)
+	if e.stats.Active {
+		e.stats.Received++
+	}
+	var cancels []PeerBlockRequest
+	for other := range peers {
+		if other != peer {
+			cancels = append(cancels, PeerBlockRequest{other, request})
+		}
+	}
+	sort.Slice(cancels, func(i, j int) bool { return cancels[i].Peer < cancels[j].Peer })
+	e.stats.Cancels += int64(len(cancels))
+	return cancels
+}
+
+// Drop drops the request of peer, e.g. rejected by the peer
+func (e *Endgame) Drop(peer PeerConnID, request BlockRequest) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.drop(peer, request)
+}
+
+// RemovePeer drops the requests of the disconnected (or choking) peer and returns the blocks requested from no
+// other peer, which are to request again
+func (e *Endgame) RemovePeer(peer PeerConnID) []BlockRequest {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	var orphans []BlockRequest
+	for request, peers := range e.outstanding {
+		if peers[peer] && e.drop(peer, request) {
+			orphans = append(orphans, request)
+		}
+	}
+	return orphans
+}
+
+// Outstanding returns the number of blocks requested but not received
+func (e *Endgame) Outstanding() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return len(e.outstanding)
+}
+
+// Stats returns the stats
+func (e *Endgame) Stats() EndgameStats {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.stats
+}
+
+func (e *Endgame) request(peer PeerConnID, request BlockRequest) {
+	if e.outstanding == nil {
+		e.outstanding = make(map[BlockRequest]map[PeerConnID]bool)
+	}
+	peers, ok := e.outstanding[request]
+	if !ok {
+		peers = make(map[PeerConnID]bool)
+		e.outstanding[request] = peers
+	}
+	peers[peer] = true
+}
+
+// drop drops the request of peer, returns true if the block is requested from no peer now
+func (e *Endgame) drop(peer PeerConnID, request BlockRequest) bool {
+	peers, ok := e.outstanding[request]
+	if !ok || !peers[peer] {
+		return false
+	}
+	delete(peers, peer)
+	if len(peers) == 0 {
+		delete(e.outstanding, request)
+		return true
+	}
+	return false
+}
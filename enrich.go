@@ -0,0 +1,248 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:58:20
+//
+// File Name: enrich.go
+// Description:
+//
+//	Metadata enrichment pipeline: fetches the metadata of harvested info hashes, classifies the content and stores
+//	the summaries to the sinks
+//
+
+package transmission
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default enrichment settings
+const (
+	DefaultEnrichConcurrency = 8
+	DefaultEnrichRetries     = 3
+	DefaultEnrichRetryDelay  = time.Minute // Doubled on each retry
+)
+
+// MetadataFetcher fetches the metadata of an info hash, e.g. by ut_metadata from peers or from torrent caches
+type MetadataFetcher interface {
+	FetchMetadata(ctx context.Context, infoHash HashValue) (*TorrentFile, error)
+}
+
+// MetadataFetcherFunc implements MetadataFetcher by a function
+type MetadataFetcherFunc func(ctx context.Context, infoHash HashValue) (*TorrentFile, error)
+
+// FetchMetadata implements MetadataFetcher
+func (f MetadataFetcherFunc) FetchMetadata(ctx context.Context, infoHash HashValue) (*TorrentFile, error) {
+	return f(ctx, infoHash)
+}
+
+// NewTorrentCacheFetcher creates a MetadataFetcher downloading torrent files from the torrent caches (which must be
+// configured by WithTorrentFetchCacheURLsOption) by FetchTorrentFromSources
+func NewTorrentCacheFetcher(opts ...TorrentFetchOption) MetadataFetcher {
+	return MetadataFetcherFunc(func(ctx context.Context, infoHash HashValue) (*TorrentFile, error) {
+		urn := Urn{"btih", hex.EncodeToString(infoHash.Value)}
+		if infoHash.Type == HashSHA256 {
+			urn = Urn{"btmh", "1220" + hex.EncodeToString(infoHash.Value)}
+		}
+		l := MagnetLink{Xt: []Urn{urn}, Xs: []string{"urn:" + urn.Nid + ":" + urn.Nss}}
+		return FetchTorrentFromSources(ctx, &l, opts...)
+	})
+}
+
+// EnrichedTorrent defines the summary of an enriched info hash
+type EnrichedTorrent struct {
+	Harvested      HarvestedInfoHash
+	Torrent        *TorrentFile
+	Description    TorrentDescription
+	Classification ContentClassification
+}
+
+// EnrichmentSink stores the enriched torrents, e.g. to a database or a search index
+type EnrichmentSink interface {
+	Store(ctx context.Context, t EnrichedTorrent) error
+}
+
+// EnrichmentSinkFunc implements EnrichmentSink by a function
+type EnrichmentSinkFunc func(ctx context.Context, t EnrichedTorrent) error
+
+// Store implements EnrichmentSink
+func (f EnrichmentSinkFunc) Store(ctx context.Context, t EnrichedTorrent) error {
+	return f(ctx, t)
+}
+
+// NewSearchIndexSink creates an EnrichmentSink adding the name, size, files and content type (as keyword) of
+// torrents to the search index
+func NewSearchIndexSink(index *SearchIndex) EnrichmentSink {
+	return EnrichmentSinkFunc(func(ctx context.Context, t EnrichedTorrent) error {
+		entry := IndexEntry{
+			InfoHash: t.Harvested.InfoHash,
+			IndexMeta: IndexMeta{
+				Name:       t.Description.Name,
+				Size:       t.Description.TotalLength,
+				MagnetLink: t.Torrent.MagnetLink().String(),
+				Source:     t.Harvested.Source,
+				AddedDate:  t.Harvested.Time,
+			},
+		}
+		for _, file := range t.Description.Files {
+			entry.Files = append(entry.Files, file.Path)
+		}
+		if t.Classification.Type != "" {
+			entry.Keywords = []string{strings.ToLower(t.Classification.Type)}
+		}
+		index.Add(entry)
+		return nil
+	})
+}
+
+// EnrichmentStats defines the stats of enrichment pipeline
+type EnrichmentStats struct {
+	Enriched   int64
+	Retries    int64
+	Failed     int64 // Run out of the retry budget
+	SinkErrors int64
+}
+
+// EnrichmentPipeline fetches the metadata of the info hashes with limited concurrency. A failed fetch is retried
+// after a delay (doubled each time) until the retry budget of the info hash runs out. The enriched torrent is
+// stored to all sinks, the failures of sinks are counted but not retried.
+type EnrichmentPipeline struct {
+	Fetcher     MetadataFetcher
+	Sinks       []EnrichmentSink
+	Concurrency int           // DefaultEnrichConcurrency if zero
+	Retries     int           // DefaultEnrichRetries if zero, negative for no retry
+	RetryDelay  time.Duration // DefaultEnrichRetryDelay if zero
+
+	mutex sync.Mutex
+	stats EnrichmentStats
+}
+
+type enrichJob struct {
+	Harvested HarvestedInfoHash
+	Attempt   int
+}
+
+// Run enriches the info hashes from in until in is closed and all info hashes are done, or ctx is done
+func (p *EnrichmentPipeline) Run(ctx context.Context, in <-chan HarvestedInfoHash) error {
+	concurrency := p.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEnrichConcurrency
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		jobs    = make(chan enrichJob)
+		pending sync.WaitGroup
+		workers sync.WaitGroup
+	)
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case job := <-jobs:
+					p.enrich(runCtx, job, jobs, &pending)
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	// Dispatch
+	func() {
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case harvested, ok := <-in:
+				if !ok {
+					return
+				}
+				pending.Add(1)
+				select {
+				case jobs <- enrichJob{Harvested: harvested}:
+				case <-runCtx.Done():
+					pending.Done()
+					return
+				}
+			}
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-runCtx.Done():
+	}
+	// Stop the workers and the scheduled retries
+	cancel()
+	workers.Wait()
+	return ctx.Err()
+}
+
+// Stats returns the stats
+func (p *EnrichmentPipeline) Stats() EnrichmentStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.stats
+}
+
+// enrich runs the job, and schedules the retry on failure
+func (p *EnrichmentPipeline) enrich(ctx context.Context, job enrichJob, jobs chan<- enrichJob, pending *sync.WaitGroup) {
+	t, err := p.Fetcher.FetchMetadata(ctx, job.Harvested.InfoHash)
+	if err != nil {
+		retries := p.Retries
+		if retries == 0 {
+			retries = DefaultEnrichRetries
+		}
+		if job.Attempt >= retries || ctx.Err() != nil {
+			p.mutex.Lock()
+			p.stats.Failed++
+			p.mutex.Unlock()
+			pending.Done()
+			return
+		}
+		delay := p.RetryDelay
+		if delay <= 0 {
+			delay = DefaultEnrichRetryDelay
+		}
+		delay <<= job.Attempt
+		job.Attempt++
+		p.mutex.Lock()
+		p.stats.Retries++
+		p.mutex.Unlock()
+		time.AfterFunc(delay, func() {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				pending.Done()
+			}
+		})
+		return
+	}
+
+	enriched := EnrichedTorrent{
+		Harvested:      job.Harvested,
+		Torrent:        t,
+		Description:    t.Description(),
+		Classification: ClassifyTorrent(t),
+	}
+	var sinkErrors int64
+	for _, sink := range p.Sinks {
+		if err := sink.Store(ctx, enriched); err != nil {
+			sinkErrors++
+		}
+	}
+	p.mutex.Lock()
+	p.stats.Enriched++
+	p.stats.SinkErrors += sinkErrors
+	p.mutex.Unlock()
+	pending.Done()
+}
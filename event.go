@@ -0,0 +1,238 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:18:06
+//
+// File Name: event.go
+// Description:
+//
+//	Event bus for torrent lifecycle notifications
+//
+
+package transmission
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event type
+const (
+	EventTorrentAdded      = "torrent-added"
+	EventMetadataReceived  = "metadata-received"
+	EventPieceVerified     = "piece-verified"
+	EventTrackerError      = "tracker-error"
+	EventPeerConnected     = "peer-connected"
+	EventDownloadComplete  = "download-complete"
+	EventSeedLimitReached  = "seed-limit-reached"
+	EventListenPortChanged = "listen-port-changed"
+)
+
+// Event defines the event interface
+type Event interface {
+	EventType() string
+	EventTime() time.Time
+	EventTorrent() EventTorrent
+}
+
+// EventTorrent defines the torrent which the event is about
+type EventTorrent struct {
+	ID          string // The id of the torrent in the engine or daemon
+	InfoHash    HashValue
+	Name        string
+	DownloadDir string
+	Labels      []string
+	Trackers    []string
+	Files       []TorrentFileEntry
+}
+
+// EventHeader defines the common fields of events
+type EventHeader struct {
+	Time    time.Time
+	Torrent EventTorrent
+}
+
+// EventTime returns the time of event
+func (h EventHeader) EventTime() time.Time {
+	return h.Time
+}
+
+// EventTorrent returns the torrent of event
+func (h EventHeader) EventTorrent() EventTorrent {
+	return h.Torrent
+}
+
+// TorrentAddedEvent is emitted when a torrent is added
+type TorrentAddedEvent struct {
+	EventHeader
+}
+
+// EventType returns EventTorrentAdded
+func (TorrentAddedEvent) EventType() string { return EventTorrentAdded }
+
+// MetadataReceivedEvent is emitted when the metadata of a magnet link is received
+type MetadataReceivedEvent struct {
+	EventHeader
+	Metadata *TorrentFile
+}
+
+// EventType returns EventMetadataReceived
+func (MetadataReceivedEvent) EventType() string { return EventMetadataReceived }
+
+// PieceVerifiedEvent is emitted when a piece is verified
+type PieceVerifiedEvent struct {
+	EventHeader
+	Piece int
+	OK    bool // False if the piece failed hash check
+}
+
+// EventType returns EventPieceVerified
+func (PieceVerifiedEvent) EventType() string { return EventPieceVerified }
+
+// TrackerErrorEvent is emitted when an announce fails
+type TrackerErrorEvent struct {
+	EventHeader
+	Tracker string
+	Err     error
+}
+
+// EventType returns EventTrackerError
+func (TrackerErrorEvent) EventType() string { return EventTrackerError }
+
+// PeerConnectedEvent is emitted when a peer is connected
+type PeerConnectedEvent struct {
+	EventHeader
+	Addr   string
+	PeerID []byte
+}
+
+// EventType returns EventPeerConnected
+func (PeerConnectedEvent) EventType() string { return EventPeerConnected }
+
+// DownloadCompleteEvent is emitted when all wanted pieces are downloaded
+type DownloadCompleteEvent struct {
+	EventHeader
+}
+
+// EventType returns EventDownloadComplete
+func (DownloadCompleteEvent) EventType() string { return EventDownloadComplete }
+
+// SeedLimitReachedEvent is emitted when a seeding torrent is stopped by a seed limit
+type SeedLimitReachedEvent struct {
+	EventHeader
+	Reason string // SeedLimitReasonXXX
+}
+
+// EventType returns EventSeedLimitReached
+func (SeedLimitReachedEvent) EventType() string { return EventSeedLimitReached }
+
+// ListenPortChangedEvent is emitted when the external listen port or its reachability changes. It's not about a
+// torrent, the torrent of header is empty.
+type ListenPortChangedEvent struct {
+	EventHeader
+	Status               ListenPortStatus
+	PreviousExternalPort int
+}
+
+// EventType returns EventListenPortChanged
+func (ListenPortChangedEvent) EventType() string { return EventListenPortChanged }
+
+// EventBus dispatches events to subscribers
+type EventBus struct {
+	mutex       sync.RWMutex
+	nextID      int
+	subscribers map[int]*eventSubscriber
+	dropped     uint64
+}
+
+type eventSubscriber struct {
+	Types map[string]bool // Nil means all types
+	C     chan Event
+	F     func(Event)
+}
+
+func (s *eventSubscriber) accept(e Event) bool {
+	return s.Types == nil || s.Types[e.EventType()]
+}
+
+// NewEventBus creates a new EventBus
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[int]*eventSubscriber)}
+}
+
+// Subscribe subscribes the events of types (all types if empty) by a channel with buffer size.
+// Events are dropped if the channel is full. Call the returned function to unsubscribe, which closes the channel.
+func (b *EventBus) Subscribe(buffer int, types ...string) (<-chan Event, func()) {
+	c := make(chan Event, buffer)
+	return c, b.subscribe(&eventSubscriber{Types: eventTypeSet(types), C: c})
+}
+
+// SubscribeFunc subscribes the events of types (all types if empty) by a callback.
+// The callback is called synchronously in Publish so it should not block.
+func (b *EventBus) SubscribeFunc(f func(Event), types ...string) func() {
+	return b.subscribe(&eventSubscriber{Types: eventTypeSet(types), F: f})
+}
+
+func eventTypeSet(types []string) map[string]bool {
+	if len(types) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(types))
+	for _, t := range types {
+		set[t] = true
+	}
+	return set
+}
+
+func (b *EventBus) subscribe(s *eventSubscriber) func() {
+	b.mutex.Lock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[int]*eventSubscriber)
+	}
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = s
+	b.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, id)
+			b.mutex.Unlock()
+			if s.C != nil {
+				close(s.C)
+			}
+		})
+	}
+}
+
+// Publish publishes the event to subscribers
+func (b *EventBus) Publish(e Event) {
+	var funcs []func(Event)
+	b.mutex.RLock()
+	for _, s := range b.subscribers {
+		if !s.accept(e) {
+			continue
+		}
+		if s.F != nil {
+			funcs = append(funcs, s.F)
+			continue
+		}
+		select {
+		case s.C <- e:
+		default:
+			atomic.AddUint64(&b.dropped, 1)
+		}
+	}
+	b.mutex.RUnlock()
+
+	// Callbacks are called without lock so they're able to (un)subscribe
+	for _, f := range funcs {
+		f(e)
+	}
+}
+
+// Dropped returns the number of events dropped because of full channels
+func (b *EventBus) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
@@ -0,0 +1,254 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:16:05
+//
+// File Name: extension.go
+// Description:
+//
+//	Extension protocol (BEP 10): the extended handshake, the registry of local extensions and typed codecs of
+//	custom extended messages
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0010.html
+//
+
+package transmission
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Extension protocol message id
+const (
+	ExtendedMessageID   = 20 // The peer message id of extended messages
+	ExtendedHandshakeID = 0  // The extended message id of the handshake
+)
+
+// Errors
+var (
+	ErrExtensionRegistered   = errors.New("Extension is already registered")
+	ErrExtensionNotSupported = errors.New("Extension is not supported by peer")
+)
+
+// ExtensionHandshake defines the extended handshake. Versions is not defined by BEP 10, it carries the versions of
+// the extensions in M which have more than one version, peers not knowing it ignore it.
+type ExtensionHandshake struct {
+	M            map[string]int `bencode:"m"` // Extension name to extended message id, zero means disabled
+	V            string         `bencode:"v,omitempty"`
+	P            int            `bencode:"p,omitempty"`
+	Reqq         int            `bencode:"reqq,omitempty"`
+	YourIP       []byte         `bencode:"yourip,omitempty"`
+	MetadataSize int64          `bencode:"metadata_size,omitempty"`
+	Versions     map[string]int `bencode:"ext_versions,omitempty"`
+}
+
+// MarshalBinary encodes the handshake as an extended message with the length prefix
+func (h ExtensionHandshake) MarshalBinary() ([]byte, error) {
+	if h.M == nil {
+		// m is required
+		h.M = map[string]int{}
+	}
+	payload, err := MarshalBencode(h)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalExtendedMessage(ExtendedHandshakeID, payload), nil
+}
+
+// ParseExtensionHandshake parses the payload of the extended handshake
+func ParseExtensionHandshake(payload []byte) (ExtensionHandshake, error) {
+	var h ExtensionHandshake
+	if err := UnmarshalBencode(payload, &h); err != nil {
+		return h, fmt.Errorf("%w: Extended handshake [%v]", ErrMalformedPeerMessage, err)
+	}
+	return h, nil
+}
+
+// MarshalExtendedMessage encodes an extended message with the length prefix
+func MarshalExtendedMessage(id byte, payload []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(2+len(payload)))
+	b = append(b, ExtendedMessageID, id)
+	return append(b, payload...)
+}
+
+// ParseExtendedMessage parses the message (id and payload, without the length prefix) and returns the extended
+// message id and its payload. The id is the one registered locally, see ExtensionRegistry.Name.
+func ParseExtendedMessage(b []byte) (byte, []byte, error) {
+	if len(b) < 2 || b[0] != ExtendedMessageID {
+		return 0, nil, fmt.Errorf("%w: Not an extended message", ErrMalformedPeerMessage)
+	}
+	return b[1], b[2:], nil
+}
+
+// ExtensionRegistry assigns the local extended message ids of extensions. The ids are advertised by Handshake, peers
+// send the messages of an extension with its local id.
+type ExtensionRegistry struct {
+	mutex    sync.Mutex
+	names    []string // Indexed by id - 1
+	versions map[string]int
+}
+
+// NewExtensionRegistry creates a new ExtensionRegistry
+func NewExtensionRegistry() *ExtensionRegistry {
+	return &ExtensionRegistry{versions: make(map[string]int)}
+}
+
+// Register registers an extension with its version (at least 1) and returns its local id
+func (r *ExtensionRegistry) Register(name string, version int) (byte, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.versions[name]; ok {
+		return 0, fmt.Errorf("%w: [%v]", ErrExtensionRegistered, name)
+	}
+	if len(r.names) >= 255 {
+		return 0, fmt.Errorf("%w: Too many extensions [%v]", ErrExtensionRegistered, name)
+	}
+	r.names = append(r.names, name)
+	r.versions[name] = max(version, 1)
+	return byte(len(r.names)), nil
+}
+
+// Name returns the extension of the local id
+func (r *ExtensionRegistry) Name(id byte) (string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if id == ExtendedHandshakeID || int(id) > len(r.names) {
+		return "", false
+	}
+	return r.names[id-1], true
+}
+
+// Handshake returns the extended handshake advertising the registered extensions, the caller sets the other fields
+func (r *ExtensionRegistry) Handshake() ExtensionHandshake {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	h := ExtensionHandshake{M: make(map[string]int, len(r.names))}
+	for i, name := range r.names {
+		h.M[name] = i + 1
+		if version := r.versions[name]; version > 1 {
+			if h.Versions == nil {
+				h.Versions = make(map[string]int)
+			}
+			h.Versions[name] = version
+		}
+	}
+	return h
+}
+
+// PeerExtensions defines the extensions a peer supports by its extended handshake
+type PeerExtensions struct {
+	IDs      map[string]byte // The ids to send the messages of extensions to the peer
+	Versions map[string]int
+}
+
+// NewPeerExtensions creates PeerExtensions by the extended handshake of peer. Handshakes update the extensions, so
+// a later handshake replaces the previous one.
+func NewPeerExtensions(h ExtensionHandshake) *PeerExtensions {
+	p := &PeerExtensions{IDs: make(map[string]byte, len(h.M)), Versions: make(map[string]int, len(h.M))}
+	for name, id := range h.M {
+		if id <= 0 || id > 255 {
+			// Disabled
+			continue
+		}
+		p.IDs[name] = byte(id)
+		p.Versions[name] = max(h.Versions[name], 1)
+	}
+	return p
+}
+
+// Names returns the sorted names of the supported extensions
+func (p *PeerExtensions) Names() []string {
+	names := make([]string, 0, len(p.IDs))
+	for name := range p.IDs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExtensionCodec encodes and decodes the messages of a custom extension as bencoded T, see MarshalBencode for the
+// struct tags. Keys added in newer versions should be omitempty, older peers ignore unknown keys.
+type ExtensionCodec[T any] struct {
+	Name    string
+	Version int
+	ID      byte // The local id
+}
+
+// RegisterExtension registers the extension to registry and returns its codec
+func RegisterExtension[T any](r *ExtensionRegistry, name string, version int) (*ExtensionCodec[T], error) {
+	id, err := r.Register(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtensionCodec[T]{Name: name, Version: max(version, 1), ID: id}, nil
+}
+
+// Negotiate returns the version to talk with peer, the lower one of both sides, zero if peer doesn't support it
+func (c *ExtensionCodec[T]) Negotiate(peer *PeerExtensions) int {
+	if _, ok := peer.IDs[c.Name]; !ok {
+		return 0
+	}
+	return min(c.Version, peer.Versions[c.Name])
+}
+
+// Marshal encodes msg as an extended message to peer with the length prefix
+func (c *ExtensionCodec[T]) Marshal(peer *PeerExtensions, msg T) ([]byte, error) {
+	id, ok := peer.IDs[c.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: [%v]", ErrExtensionNotSupported, c.Name)
+	}
+	payload, err := MarshalBencode(msg)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalExtendedMessage(id, payload), nil
+}
+
+// Unmarshal decodes the payload of an extended message with the local id of the codec
+func (c *ExtensionCodec[T]) Unmarshal(payload []byte) (T, error) {
+	var msg T
+	if err := UnmarshalBencode(payload, &msg); err != nil {
+		return msg, fmt.Errorf("%w: [%v] [%v]", ErrMalformedPeerMessage, c.Name, err)
+	}
+	return msg, nil
+}
+
+//
+//
+//
+// Example extension
+//
+//
+//
+
+// ExtensionPing defines the name of the ping extension, an example of custom extensions. Version 1 replies the
+// sequence number, version 2 adds the send time so both sides can measure the round trip.
+const (
+	ExtensionPing        = "gt_ping"
+	ExtensionPingVersion = 2
+)
+
+// PingMessage defines the message of the ping extension
+type PingMessage struct {
+	Seq  int64 `bencode:"seq"`
+	Pong bool  `bencode:"pong,omitempty"` // A reply
+	Time int64 `bencode:"t,omitempty"`    // Version 2, the unix time in milliseconds of the ping
+}
+
+// RegisterPingExtension registers the ping extension to registry
+func RegisterPingExtension(r *ExtensionRegistry) (*ExtensionCodec[PingMessage], error) {
+	return RegisterExtension[PingMessage](r, ExtensionPing, ExtensionPingVersion)
+}
+
+// PingReply returns the reply of ping in the negotiated version
+func PingReply(ping PingMessage, version int) PingMessage {
+	reply := PingMessage{Seq: ping.Seq, Pong: true}
+	if version >= 2 {
+		reply.Time = ping.Time
+	}
+	return reply
+}
@@ -0,0 +1,151 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:49:49
+//
+// File Name: fast_extension.go
+// Description:
+//
+//	Fast extension (BEP 6): messages, allowed fast set and the request handling on choke
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0006.html
+//
+
+package transmission
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Fast extension message id
+const (
+	FastMessageSuggestPiece  = 0x0D
+	FastMessageHaveAll       = 0x0E
+	FastMessageHaveNone      = 0x0F
+	FastMessageRejectRequest = 0x10
+	FastMessageAllowedFast   = 0x11
+)
+
+// DefaultAllowedFastCount defines the default size of allowed fast set
+const DefaultAllowedFastCount = 10
+
+// Errors
+var (
+	ErrMalformedPeerMessage = errors.New("Malformed peer message")
+)
+
+// BlockRequest defines a block request
+type BlockRequest struct {
+	Piece  uint32
+	Begin  uint32
+	Length uint32
+}
+
+// FastMessage defines a fast extension message. Piece is set for suggest piece and allowed fast, Request for
+// reject request.
+type FastMessage struct {
+	ID      byte
+	Piece   uint32
+	Request BlockRequest
+}
+
+// MarshalBinary encodes the message with the length prefix
+func (m FastMessage) MarshalBinary() ([]byte, error) {
+	var payload []byte
+	switch m.ID {
+	case FastMessageHaveAll, FastMessageHaveNone:
+	case FastMessageSuggestPiece, FastMessageAllowedFast:
+		payload = binary.BigEndian.AppendUint32(payload, m.Piece)
+	case FastMessageRejectRequest:
+		payload = binary.BigEndian.AppendUint32(payload, m.Request.Piece)
+		payload = binary.BigEndian.AppendUint32(payload, m.Request.Begin)
+		payload = binary.BigEndian.AppendUint32(payload, m.Request.Length)
+	default:
+		return nil, fmt.Errorf("%w: Not a fast extension message [%v]", ErrMalformedPeerMessage, m.ID)
+	}
+	b := binary.BigEndian.AppendUint32(nil, uint32(1+len(payload)))
+	b = append(b, m.ID)
+	return append(b, payload...), nil
+}
+
+// ParseFastMessage parses the message (id and payload, without the length prefix)
+func ParseFastMessage(b []byte) (FastMessage, error) {
+	if len(b) == 0 {
+		return FastMessage{}, fmt.Errorf("%w: Empty message", ErrMalformedPeerMessage)
+	}
+	m, payload := FastMessage{ID: b[0]}, b[1:]
+	var size int
+	switch m.ID {
+	case FastMessageHaveAll, FastMessageHaveNone:
+		size = 0
+	case FastMessageSuggestPiece, FastMessageAllowedFast:
+		size = 4
+	case FastMessageRejectRequest:
+		size = 12
+	default:
+		return FastMessage{}, fmt.Errorf("%w: Not a fast extension message [%v]", ErrMalformedPeerMessage, m.ID)
+	}
+	if len(payload) != size {
+		return FastMessage{}, fmt.Errorf("%w: Bad payload length of message [%v]", ErrMalformedPeerMessage, m.ID)
+	}
+	switch size {
+	case 4:
+		m.Piece = binary.BigEndian.Uint32(payload)
+	case 12:
+		m.Request = BlockRequest{
+			Piece:  binary.BigEndian.Uint32(payload),
+			Begin:  binary.BigEndian.Uint32(payload[4:]),
+			Length: binary.BigEndian.Uint32(payload[8:]),
+		}
+	}
+	return m, nil
+}
+
+// AllowedFastSet generates the canonical allowed fast set of k pieces for the peer ip. Only IPv4 is defined by
+// BEP 6, nil is returned for IPv6.
+func AllowedFastSet(infoHash []byte, ip net.IP, pieceCount, k int) []int {
+	ip4 := ip.To4()
+	if ip4 == nil || pieceCount <= 0 {
+		return nil
+	}
+	k = min(k, pieceCount)
+	x := append([]byte{ip4[0], ip4[1], ip4[2], 0}, infoHash...)
+	var (
+		set  = make([]int, 0, k)
+		seen = make(map[int]bool, k)
+	)
+	for len(set) < k {
+		sum := sha1.Sum(x)
+		x = sum[:]
+		for i := 0; i < 5 && len(set) < k; i++ {
+			index := int(binary.BigEndian.Uint32(x[i*4:]) % uint32(pieceCount))
+			if !seen[index] {
+				seen[index] = true
+				set = append(set, index)
+			}
+		}
+	}
+	return set
+}
+
+// RejectOnChoke splits the pending requests of a peer when choking it. With the fast extension the requests are
+// not discarded implicitly: the requests of the allowed fast pieces are kept and served, the others must be
+// rejected by reject request messages.
+func RejectOnChoke(pending []BlockRequest, allowedFast []int) (keep, reject []BlockRequest) {
+	allowed := make(map[uint32]bool, len(allowedFast))
+	for _, piece := range allowedFast {
+		allowed[uint32(piece)] = true
+	}
+	for _, request := range pending {
+		if allowed[request.Piece] {
+			keep = append(keep, request)
+		} else {
+			reject = append(reject, request)
+		}
+	}
+	return keep, reject
+}
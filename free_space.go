@@ -0,0 +1,127 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:05:50
+//
+// File Name: free_space.go
+// Description:
+//
+//	Check the free space of the download directory before adding torrents
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Errors
+var (
+	ErrInsufficientSpace = errors.New("Insufficient disk space")
+	ErrFreeSpace         = errors.New("Failed to get free space")
+)
+
+// InsufficientSpaceError defines the error of insufficient space, it unwraps to ErrInsufficientSpace
+type InsufficientSpaceError struct {
+	Dir       string
+	Required  int64 // The content size plus the reserve
+	Available int64
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	return fmt.Sprintf("%v: [%v] requires %v, %v available", ErrInsufficientSpace, e.Dir, FormatSize(e.Required),
+		FormatSize(e.Available))
+}
+
+// Unwrap returns ErrInsufficientSpace
+func (e *InsufficientSpaceError) Unwrap() error {
+	return ErrInsufficientSpace
+}
+
+// FreeSpace returns the bytes available to the user of the filesystem of dir
+func FreeSpace(dir string) (int64, error) {
+	n, err := freeSpace(dir)
+	if err != nil {
+		return 0, fmt.Errorf("%w: [%v] [%v]", ErrFreeSpace, dir, err)
+	}
+	return n, nil
+}
+
+// SpaceGate checks the free space before adding torrents. On ErrInsufficientSpace the caller refuses the torrent or
+// queues it (e.g. adds it paused) to retry later.
+type SpaceGate struct {
+	// FreeSpace returns the free space of dir, FreeSpace of the local filesystem if nil. Set it to ask a daemon,
+	// e.g. by the free-space method of transmission rpc.
+	FreeSpace func(ctx context.Context, dir string) (int64, error)
+	// Reserve is the space kept free after adding
+	Reserve int64
+	// Reclaim is called with the missing bytes when the space is insufficient, e.g. to remove finished torrents by
+	// RemovePolicy. The space is checked again if it returns true.
+	Reclaim func(ctx context.Context, dir string, missing int64) (bool, error)
+}
+
+// Check checks if dir has size bytes free (plus the reserve). A non-positive size (unknown) always passes.
+func (g *SpaceGate) Check(ctx context.Context, dir string, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	required := size + g.Reserve
+	for reclaimed := false; ; reclaimed = true {
+		var (
+			available int64
+			err       error
+		)
+		if g.FreeSpace != nil {
+			available, err = g.FreeSpace(ctx, dir)
+		} else {
+			available, err = FreeSpace(dir)
+		}
+		if err != nil {
+			return err
+		}
+		if available >= required {
+			return nil
+		}
+		insufficient := &InsufficientSpaceError{Dir: dir, Required: required, Available: available}
+		// Reclaim once
+		if reclaimed || g.Reclaim == nil {
+			return insufficient
+		}
+		ok, err := g.Reclaim(ctx, dir, required-available)
+		if err != nil {
+			return fmt.Errorf("%w [%v]", insufficient, err)
+		}
+		if !ok {
+			return insufficient
+		}
+	}
+}
+
+// CheckTorrent checks the space of the contents of torrent, padding files are not counted
+func (g *SpaceGate) CheckTorrent(ctx context.Context, dir string, t *TorrentFile) error {
+	return g.Check(ctx, dir, TorrentContentSize(t))
+}
+
+// CheckMagnetLink checks the space by the exact length (xl) of magnet link, the largest one if there're many. The
+// check passes if there's no xl, the caller should check again by CheckTorrent when the metadata is fetched.
+func (g *SpaceGate) CheckMagnetLink(ctx context.Context, dir string, l *MagnetLink) error {
+	var size int64
+	for _, xl := range l.Xl {
+		size = max(size, int64(xl))
+	}
+	return g.Check(ctx, dir, size)
+}
+
+// TorrentContentSize returns the total length of the files of torrent written to disk, without padding files
+func TorrentContentSize(t *TorrentFile) int64 {
+	if len(t.Info.Files) == 0 {
+		return t.Info.Length
+	}
+	var size int64
+	for _, file := range t.Info.Files {
+		if !file.IsPadding() {
+			size += file.Length
+		}
+	}
+	return size
+}
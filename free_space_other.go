@@ -0,0 +1,21 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:05:50
+//
+// File Name: free_space_other.go
+// Description:
+//
+//	Free space is not available on this platform
+//
+
+//go:build !linux && !darwin && !freebsd && !windows
+
+package transmission
+
+import (
+	"errors"
+)
+
+// freeSpace returns an error, SpaceGate.FreeSpace must be set on this platform
+func freeSpace(dir string) (int64, error) {
+	return 0, errors.New("Not supported on this platform")
+}
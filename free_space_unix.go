@@ -0,0 +1,25 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:05:50
+//
+// File Name: free_space_unix.go
+// Description:
+//
+//	Free space by statfs
+//
+
+//go:build linux || darwin || freebsd
+
+package transmission
+
+import (
+	"syscall"
+)
+
+// freeSpace returns the bytes available to unprivileged users of the filesystem of dir
+func freeSpace(dir string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), nil
+}
@@ -0,0 +1,32 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:05:50
+//
+// File Name: free_space_windows.go
+// Description:
+//
+//	Free space by GetDiskFreeSpaceExW
+//
+
+//go:build windows
+
+package transmission
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// freeSpace returns the bytes available to the caller of the volume of dir
+func freeSpace(dir string) (int64, error) {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if r, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&available)), 0, 0); r == 0 {
+		return 0, err
+	}
+	return int64(available), nil
+}
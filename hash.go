@@ -7,6 +7,16 @@
 
 package transmission
 
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // Hash type
 const (
 	HashSHA1   = "sha1"
@@ -18,3 +28,65 @@ type HashValue struct {
 	Type  string
 	Value []byte
 }
+
+// Info hash encoding
+const (
+	InfoHashEncodingHex    = "hex"    // Lower case hex
+	InfoHashEncodingBase32 = "base32" // Upper case base32 (with padding for sha256)
+)
+
+// Errors
+var (
+	ErrInvalidInfoHash = errors.New("Invalid info hash")
+)
+
+// InfoHashFromHex decodes the hex (case insensitive) of 20 bytes (sha1) or 32 bytes (sha256) info hash
+func InfoHashFromHex(s string) (HashValue, error) {
+	var hashType string
+	switch len(s) {
+	case 2 * sha1.Size:
+		hashType = HashSHA1
+	case 2 * sha256.Size:
+		hashType = HashSHA256
+	default:
+		return HashValue{}, fmt.Errorf("%w: Bad hex length [%v]", ErrInvalidInfoHash, len(s))
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return HashValue{}, fmt.Errorf("%w: %v", ErrInvalidInfoHash, err)
+	}
+	return HashValue{hashType, b}, nil
+}
+
+// InfoHashFromBase32 decodes the base32 (case insensitive) of 20 bytes (sha1) or 32 bytes (sha256, padding is
+// optional) info hash
+func InfoHashFromBase32(s string) (HashValue, error) {
+	var (
+		hashType string
+		encoding = base32.StdEncoding
+	)
+	switch len(s) {
+	case base32.StdEncoding.EncodedLen(sha1.Size):
+		hashType = HashSHA1
+	case base32.StdEncoding.EncodedLen(sha256.Size):
+		hashType = HashSHA256
+	case base32.StdEncoding.WithPadding(base32.NoPadding).EncodedLen(sha256.Size):
+		hashType = HashSHA256
+		encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+	default:
+		return HashValue{}, fmt.Errorf("%w: Bad base32 length [%v]", ErrInvalidInfoHash, len(s))
+	}
+	b, err := encoding.DecodeString(strings.ToUpper(s))
+	if err != nil {
+		return HashValue{}, fmt.Errorf("%w: %v", ErrInvalidInfoHash, err)
+	}
+	return HashValue{hashType, b}, nil
+}
+
+// InfoHashString encodes the info hash by encoding (InfoHashEncodingXXX), hex is used for unknown encodings
+func (h HashValue) InfoHashString(encoding string) string {
+	if encoding == InfoHashEncodingBase32 {
+		return base32.StdEncoding.EncodeToString(h.Value)
+	}
+	return hex.EncodeToString(h.Value)
+}
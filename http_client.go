@@ -0,0 +1,87 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:24:02
+//
+// File Name: http_client.go
+// Description:
+//
+//	Customized http client for tracker requests
+//
+
+package transmission
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrInvalidHTTPClientOptions = errors.New("Invalid http client options")
+)
+
+// HTTPClientOptions defines the options to create http client.
+// Several private trackers require a specific user agent.
+type HTTPClientOptions struct {
+	UserAgent string
+	Headers   http.Header   // Extra headers added to every request
+	Proxy     string        // Proxy url, http://, https:// or socks5://. Environment proxy settings are used if empty
+	TLSConfig *tls.Config   // Custom tls config, e.g. client certificates or InsecureSkipVerify
+	Timeout   time.Duration // Timeout of the whole request, zero means no timeout
+	Resolver  *Resolver     // Resolves and dials hosts (see Resolver), the default dialer is used if nil
+}
+
+// NewHTTPClient creates a new http client by options
+func NewHTTPClient(opts HTTPClientOptions) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%w: Invalid proxy [%v]", ErrInvalidHTTPClientOptions, err)
+		}
+		switch strings.ToLower(proxyURL.Scheme) {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("%w: Unsupported proxy scheme [%v]", ErrInvalidHTTPClientOptions, proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig
+	}
+	if opts.Resolver != nil {
+		transport.DialContext = opts.Resolver.DialContext
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if opts.UserAgent != "" || len(opts.Headers) > 0 {
+		roundTripper = &headerRoundTripper{transport, opts.UserAgent, opts.Headers.Clone()}
+	}
+	return &http.Client{Transport: roundTripper, Timeout: opts.Timeout}, nil
+}
+
+// headerRoundTripper sets user agent and headers of requests
+type headerRoundTripper struct {
+	Base      http.RoundTripper
+	UserAgent string
+	Headers   http.Header
+}
+
+func (t *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper must not modify the request
+	req = req.Clone(req.Context())
+	for key, values := range t.Headers {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if t.UserAgent != "" {
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+	return t.Base.RoundTrip(req)
+}
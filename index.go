@@ -0,0 +1,292 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:55:51
+//
+// File Name: index.go
+// Description:
+//
+//	Deduplication index of seen info hashes for crawlers and feed processors. A bloom filter answers most lookups of
+//	unseen hashes in memory, the store is only queried on positives.
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Default index settings
+const (
+	DefaultIndexExpectedItems     = 1 << 20
+	DefaultIndexFalsePositiveRate = 0.01
+)
+
+// Errors
+var (
+	ErrIndexEntryNotFound = errors.New("Index entry not found")
+)
+
+// IndexMeta defines the metadata of an indexed torrent
+type IndexMeta struct {
+	Name       string
+	Size       int64
+	Files      []string // File paths joined by "/"
+	Keywords   []string // Lowercase keywords, e.g. from kt
+	MagnetLink string
+	Source     string // Where the hash was seen, e.g. feed url
+	AddedDate  time.Time
+}
+
+// IndexEntry defines an entry of index
+type IndexEntry struct {
+	InfoHash HashValue
+	IndexMeta
+}
+
+// IndexStore defines the persistent backing of Index. Entries are keyed by the info hash value.
+type IndexStore interface {
+	// Has checks if the info hash exists
+	Has(ctx context.Context, infoHash HashValue) (bool, error)
+	// Put inserts the entry if the info hash doesn't exist, returns true if inserted
+	Put(ctx context.Context, entry IndexEntry) (bool, error)
+	// Get returns ErrIndexEntryNotFound if the info hash doesn't exist
+	Get(ctx context.Context, infoHash HashValue) (*IndexEntry, error)
+	// Range calls f with all entries until f returns an error, which is returned
+	Range(ctx context.Context, f func(entry IndexEntry) error) error
+}
+
+// IndexStats defines the stats of index
+type IndexStats struct {
+	Items          int64 // Items added to the bloom filter
+	Lookups        int64 // Seen calls
+	BloomNegatives int64 // Seen calls answered by the bloom filter
+	FalsePositives int64 // Store lookups of the bloom filter positives which are not found
+}
+
+// Index answers whether info hashes are seen. It's safe for concurrent use.
+type Index struct {
+	store IndexStore
+
+	mutex sync.Mutex
+	bloom *BloomFilter
+	stats IndexStats
+}
+
+// NewIndex creates a new Index and loads the existing entries of store into the bloom filter sized by expected
+// items (DefaultIndexExpectedItems if zero) and false positive rate (DefaultIndexFalsePositiveRate if zero). The
+// false positive rate grows when the items exceed expected ones, which costs more store lookups but is still correct.
+func NewIndex(ctx context.Context, store IndexStore, expected int, falsePositiveRate float64) (*Index, error) {
+	if expected <= 0 {
+		expected = DefaultIndexExpectedItems
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = DefaultIndexFalsePositiveRate
+	}
+	index := &Index{store: store, bloom: NewBloomFilter(expected, falsePositiveRate)}
+	if err := store.Range(ctx, func(entry IndexEntry) error {
+		index.bloom.Add(entry.InfoHash.Value)
+		index.stats.Items++
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// Store returns the store
+func (index *Index) Store() IndexStore {
+	return index.store
+}
+
+// Seen checks if the info hash is added
+func (index *Index) Seen(ctx context.Context, infoHash HashValue) (bool, error) {
+	index.mutex.Lock()
+	index.stats.Lookups++
+	if !index.bloom.Test(infoHash.Value) {
+		index.stats.BloomNegatives++
+		index.mutex.Unlock()
+		return false, nil
+	}
+	index.mutex.Unlock()
+
+	ok, err := index.store.Has(ctx, infoHash)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		index.mutex.Lock()
+		index.stats.FalsePositives++
+		index.mutex.Unlock()
+	}
+	return ok, nil
+}
+
+// Add adds the info hash with meta, returns true if it's not seen before. The meta of a seen info hash is not updated.
+func (index *Index) Add(ctx context.Context, infoHash HashValue, meta IndexMeta) (bool, error) {
+	if meta.AddedDate.IsZero() {
+		meta.AddedDate = time.Now()
+	}
+	added, err := index.store.Put(ctx, IndexEntry{infoHash, meta})
+	if err != nil {
+		return false, err
+	}
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if added {
+		index.stats.Items++
+	}
+	index.bloom.Add(infoHash.Value)
+	return added, nil
+}
+
+// Stats returns the stats
+func (index *Index) Stats() IndexStats {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	return index.stats
+}
+
+//
+//
+//
+// Bloom filter
+//
+//
+//
+
+// BloomFilter is a bloom filter of byte strings. It's not safe for concurrent use.
+type BloomFilter struct {
+	bits   []uint64
+	hashes int
+}
+
+// NewBloomFilter creates a new BloomFilter sized for n items with the false positive rate p
+func NewBloomFilter(n int, p float64) *BloomFilter {
+	n = max(n, 1)
+	m := int(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), hashes: max(k, 1)}
+}
+
+// Add adds the key
+func (f *BloomFilter) Add(key []byte) {
+	h1, h2 := bloomHashes(key)
+	m := uint64(len(f.bits) * 64)
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test returns false if the key is definitely not added
+func (f *BloomFilter) Test(key []byte) bool {
+	h1, h2 := bloomHashes(key)
+	m := uint64(len(f.bits) * 64)
+	for i := 0; i < f.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes returns the two hashes of double hashing
+func bloomHashes(key []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(key)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	var h1, h2 uint64
+	for i := 0; i < 8; i++ {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[8+i])
+	}
+	return h1, h2 | 1
+}
+
+//
+//
+//
+// Memory index store
+//
+//
+//
+
+// MemoryIndexStore implements in-memory IndexStore
+type MemoryIndexStore struct {
+	mutex   sync.Mutex
+	entries map[string]IndexEntry // Hex of info hash -> entry
+}
+
+// NewMemoryIndexStore creates a new MemoryIndexStore
+func NewMemoryIndexStore() *MemoryIndexStore {
+	return &MemoryIndexStore{entries: make(map[string]IndexEntry)}
+}
+
+// Has implements IndexStore
+func (s *MemoryIndexStore) Has(ctx context.Context, infoHash HashValue) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.entries[infoHash.InfoHashString(InfoHashEncodingHex)]
+	return ok, nil
+}
+
+// Put implements IndexStore
+func (s *MemoryIndexStore) Put(ctx context.Context, entry IndexEntry) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]IndexEntry)
+	}
+	key := entry.InfoHash.InfoHashString(InfoHashEncodingHex)
+	if _, ok := s.entries[key]; ok {
+		return false, nil
+	}
+	s.entries[key] = cloneIndexEntry(entry)
+	return true, nil
+}
+
+// Get implements IndexStore
+func (s *MemoryIndexStore) Get(ctx context.Context, infoHash HashValue) (*IndexEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[infoHash.InfoHashString(InfoHashEncodingHex)]
+	if !ok {
+		return nil, ErrIndexEntryNotFound
+	}
+	entry = cloneIndexEntry(entry)
+	return &entry, nil
+}
+
+// Range implements IndexStore, the entries are ordered by hex of info hash
+func (s *MemoryIndexStore) Range(ctx context.Context, f func(entry IndexEntry) error) error {
+	s.mutex.Lock()
+	entries := make([]IndexEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, cloneIndexEntry(entry))
+	}
+	s.mutex.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].InfoHash.InfoHashString(InfoHashEncodingHex) < entries[j].InfoHash.InfoHashString(InfoHashEncodingHex)
+	})
+	for _, entry := range entries {
+		if err := f(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cloneIndexEntry(entry IndexEntry) IndexEntry {
+	entry.InfoHash.Value = append([]byte(nil), entry.InfoHash.Value...)
+	entry.Files = append([]string(nil), entry.Files...)
+	entry.Keywords = append([]string(nil), entry.Keywords...)
+	return entry
+}
@@ -0,0 +1,150 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:55:51
+//
+// File Name: index_sql.go
+// Description:
+//
+//	SQLite backed IndexStore by database/sql, the driver is registered by the caller
+//
+
+package transmission
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+var sqliteIndexStoreSchema = []string{
+	`CREATE TABLE IF NOT EXISTS torrent_index (
+		info_hash   TEXT PRIMARY KEY,
+		hash_type   TEXT NOT NULL,
+		name        TEXT NOT NULL DEFAULT '',
+		size        INTEGER NOT NULL DEFAULT 0,
+		files       TEXT NOT NULL DEFAULT '[]',
+		keywords    TEXT NOT NULL DEFAULT '[]',
+		magnet_link TEXT NOT NULL DEFAULT '',
+		source      TEXT NOT NULL DEFAULT '',
+		added_date  INTEGER NOT NULL DEFAULT 0
+	)`,
+}
+
+const sqliteIndexColumns = `info_hash, hash_type, name, size, files, keywords, magnet_link, source, added_date`
+
+// SQLiteIndexStore implements IndexStore by a sqlite database. The info hash is stored as hex, files and keywords
+// as json arrays.
+type SQLiteIndexStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteIndexStore creates the tables if not exist and returns the store, db is not closed by the store
+func NewSQLiteIndexStore(ctx context.Context, db *sql.DB) (*SQLiteIndexStore, error) {
+	for _, stmt := range sqliteIndexStoreSchema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("%w: Cannot create tables [%v]", ErrStateStore, err)
+		}
+	}
+	return &SQLiteIndexStore{db}, nil
+}
+
+// DB returns the database
+func (s *SQLiteIndexStore) DB() *sql.DB {
+	return s.db
+}
+
+// Has implements IndexStore
+func (s *SQLiteIndexStore) Has(ctx context.Context, infoHash HashValue) (bool, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM torrent_index WHERE info_hash = ?`,
+		infoHash.InfoHashString(InfoHashEncodingHex)).Scan(&n); err != nil {
+		return false, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return n > 0, nil
+}
+
+// Put implements IndexStore
+func (s *SQLiteIndexStore) Put(ctx context.Context, entry IndexEntry) (bool, error) {
+	files, err := json.Marshal(append([]string{}, entry.Files...))
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	keywords, err := json.Marshal(append([]string{}, entry.Keywords...))
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	result, err := s.db.ExecContext(ctx, `INSERT INTO torrent_index (`+sqliteIndexColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (info_hash) DO NOTHING`,
+		entry.InfoHash.InfoHashString(InfoHashEncodingHex), entry.InfoHash.Type, entry.Name, entry.Size,
+		string(files), string(keywords), entry.MagnetLink, entry.Source, unixOrZero(entry.AddedDate),
+	)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return n > 0, nil
+}
+
+// Get implements IndexStore
+func (s *SQLiteIndexStore) Get(ctx context.Context, infoHash HashValue) (*IndexEntry, error) {
+	var entry *IndexEntry
+	if err := s.query(ctx, `WHERE info_hash = ?`, []interface{}{infoHash.InfoHashString(InfoHashEncodingHex)},
+		func(e IndexEntry) error {
+			entry = &e
+			return nil
+		}); err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrIndexEntryNotFound
+	}
+	return entry, nil
+}
+
+// Range implements IndexStore, the entries are ordered by hex of info hash
+func (s *SQLiteIndexStore) Range(ctx context.Context, f func(entry IndexEntry) error) error {
+	return s.query(ctx, "", nil, f)
+}
+
+// query calls f with the entries of the where clause ordered by info hash, the error of f is returned as is
+func (s *SQLiteIndexStore) query(ctx context.Context, where string, args []interface{}, f func(entry IndexEntry) error) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteIndexColumns+` FROM torrent_index `+where+` ORDER BY info_hash`, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			entry              IndexEntry
+			infoHash, hashType string
+			files, keywords    string
+			addedDate          int64
+		)
+		if err := rows.Scan(&infoHash, &hashType, &entry.Name, &entry.Size, &files, &keywords, &entry.MagnetLink,
+			&entry.Source, &addedDate); err != nil {
+			return fmt.Errorf("%w: %v", ErrStateStore, err)
+		}
+		if entry.InfoHash, err = InfoHashFromHex(infoHash); err != nil {
+			return fmt.Errorf("%w: %v", ErrStateStore, err)
+		}
+		entry.InfoHash.Type = hashType
+		if err := json.Unmarshal([]byte(files), &entry.Files); err != nil {
+			return fmt.Errorf("%w: Bad files of [%v] [%v]", ErrStateStore, infoHash, err)
+		}
+		if err := json.Unmarshal([]byte(keywords), &entry.Keywords); err != nil {
+			return fmt.Errorf("%w: Bad keywords of [%v] [%v]", ErrStateStore, infoHash, err)
+		}
+		entry.AddedDate = unixTimeOrZero(addedDate)
+		if err := f(entry); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return nil
+}
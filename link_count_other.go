@@ -0,0 +1,21 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:01:35
+//
+// File Name: link_count_other.go
+// Description:
+//
+//	Hard link count of files, not available on this platform
+//
+
+//go:build !unix
+
+package transmission
+
+import (
+	"io/fs"
+)
+
+// fileLinkCount returns the number of hard links of file
+func fileLinkCount(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
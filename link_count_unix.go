@@ -0,0 +1,26 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:01:35
+//
+// File Name: link_count_unix.go
+// Description:
+//
+//	Hard link count of files
+//
+
+//go:build unix
+
+package transmission
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileLinkCount returns the number of hard links of file
+func fileLinkCount(info fs.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Nlink), true
+}
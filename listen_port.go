@@ -0,0 +1,197 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:14:39
+//
+// File Name: listen_port.go
+// Description:
+//
+//	Random listen port selection within a range, remembered across restarts by settings.json, and the reachability
+//	monitor of the external port
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors
+var (
+	ErrNoListenPort = errors.New("No available listen port")
+	ErrPortCheck    = errors.New("Failed to check port")
+)
+
+// Listen port defaults
+const (
+	DefaultListenPortLow      = 49152 // The dynamic port range
+	DefaultListenPortHigh     = 65535
+	DefaultListenPortAttempts = 32
+)
+
+// PickListenPort returns remembered if it's in [low, high] and free, otherwise a random free port in the range. A
+// port is free if both tcp and udp (utp, dht) can listen on it. The defaults are used if low or high is zero.
+func PickListenPort(low, high, remembered int, r *rand.Rand) (int, error) {
+	if low <= 0 {
+		low = DefaultListenPortLow
+	}
+	if high <= 0 {
+		high = DefaultListenPortHigh
+	}
+	if low > high || high > 65535 {
+		return 0, fmt.Errorf("%w: Invalid range [%v] [%v]", ErrNoListenPort, low, high)
+	}
+	if remembered >= low && remembered <= high && listenPortFree(remembered) {
+		return remembered, nil
+	}
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	for i := 0; i < DefaultListenPortAttempts; i++ {
+		port := low + r.Intn(high-low+1)
+		if listenPortFree(port) {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: [%v-%v]", ErrNoListenPort, low, high)
+}
+
+// listenPortFree checks if port can be listened by both tcp and udp
+func listenPortFree(port int) bool {
+	addr := net.JoinHostPort("", strconv.Itoa(port))
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return false
+	}
+	defer l.Close()
+	c, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return false
+	}
+	c.Close()
+	return true
+}
+
+// PickPeerPort picks the peer port by PickListenPort within peer-port-random-low and peer-port-random-high, and
+// stores it in peer-port. The current peer-port is kept if it's still in the range and free, so the port is
+// remembered across restarts once the settings are written back.
+func (s *TransmissionSettings) PickPeerPort(r *rand.Rand) (int, error) {
+	var low, high, remembered int
+	if s.PeerPortRandomLow != nil {
+		low = *s.PeerPortRandomLow
+	}
+	if s.PeerPortRandomHigh != nil {
+		high = *s.PeerPortRandomHigh
+	}
+	if s.PeerPort != nil {
+		remembered = *s.PeerPort
+	}
+	port, err := PickListenPort(low, high, remembered, r)
+	if err != nil {
+		return 0, err
+	}
+	s.PeerPort = &port
+	return port, nil
+}
+
+// TestPortReachable tests if the port is reachable from the internet by a port check service, which is requested at
+// checkURL followed by the port and replies 1 (reachable) or 0, e.g. DefaultPortCheckURL. The client is
+// http.DefaultClient if nil.
+func TestPortReachable(ctx context.Context, client *http.Client, checkURL string, port int) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%v%d", checkURL, port), nil)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrPortCheck, err)
+	}
+	resp, err := httpClientOrDefault(client).Do(req)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrPortCheck, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%w: Status [%v]", ErrPortCheck, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrPortCheck, err)
+	}
+	switch result := strings.TrimSpace(string(body)); result {
+	case "1":
+		return true, nil
+	case "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: Unknown result [%v]", ErrPortCheck, result)
+	}
+}
+
+// ListenPortStatus defines the status of the listen port
+type ListenPortStatus struct {
+	Port         int // The local listen port
+	ExternalPort int // The port seen from the internet, e.g. mapped by upnp or nat-pmp
+	Reachable    bool
+	Tested       bool // False if the reachability is unknown, e.g. no test of the port succeeded yet
+	Time         time.Time
+	Err          error // The error of the last test
+}
+
+// ListenPortMonitor tests the reachability of the external port and publishes ListenPortChangedEvent to the bus when
+// the external port or its reachability changes
+type ListenPortMonitor struct {
+	// Test tests if the external port is reachable, e.g. by TestPortReachable or a connect-back of a peer
+	Test func(ctx context.Context, port int) (bool, error)
+	Bus  *EventBus // Optional
+
+	mutex  sync.Mutex
+	status ListenPortStatus
+}
+
+// NewListenPortMonitor creates a new ListenPortMonitor
+func NewListenPortMonitor(test func(ctx context.Context, port int) (bool, error), bus *EventBus) *ListenPortMonitor {
+	return &ListenPortMonitor{Test: test, Bus: bus}
+}
+
+// Update tests the external port (the local port if zero) at now and returns the new status. A failed test keeps
+// the previous reachability of the same port.
+func (m *ListenPortMonitor) Update(ctx context.Context, port, externalPort int, now time.Time) (ListenPortStatus, error) {
+	if externalPort == 0 {
+		externalPort = port
+	}
+	reachable, err := m.Test(ctx, externalPort)
+
+	m.mutex.Lock()
+	previous := m.status
+	status := ListenPortStatus{Port: port, ExternalPort: externalPort, Time: now, Err: err}
+	if err == nil {
+		status.Reachable, status.Tested = reachable, true
+	} else if previous.Port == port && previous.ExternalPort == externalPort {
+		status.Reachable, status.Tested = previous.Reachable, previous.Tested
+	}
+	m.status = status
+	m.mutex.Unlock()
+
+	changed := previous.ExternalPort != status.ExternalPort || previous.Port != status.Port ||
+		(status.Tested && (!previous.Tested || previous.Reachable != status.Reachable))
+	if changed && m.Bus != nil {
+		m.Bus.Publish(ListenPortChangedEvent{
+			EventHeader:          EventHeader{Time: now},
+			Status:               status,
+			PreviousExternalPort: previous.ExternalPort,
+		})
+	}
+	return status, err
+}
+
+// Status returns the last status
+func (m *ListenPortMonitor) Status() ListenPortStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.status
+}
@@ -0,0 +1,180 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:42:18
+//
+// File Name: magnet_collection.go
+// Description:
+//
+//	Magnet collection files (.magnet, one uri per line) and info hash lists (one hex or base32 info hash per line).
+//	Empty lines and lines starting with '#' are comments. Both are read and written as streams.
+//
+
+package transmission
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Errors
+var (
+	ErrMalformedMagnetCollection = errors.New("Malformed magnet collection")
+)
+
+// commentedLineScanner scans the lines which are not empty or comments
+type commentedLineScanner struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newCommentedLineScanner(r io.Reader) *commentedLineScanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMagnetLinkLineSize)
+	return &commentedLineScanner{scanner: scanner}
+}
+
+// Next returns the next line and its line number (starts from 1), io.EOF at the end
+func (s *commentedLineScanner) Next() (string, int, error) {
+	for s.scanner.Scan() {
+		s.line++
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, s.line, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return "", s.line, err
+	}
+	return "", s.line, io.EOF
+}
+
+func writeComment(w *bufio.Writer, comment string) error {
+	for _, line := range strings.Split(comment, "\n") {
+		if _, err := w.WriteString("# " + strings.TrimRight(line, "\r") + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+//
+//
+// Magnet collection
+//
+//
+//
+
+// MagnetCollectionReader reads the uris of magnet collection file. The uris are not parsed, see ParseMagnetLink or
+// ParseMagnetLinksFromReader.
+type MagnetCollectionReader struct {
+	scanner *commentedLineScanner
+}
+
+// NewMagnetCollectionReader creates a new MagnetCollectionReader
+func NewMagnetCollectionReader(r io.Reader) *MagnetCollectionReader {
+	return &MagnetCollectionReader{newCommentedLineScanner(r)}
+}
+
+// Next returns the next uri and its line number (starts from 1), io.EOF at the end
+func (r *MagnetCollectionReader) Next() (string, int, error) {
+	return r.scanner.Next()
+}
+
+// MagnetCollectionWriter writes magnet collection file, Flush must be called at the end
+type MagnetCollectionWriter struct {
+	w *bufio.Writer
+}
+
+// NewMagnetCollectionWriter creates a new MagnetCollectionWriter
+func NewMagnetCollectionWriter(w io.Writer) *MagnetCollectionWriter {
+	return &MagnetCollectionWriter{bufio.NewWriter(w)}
+}
+
+// WriteComment writes the comment, each line is prefixed by "# "
+func (w *MagnetCollectionWriter) WriteComment(comment string) error {
+	return writeComment(w.w, comment)
+}
+
+// WriteURI writes the magnet link uri
+func (w *MagnetCollectionWriter) WriteURI(uri string) error {
+	if strings.ContainsAny(uri, "\r\n") {
+		return fmt.Errorf("%w: Uri contains line break", ErrMalformedMagnetCollection)
+	}
+	_, err := w.w.WriteString(uri + "\n")
+	return err
+}
+
+// WriteMagnetLink writes the magnet link
+func (w *MagnetCollectionWriter) WriteMagnetLink(l *MagnetLink) error {
+	return w.WriteURI(l.String())
+}
+
+// Flush flushes the buffered data
+func (w *MagnetCollectionWriter) Flush() error {
+	return w.w.Flush()
+}
+
+//
+//
+//
+// Info hash list
+//
+//
+//
+
+// InfoHashListReader reads info hash list. Each line is a btih value (hex or base32 of sha1, or hex of sha256).
+type InfoHashListReader struct {
+	scanner *commentedLineScanner
+}
+
+// NewInfoHashListReader creates a new InfoHashListReader
+func NewInfoHashListReader(r io.Reader) *InfoHashListReader {
+	return &InfoHashListReader{newCommentedLineScanner(r)}
+}
+
+// Next returns the next info hash and its line number (starts from 1), io.EOF at the end.
+// A malformed line returns ErrMalformedMagnetCollection, the reading can be continued by calling Next again.
+func (r *InfoHashListReader) Next() (HashValue, int, error) {
+	line, lineNo, err := r.scanner.Next()
+	if err != nil {
+		return HashValue{}, lineNo, err
+	}
+	hashValue, err := decodeBtih(line)
+	if err != nil {
+		return HashValue{}, lineNo, fmt.Errorf("%w: Line [%v] [%v]", ErrMalformedMagnetCollection, lineNo, err)
+	}
+	return hashValue, lineNo, nil
+}
+
+// InfoHashListWriter writes info hash list in lower case hex, Flush must be called at the end
+type InfoHashListWriter struct {
+	w *bufio.Writer
+}
+
+// NewInfoHashListWriter creates a new InfoHashListWriter
+func NewInfoHashListWriter(w io.Writer) *InfoHashListWriter {
+	return &InfoHashListWriter{bufio.NewWriter(w)}
+}
+
+// WriteComment writes the comment, each line is prefixed by "# "
+func (w *InfoHashListWriter) WriteComment(comment string) error {
+	return writeComment(w.w, comment)
+}
+
+// WriteInfoHash writes the info hash
+func (w *InfoHashListWriter) WriteInfoHash(infoHash HashValue) error {
+	if len(infoHash.Value) == 0 {
+		return fmt.Errorf("%w: Empty info hash", ErrMalformedMagnetCollection)
+	}
+	_, err := w.w.WriteString(infoHash.InfoHashString(InfoHashEncodingHex) + "\n")
+	return err
+}
+
+// Flush flushes the buffered data
+func (w *InfoHashListWriter) Flush() error {
+	return w.w.Flush()
+}
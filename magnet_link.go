@@ -13,11 +13,12 @@
 package transmission
 
 import (
-	"encoding/base32"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -39,6 +40,7 @@ var (
 // MagnetLink defines magnet link
 type MagnetLink struct {
 	Dn       []string            // Display name
+	DnRaw    []string            // Raw display name bytes before charset conversion (only set with WithMagnetLinkParseDnCharsetOption)
 	Xt       []Urn               // Exact topic
 	Xl       []int               // Exact length
 	As       []string            // Acceptable source
@@ -49,6 +51,10 @@ type MagnetLink struct {
 	So       []NumRange          // Select only
 	Exps     map[string][]string // Experimental parameters (which must begin with "x.")
 	Unknowns map[string][]string // Uknown parameters
+
+	ParseIssues []error // The skipped malformed parameters (only set with WithMagnetLinkParseCollectErrorsOption)
+
+	cache *magnetLinkCache
 }
 
 // ParseMagnetLink parses magnetLink uri
@@ -60,27 +66,66 @@ func ParseMagnetLink(uri string, opts ...MagnetLinkParseOption) (*MagnetLink, er
 		}
 	}
 
-	// Parse uri
-	u, err := url.Parse(uri)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrMalformedMagnetLink, err)
+	var magnetLink MagnetLink
+	// collect records the issue and returns nil in collect errors mode, otherwise returns the issue as error
+	collect := func(err error) error {
+		if !option.CollectErrors {
+			return err
+		}
+		magnetLink.ParseIssues = append(magnetLink.ParseIssues, err)
+		return nil
 	}
-	if strings.ToLower(u.Scheme) != "magnet" {
-		return nil, fmt.Errorf("%w: Invalid scheme", ErrMalformedMagnetLink)
+
+	// Parse uri
+	var q url.Values
+	unescape := url.QueryUnescape
+	if option.RawQuery {
+		var (
+			issues []error
+			err    error
+		)
+		if q, issues, err = parseMagnetLinkRawQuery(uri); err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if err := collect(issue); err != nil {
+				return nil, err
+			}
+		}
+		// '+' is literal in double escaped urls as well
+		unescape = url.PathUnescape
+	} else {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedMagnetLink, err)
+		}
+		if strings.ToLower(u.Scheme) != "magnet" {
+			return nil, fmt.Errorf("%w: Invalid scheme", ErrMalformedMagnetLink)
+		}
+		q = u.Query()
 	}
 
 	// Parse parameters
-	var magnetLink MagnetLink
-	q := u.Query()
 	for key, values := range q {
 		key = strings.ToLower(key)
 		if key == "dn" {
-			magnetLink.Dn = append(magnetLink.Dn, values...)
+			if option.DnCharset {
+				for _, value := range values {
+					dn, _ := DecodeCharset([]byte(value), option.DnDecoders...)
+					magnetLink.Dn = append(magnetLink.Dn, dn)
+					magnetLink.DnRaw = append(magnetLink.DnRaw, value)
+				}
+			} else {
+				magnetLink.Dn = append(magnetLink.Dn, values...)
+			}
 		} else if checkIsMagnetLinkXTParameter(key) {
 			for _, value := range values {
 				urn, err := ParseUrn(value)
 				if err != nil {
-					return nil, fmt.Errorf("%w: Invalid xt [%v]", ErrMalformedMagnetLink, err)
+					if err := collect(fmt.Errorf("%w: Invalid xt [%v]", ErrMalformedMagnetLink, err)); err != nil {
+						return nil, err
+					}
+					continue
 				}
 				magnetLink.Xt = append(magnetLink.Xt, urn)
 			}
@@ -88,15 +133,21 @@ func ParseMagnetLink(uri string, opts ...MagnetLinkParseOption) (*MagnetLink, er
 			for _, value := range values {
 				num, err := strconv.Atoi(value)
 				if err != nil {
-					return nil, fmt.Errorf("%w: Invalid xl [%v]", ErrMalformedMagnetLink, err)
+					if err := collect(fmt.Errorf("%w: Invalid xl [%v]", ErrMalformedMagnetLink, err)); err != nil {
+						return nil, err
+					}
+					continue
 				}
 				magnetLink.Xl = append(magnetLink.Xl, num)
 			}
 		} else if key == "as" {
 			for _, value := range values {
-				value, err := url.QueryUnescape(value)
+				value, err := unescape(value)
 				if err != nil {
-					return nil, fmt.Errorf("%w: Invalid as [%v]", ErrMalformedMagnetLink, err)
+					if err := collect(fmt.Errorf("%w: Invalid as [%v]", ErrMalformedMagnetLink, err)); err != nil {
+						return nil, err
+					}
+					continue
 				}
 				magnetLink.As = append(magnetLink.As, value)
 			}
@@ -108,9 +159,22 @@ func ParseMagnetLink(uri string, opts ...MagnetLinkParseOption) (*MagnetLink, er
 			magnetLink.Mt = append(magnetLink.Mt, values...)
 		} else if key == "tr" {
 			for _, value := range values {
-				value, err := url.QueryUnescape(value)
+				if option.LenientTracker {
+					if unescaped, err := unescape(value); err == nil {
+						value = unescaped
+					}
+					if normalized, err := NormalizeTrackerURL(value); err == nil {
+						value = normalized
+					}
+					magnetLink.Tr = append(magnetLink.Tr, value)
+					continue
+				}
+				value, err := unescape(value)
 				if err != nil {
-					return nil, fmt.Errorf("%w: Invalid tr [%v]", ErrMalformedMagnetLink, err)
+					if err := collect(fmt.Errorf("%w: Invalid tr [%v]", ErrMalformedMagnetLink, err)); err != nil {
+						return nil, err
+					}
+					continue
 				}
 				magnetLink.Tr = append(magnetLink.Tr, value)
 			}
@@ -120,14 +184,20 @@ func ParseMagnetLink(uri string, opts ...MagnetLinkParseOption) (*MagnetLink, er
 				for _, s := range strs {
 					numRange, err := ParseNumRangeFromString(s)
 					if err != nil {
-						return nil, fmt.Errorf("%w: Invalid so [%v]", ErrMalformedMagnetLink, err)
+						if err := collect(fmt.Errorf("%w: Invalid so [%v]", ErrMalformedMagnetLink, err)); err != nil {
+							return nil, err
+						}
+						continue
 					}
 					magnetLink.So = append(magnetLink.So, numRange)
 				}
 			}
 		} else if strings.HasPrefix(key, "x.") {
 			if len(key) <= 2 {
-				return nil, fmt.Errorf("%w: Invalid experimental parameter", ErrMalformedMagnetLink)
+				if err := collect(fmt.Errorf("%w: Invalid experimental parameter", ErrMalformedMagnetLink)); err != nil {
+					return nil, err
+				}
+				continue
 			}
 			key := key[2:]
 			if magnetLink.Exps == nil {
@@ -136,7 +206,10 @@ func ParseMagnetLink(uri string, opts ...MagnetLinkParseOption) (*MagnetLink, er
 			magnetLink.Exps[key] = append(magnetLink.Exps[key], values...)
 		} else {
 			if option.Strict {
-				return nil, fmt.Errorf("%w: Uknown parameters", ErrMalformedMagnetLink)
+				if err := collect(fmt.Errorf("%w: Uknown parameters [%v]", ErrMalformedMagnetLink, key)); err != nil {
+					return nil, err
+				}
+				continue
 			}
 			// Unknown parameters
 			if magnetLink.Unknowns == nil {
@@ -146,6 +219,12 @@ func ParseMagnetLink(uri string, opts ...MagnetLinkParseOption) (*MagnetLink, er
 		}
 	}
 
+	if option.CollectErrors && len(magnetLink.Xt) == 0 {
+		if len(magnetLink.ParseIssues) == 0 {
+			return nil, fmt.Errorf("%w: No valid xt", ErrMalformedMagnetLink)
+		}
+		return nil, fmt.Errorf("%w: No valid xt [%w]", ErrMalformedMagnetLink, errors.Join(magnetLink.ParseIssues...))
+	}
 	return &magnetLink, nil
 }
 
@@ -166,40 +245,37 @@ func checkIsMagnetLinkXTParameter(key string) bool {
 	return err == nil
 }
 
-// AsTorrent converts to TorrentMagnetLink
-func (l *MagnetLink) AsTorrent() (*TorrentMagnetLink, error) {
+// AsTorrent converts to TorrentMagnetLink.
+// The same info hash in different encodings (hex and base32) is kept once unless WithMagnetLinkParseKeepDuplicateInfoHashOption
+func (l *MagnetLink) AsTorrent(opts ...MagnetLinkParseOption) (*TorrentMagnetLink, error) {
+	var option magnetLinkParseOption
+	for _, opt := range opts {
+		if opt != nil {
+			opt.set(&option)
+		}
+	}
+
 	torrentMagnetLink := TorrentMagnetLink{MagnetLink: l}
 	for _, xt := range l.Xt {
-		if strings.ToLower(xt.Nid) == "btih" {
-			var (
-				err       error
-				hashValue HashValue
-			)
-			switch len(xt.Nss) {
-			case 32:
-				// SHA-1. Base32 encoding
-				hashValue.Type = HashSHA1
-				hashValue.Value, err = base32.StdEncoding.DecodeString(xt.Nss)
-			case 40:
-				// SHA-1. Hex encoding
-				hashValue.Type = HashSHA1
-				hashValue.Value, err = hex.DecodeString(xt.Nss)
-			case 56:
-				// SHA-64. Base32 encoding
-				hashValue.Type = HashSHA256
-				hashValue.Value, err = base32.StdEncoding.DecodeString(xt.Nss)
-			case 64:
-				// SHA-64. Hex encoding
-				hashValue.Type = HashSHA256
-				hashValue.Value, err = hex.DecodeString(xt.Nss)
-			default:
-				return nil, fmt.Errorf("%w: Cannot decode btih [Bad length]", ErrMalformedMagnetLink)
-			}
-			if err != nil {
-				return nil, fmt.Errorf("%w: Cannot decode btih [%v]", ErrMalformedMagnetLink, err)
-			}
-			torrentMagnetLink.InfoHashs = append(torrentMagnetLink.InfoHashs, hashValue)
+		var (
+			hashValue HashValue
+			err       error
+		)
+		switch strings.ToLower(xt.Nid) {
+		case "btih":
+			hashValue, err = decodeBtih(xt.Nss)
+		case "btmh":
+			hashValue, err = decodeBtmh(xt.Nss)
+		default:
+			continue
 		}
+		if err != nil {
+			return nil, err
+		}
+		if !option.KeepDuplicateInfoHash && shareInfoHash(torrentMagnetLink.InfoHashs, []HashValue{hashValue}) {
+			continue
+		}
+		torrentMagnetLink.InfoHashs = append(torrentMagnetLink.InfoHashs, hashValue)
 	}
 
 	if len(torrentMagnetLink.InfoHashs) == 0 {
@@ -208,6 +284,98 @@ func (l *MagnetLink) AsTorrent() (*TorrentMagnetLink, error) {
 	return &torrentMagnetLink, nil
 }
 
+// String encodes the magnet link to uri.
+// Parameters are written in a fixed order with map keys sorted, so the result is deterministic. Select only ranges
+// are merged by EncodeNumRanges.
+func (l *MagnetLink) String() string {
+	var builder strings.Builder
+	builder.WriteString("magnet:?")
+	first := true
+	write := func(key, value string) {
+		if !first {
+			builder.WriteByte('&')
+		}
+		first = false
+		builder.WriteString(key)
+		builder.WriteByte('=')
+		builder.WriteString(value)
+	}
+	for _, xt := range l.Xt {
+		// Keep colons of urn readable
+		write("xt", strings.ReplaceAll(url.QueryEscape(xt.String()), "%3A", ":"))
+	}
+	for _, dn := range l.Dn {
+		write("dn", url.QueryEscape(dn))
+	}
+	for _, xl := range l.Xl {
+		write("xl", strconv.Itoa(xl))
+	}
+	for _, tr := range l.Tr {
+		write("tr", url.QueryEscape(tr))
+	}
+	for _, as := range l.As {
+		write("as", url.QueryEscape(as))
+	}
+	for _, xs := range l.Xs {
+		write("xs", url.QueryEscape(xs))
+	}
+	for _, kt := range l.Kt {
+		write("kt", url.QueryEscape(kt))
+	}
+	for _, mt := range l.Mt {
+		write("mt", url.QueryEscape(mt))
+	}
+	if len(l.So) > 0 {
+		write("so", EncodeNumRanges(l.So))
+	}
+	for _, key := range sortedKeys(l.Exps) {
+		for _, value := range l.Exps[key] {
+			write("x."+url.QueryEscape(key), url.QueryEscape(value))
+		}
+	}
+	for _, key := range sortedKeys(l.Unknowns) {
+		for _, value := range l.Unknowns[key] {
+			write(url.QueryEscape(key), url.QueryEscape(value))
+		}
+	}
+	return builder.String()
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// decodeBtih decodes the nss of btih urn, which is hex or base32 of info hash
+func decodeBtih(nss string) (HashValue, error) {
+	decode := InfoHashFromHex
+	if len(nss) == 32 || len(nss) == 56 {
+		decode = InfoHashFromBase32
+	}
+	hashValue, err := decode(nss)
+	if err != nil {
+		return hashValue, fmt.Errorf("%w: Cannot decode btih [%v]", ErrMalformedMagnetLink, err)
+	}
+	return hashValue, nil
+}
+
+// decodeBtmh decodes the nss of btmh urn, which is a hex encoded multihash (BEP 9). Only sha2-256 is supported
+func decodeBtmh(nss string) (HashValue, error) {
+	b, err := hex.DecodeString(nss)
+	if err != nil {
+		return HashValue{}, fmt.Errorf("%w: Cannot decode btmh [%v]", ErrMalformedMagnetLink, err)
+	}
+	// 0x12 is the multihash code of sha2-256, 0x20 is the digest length
+	if len(b) != 2+sha256.Size || b[0] != 0x12 || b[1] != sha256.Size {
+		return HashValue{}, fmt.Errorf("%w: Cannot decode btmh [Unsupported multihash]", ErrMalformedMagnetLink)
+	}
+	return HashValue{HashSHA256, b[2:]}, nil
+}
+
 //
 //
 //
@@ -221,6 +389,8 @@ type TorrentMagnetLink struct {
 	*MagnetLink
 
 	InfoHashs []HashValue
+
+	infoHashCache *infoHashCache
 }
 
 // ParseTorrentMagnetLink parses torrent magnet link
@@ -229,7 +399,12 @@ func ParseTorrentMagnetLink(uri string, opts ...MagnetLinkParseOption) (*Torrent
 	if err != nil {
 		return nil, err
 	}
-	return magnetLink.AsTorrent()
+	return magnetLink.AsTorrent(opts...)
+}
+
+// Version returns the torrent version by the info hashs (btih for v1, btmh for v2)
+func (l *TorrentMagnetLink) Version() TorrentVersion {
+	return torrentVersionOf(l.InfoHashs)
 }
 
 //
@@ -245,7 +420,13 @@ type MagnetLinkParseOption interface {
 	set(option *magnetLinkParseOption)
 }
 type magnetLinkParseOption struct {
-	Strict bool
+	Strict                bool
+	DnCharset             bool
+	DnDecoders            []CharsetDecoder
+	LenientTracker        bool
+	KeepDuplicateInfoHash bool
+	RawQuery              bool
+	CollectErrors         bool
 }
 type magnetLinkParseOptionSetterFunc func(options *magnetLinkParseOption)
 type magnetLinkParseOptionSetter struct {
@@ -264,3 +445,52 @@ func WithMagnetLinkParseStrictOption(strict bool) MagnetLinkParseOption {
 		},
 	}
 }
+
+// WithMagnetLinkParseDnCharsetOption converts non utf-8 display names to utf-8.
+// The decoders are tried in order (see DecodeCharset), the raw values are kept in MagnetLink.DnRaw
+func WithMagnetLinkParseDnCharsetOption(decoders ...CharsetDecoder) MagnetLinkParseOption {
+	return magnetLinkParseOptionSetter{
+		func(option *magnetLinkParseOption) {
+			option.DnCharset = true
+			option.DnDecoders = decoders
+		},
+	}
+}
+
+// WithMagnetLinkParseLenientTrackerOption normalizes tracker urls by NormalizeTrackerURL instead of failing on malformed values
+func WithMagnetLinkParseLenientTrackerOption(lenient bool) MagnetLinkParseOption {
+	return magnetLinkParseOptionSetter{
+		func(option *magnetLinkParseOption) {
+			option.LenientTracker = lenient
+		},
+	}
+}
+
+// WithMagnetLinkParseKeepDuplicateInfoHashOption keeps duplicated info hashs in TorrentMagnetLink.InfoHashs, e.g. for diagnostics
+func WithMagnetLinkParseKeepDuplicateInfoHashOption(keep bool) MagnetLinkParseOption {
+	return magnetLinkParseOptionSetter{
+		func(option *magnetLinkParseOption) {
+			option.KeepDuplicateInfoHash = keep
+		},
+	}
+}
+
+// WithMagnetLinkParseRawQueryOption parses the query by ParseMagnetQuery instead of net/url, which treats '+' literally
+// except in dn and kt, reports malformed pairs instead of dropping them and doesn't treat '#' as fragment
+func WithMagnetLinkParseRawQueryOption(raw bool) MagnetLinkParseOption {
+	return magnetLinkParseOptionSetter{
+		func(option *magnetLinkParseOption) {
+			option.RawQuery = raw
+		},
+	}
+}
+
+// WithMagnetLinkParseCollectErrorsOption skips malformed parameters instead of failing and collects the issues into
+// MagnetLink.ParseIssues. An error is only returned if no valid xt is found
+func WithMagnetLinkParseCollectErrorsOption(collect bool) MagnetLinkParseOption {
+	return magnetLinkParseOptionSetter{
+		func(option *magnetLinkParseOption) {
+			option.CollectErrors = collect
+		},
+	}
+}
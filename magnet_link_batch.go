@@ -0,0 +1,114 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:32:09
+//
+// File Name: magnet_link_batch.go
+// Description:
+//
+//	Parse magnet links in bulk by a worker pool
+//
+
+package transmission
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// maxMagnetLinkLineSize defines the max line size when reading magnet links from reader
+const maxMagnetLinkLineSize = 1 << 20
+
+// MagnetLinkParseResult defines the result of parsing a magnet link in batch
+type MagnetLinkParseResult struct {
+	Index int // The index in uris, or the line number (starts from 1) of reader
+	URI   string
+	Link  *MagnetLink
+	Err   error
+}
+
+type magnetLinkParseJob struct {
+	Index int
+	URI   string
+}
+
+// ParseMagnetLinks parses magnet links in parallel (GOMAXPROCS workers). The results are in the order of uris and
+// the errors are per item. The returned error is only not nil if ctx is done.
+func ParseMagnetLinks(ctx context.Context, uris []string, opts ...MagnetLinkParseOption) ([]MagnetLinkParseResult, error) {
+	results := make([]MagnetLinkParseResult, len(uris))
+	jobs := make(chan magnetLinkParseJob)
+	go func() {
+		defer close(jobs)
+		for i, uri := range uris {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- magnetLinkParseJob{i, uri}:
+			}
+		}
+	}()
+	runMagnetLinkParseWorkers(jobs, func(result MagnetLinkParseResult) {
+		results[result.Index] = result
+	}, opts)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ParseMagnetLinksFromReader parses magnet links from reader (a magnet collection, see MagnetCollectionReader) in
+// parallel. The handle is called in a single goroutine in the order of completion, not the order of lines.
+// The returned error is the read error or ctx error.
+func ParseMagnetLinksFromReader(ctx context.Context, r io.Reader, handle func(result MagnetLinkParseResult), opts ...MagnetLinkParseOption) error {
+	var readErr error
+	jobs := make(chan magnetLinkParseJob)
+	go func() {
+		defer close(jobs)
+		reader := NewMagnetCollectionReader(r)
+		for {
+			uri, lineNo, err := reader.Next()
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- magnetLinkParseJob{lineNo, uri}:
+			}
+		}
+	}()
+
+	results := make(chan MagnetLinkParseResult)
+	go func() {
+		defer close(results)
+		runMagnetLinkParseWorkers(jobs, func(result MagnetLinkParseResult) {
+			results <- result
+		}, opts)
+	}()
+	for result := range results {
+		handle(result)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// The reader goroutine has exited since results is closed after jobs is drained
+	return readErr
+}
+
+func runMagnetLinkParseWorkers(jobs <-chan magnetLinkParseJob, emit func(result MagnetLinkParseResult), opts []MagnetLinkParseOption) {
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				link, err := ParseMagnetLink(job.URI, opts...)
+				emit(MagnetLinkParseResult{Index: job.Index, URI: job.URI, Link: link, Err: err})
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,88 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:44:16
+//
+// File Name: magnet_link_compact.go
+// Description:
+//
+//	Compact magnet link uris to fit length limits (e.g. QR codes), and re-expand the trackers later
+//
+
+package transmission
+
+import (
+	"context"
+)
+
+// CompactURI returns the uri (see String) of magnet link within maxLen bytes by dropping parameters in order:
+//
+//  1. Unknown parameters
+//  2. Experimental parameters (x.*)
+//  3. Manifest topics (mt), acceptable sources (as), exact sources (xs)
+//  4. Keyword topics (kt)
+//  5. Exact lengths (xl)
+//  6. Trackers (tr) but the first one, from the last
+//  7. Display names (dn)
+//  8. The first tracker
+//  9. Select only (so)
+//
+// Exact topics (xt) are always preserved. Returns false with the uri of xt only if it still doesn't fit.
+// The link is not modified, see Expand to add trackers back.
+func (l *MagnetLink) CompactURI(maxLen int) (string, bool) {
+	m := MagnetLink{
+		Dn:       l.Dn,
+		Xt:       l.Xt,
+		Xl:       l.Xl,
+		As:       l.As,
+		Xs:       l.Xs,
+		Kt:       l.Kt,
+		Mt:       l.Mt,
+		Tr:       l.Tr,
+		So:       l.So,
+		Exps:     l.Exps,
+		Unknowns: l.Unknowns,
+	}
+	uri := m.String()
+	if len(uri) <= maxLen {
+		return uri, true
+	}
+	steps := []func() bool{
+		func() bool { m.Unknowns = nil; return true },
+		func() bool { m.Exps = nil; return true },
+		func() bool { m.Mt, m.As, m.Xs = nil, nil, nil; return true },
+		func() bool { m.Kt = nil; return true },
+		func() bool { m.Xl = nil; return true },
+		func() bool {
+			// Repeated until only one tracker is left
+			if len(m.Tr) <= 1 {
+				return true
+			}
+			m.Tr = m.Tr[:len(m.Tr)-1]
+			return len(m.Tr) <= 1
+		},
+		func() bool { m.Dn = nil; return true },
+		func() bool { m.Tr = nil; return true },
+		func() bool { m.So = nil; return true },
+	}
+	for _, step := range steps {
+		for {
+			done := step()
+			if uri = m.String(); len(uri) <= maxLen {
+				return uri, true
+			}
+			if done {
+				break
+			}
+		}
+	}
+	return uri, false
+}
+
+// Expand adds the trackers fetched from the tracker lists (see TrackerListFetcher) to the magnet link, e.g. after
+// it's decoded from a compacted uri. Returns the number of added trackers.
+func (l *MagnetLink) Expand(ctx context.Context, fetcher *TrackerListFetcher, sources ...string) (int, error) {
+	trackers, err := fetcher.Fetch(ctx, sources...)
+	if err != nil {
+		return 0, err
+	}
+	return l.AddTrackers(trackers...), nil
+}
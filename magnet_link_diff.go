@@ -0,0 +1,135 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:18:34
+//
+// File Name: magnet_link_diff.go
+// Description:
+//
+//	Diff magnet links
+//
+
+package transmission
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MagnetLinkDiff defines the difference from magnet link A to magnet link B
+type MagnetLinkDiff struct {
+	AddedTrackers   []string   // Trackers in B but not in A
+	RemovedTrackers []string   // Trackers in A but not in B
+	DnChanged       bool       // Display names are different
+	OldDn           []string   // Display names of A
+	NewDn           []string   // Display names of B
+	AddedXt         []Urn      // Exact topics in B but not in A
+	RemovedXt       []Urn      // Exact topics in A but not in B
+	SoChanged       bool       // Select only ranges are different
+	OldSo           []NumRange // Select only ranges of A
+	NewSo           []NumRange // Select only ranges of B
+}
+
+// Empty checks if there's no difference
+func (d MagnetLinkDiff) Empty() bool {
+	return len(d.AddedTrackers) == 0 && len(d.RemovedTrackers) == 0 &&
+		!d.DnChanged && len(d.AddedXt) == 0 && len(d.RemovedXt) == 0 && !d.SoChanged
+}
+
+// DiffMagnetLinks compares magnet link a and b.
+// Exact topics are compared by the decoded hash, so the hex and base32 forms of the same btih are equal.
+func DiffMagnetLinks(a, b *MagnetLink) MagnetLinkDiff {
+	var diff MagnetLinkDiff
+
+	// Trackers
+	diff.AddedTrackers, diff.RemovedTrackers = diffStrings(a.Tr, b.Tr)
+
+	// Display names
+	if !equalStrings(a.Dn, b.Dn) {
+		diff.DnChanged = true
+		diff.OldDn = a.Dn
+		diff.NewDn = b.Dn
+	}
+
+	// Exact topics
+	aXt := make(map[string]bool, len(a.Xt))
+	for _, xt := range a.Xt {
+		aXt[urnKey(xt)] = true
+	}
+	bXt := make(map[string]bool, len(b.Xt))
+	for _, xt := range b.Xt {
+		key := urnKey(xt)
+		bXt[key] = true
+		if !aXt[key] {
+			diff.AddedXt = append(diff.AddedXt, xt)
+		}
+	}
+	for _, xt := range a.Xt {
+		if !bXt[urnKey(xt)] {
+			diff.RemovedXt = append(diff.RemovedXt, xt)
+		}
+	}
+
+	// Select only
+	if !equalStrings(numRangeKeys(a.So), numRangeKeys(b.So)) {
+		diff.SoChanged = true
+		diff.OldSo = a.So
+		diff.NewSo = b.So
+	}
+
+	return diff
+}
+
+// urnKey returns the comparable key of urn
+func urnKey(u Urn) string {
+	nid := strings.ToLower(u.Nid)
+	if nid == "btih" {
+		if hashValue, err := decodeBtih(u.Nss); err == nil {
+			return nid + ":" + hex.EncodeToString(hashValue.Value)
+		}
+	}
+	return nid + ":" + u.Nss
+}
+
+func numRangeKeys(ranges []NumRange) []string {
+	keys := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		keys = append(keys, fmt.Sprintf("%v:%v:%v:%v", r.Start, r.End, r.IncludeStart, r.IncludeEnd))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffStrings returns the strings in b but not in a, and the strings in a but not in b
+func diffStrings(a, b []string) (added, removed []string) {
+	aSet := make(map[string]bool, len(a))
+	for _, s := range a {
+		aSet[s] = true
+	}
+	bSet := make(map[string]bool, len(b))
+	for _, s := range b {
+		if !aSet[s] && !bSet[s] {
+			added = append(added, s)
+		}
+		bSet[s] = true
+	}
+	for _, s := range a {
+		if !bSet[s] {
+			removed = append(removed, s)
+			bSet[s] = true
+		}
+	}
+	return
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
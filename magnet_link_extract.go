@@ -0,0 +1,58 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:31:47
+//
+// File Name: magnet_link_extract.go
+// Description:
+//
+//	Fast path to extract the info hash of magnet links without parsing the whole link
+//
+
+package transmission
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ExtractInfoHash returns the first btih or btmh info hash of magnet link uri.
+// It scans the raw string without url.Parse or building MagnetLink, and only allocates for the hash value
+// (and for unescaping xt if it's percent encoded), which suits pipelines ingesting large amounts of links.
+func ExtractInfoHash(uri string) (HashValue, error) {
+	if len(uri) < 7 || !strings.EqualFold(uri[:7], "magnet:") {
+		return HashValue{}, fmt.Errorf("%w: Invalid scheme", ErrMalformedMagnetLink)
+	}
+	query := uri[7:]
+	if i := strings.IndexByte(query, '?'); i >= 0 {
+		query = query[i+1:]
+	}
+	for len(query) > 0 {
+		param := query
+		if i := strings.IndexByte(query, '&'); i >= 0 {
+			param, query = query[:i], query[i+1:]
+		} else {
+			query = ""
+		}
+		key, value, ok := strings.Cut(param, "=")
+		if !ok || !checkIsMagnetLinkXTParameter(strings.ToLower(key)) {
+			continue
+		}
+		if strings.IndexByte(value, '%') >= 0 {
+			unescaped, err := url.QueryUnescape(value)
+			if err != nil {
+				continue
+			}
+			value = unescaped
+		}
+		if len(value) < 9 || !strings.EqualFold(value[:4], "urn:") || value[8] != ':' {
+			continue
+		}
+		switch nid := value[4:8]; {
+		case strings.EqualFold(nid, "btih"):
+			return decodeBtih(value[9:])
+		case strings.EqualFold(nid, "btmh"):
+			return decodeBtmh(value[9:])
+		}
+	}
+	return HashValue{}, fmt.Errorf("%w: No torrent", ErrWrongMagnetLinkType)
+}
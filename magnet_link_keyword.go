@@ -0,0 +1,78 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:18:57
+//
+// File Name: magnet_link_keyword.go
+// Description:
+//
+//	Keyword topic (kt) helpers
+//
+
+package transmission
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// ParseKeywords splits a keyword topic into lowercase keywords.
+// Keywords are separated by "+" (or spaces, since url query decoding turns "+" into space) and may be percent-encoded.
+func ParseKeywords(kt string) []string {
+	var keywords []string
+	fields := strings.FieldsFunc(kt, func(r rune) bool {
+		return r == '+' || unicode.IsSpace(r)
+	})
+	for _, field := range fields {
+		if s, err := url.PathUnescape(field); err == nil {
+			field = s
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" {
+			keywords = append(keywords, field)
+		}
+	}
+	return keywords
+}
+
+// Keywords returns the lowercase keywords of all keyword topics with duplications removed
+func (l *MagnetLink) Keywords() []string {
+	var keywords []string
+	for _, kt := range l.Kt {
+		keywords = appendUniqueStrings(keywords, ParseKeywords(kt)...)
+	}
+	return keywords
+}
+
+// KeywordMatcher matches magnet links by keywords. Keywords are compared case-insensitively
+type KeywordMatcher struct {
+	All  []string // All of these keywords must be present
+	Any  []string // At least one of these keywords must be present, ignored if empty
+	None []string // None of these keywords can be present
+}
+
+// Match checks if the magnet link matches
+func (m KeywordMatcher) Match(l *MagnetLink) bool {
+	keywords := make(map[string]bool)
+	for _, keyword := range l.Keywords() {
+		keywords[keyword] = true
+	}
+	for _, keyword := range m.All {
+		if !keywords[strings.ToLower(keyword)] {
+			return false
+		}
+	}
+	for _, keyword := range m.None {
+		if keywords[strings.ToLower(keyword)] {
+			return false
+		}
+	}
+	if len(m.Any) == 0 {
+		return true
+	}
+	for _, keyword := range m.Any {
+		if keywords[strings.ToLower(keyword)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:18:47
+//
+// File Name: magnet_link_merge.go
+// Description:
+//
+//	Merge magnet links of the same torrent
+//
+
+package transmission
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Errors
+var (
+	ErrInfoHashMismatch = errors.New("Info hash mismatch")
+)
+
+// MergeMagnetLinks merges magnet links which refer to the same info hash.
+// Trackers, sources, keywords and other parameters are unioned with duplications removed, the order of first appearance is kept.
+func MergeMagnetLinks(links ...*TorrentMagnetLink) (*TorrentMagnetLink, error) {
+	if len(links) == 0 {
+		return nil, fmt.Errorf("%w: No magnet link", ErrWrongMagnetLinkType)
+	}
+	for _, link := range links[1:] {
+		if !shareInfoHash(links[0].InfoHashs, link.InfoHashs) {
+			return nil, fmt.Errorf("%w: Cannot merge different torrents", ErrInfoHashMismatch)
+		}
+	}
+
+	var merged MagnetLink
+	var (
+		xts = make(map[string]bool)
+		xls = make(map[int]bool)
+		sos = make(map[NumRange]bool)
+	)
+	for _, link := range links {
+		merged.Dn = appendUniqueStrings(merged.Dn, link.Dn...)
+		for _, xt := range link.Xt {
+			if key := urnKey(xt); !xts[key] {
+				xts[key] = true
+				merged.Xt = append(merged.Xt, xt)
+			}
+		}
+		for _, xl := range link.Xl {
+			if !xls[xl] {
+				xls[xl] = true
+				merged.Xl = append(merged.Xl, xl)
+			}
+		}
+		merged.As = appendUniqueStrings(merged.As, link.As...)
+		merged.Xs = appendUniqueStrings(merged.Xs, link.Xs...)
+		merged.Kt = appendUniqueStrings(merged.Kt, link.Kt...)
+		merged.Mt = appendUniqueStrings(merged.Mt, link.Mt...)
+		merged.Tr = appendUniqueStrings(merged.Tr, link.Tr...)
+		for _, so := range link.So {
+			if !sos[so] {
+				sos[so] = true
+				merged.So = append(merged.So, so)
+			}
+		}
+		merged.Exps = mergeStringsMap(merged.Exps, link.Exps)
+		merged.Unknowns = mergeStringsMap(merged.Unknowns, link.Unknowns)
+	}
+
+	return merged.AsTorrent()
+}
+
+func shareInfoHash(a, b []HashValue) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x.Type == y.Type && bytes.Equal(x.Value, y.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func appendUniqueStrings(strs []string, values ...string) []string {
+	for _, value := range values {
+		var found bool
+		for _, s := range strs {
+			if s == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			strs = append(strs, value)
+		}
+	}
+	return strs
+}
+
+func mergeStringsMap(dst, src map[string][]string) map[string][]string {
+	for key, values := range src {
+		if dst == nil {
+			dst = make(map[string][]string)
+		}
+		dst[key] = appendUniqueStrings(dst[key], values...)
+	}
+	return dst
+}
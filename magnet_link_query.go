@@ -0,0 +1,90 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:29:44
+//
+// File Name: magnet_link_query.go
+// Description:
+//
+//	Magnet link query parser independent of net/url semantics
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Errors
+var (
+	ErrMalformedMagnetQuery = errors.New("Malformed magnet link query")
+)
+
+// magnetQueryPlusAsSpace defines the parameters in which '+' is decoded as space (form encoded text).
+// In other parameters (e.g. urls of tr, xs) '+' is literal.
+var magnetQueryPlusAsSpace = map[string]bool{
+	"dn": true,
+	"kt": true,
+}
+
+// MagnetQueryPair defines a key value pair of magnet link query
+type MagnetQueryPair struct {
+	Key   string // Unescaped key
+	Value string // Unescaped value, or the raw value if it cannot be unescaped
+	Raw   string // The raw pair
+	Err   error  // Not nil if the pair is malformed
+}
+
+// ParseMagnetQuery parses the query of magnet link (the part after "?").
+// Unlike url.ParseQuery, pairs are kept in order even if malformed, '+' is only decoded as space in dn and kt,
+// and ';' or '#' are not special.
+func ParseMagnetQuery(query string) []MagnetQueryPair {
+	var pairs []MagnetQueryPair
+	for _, raw := range strings.Split(query, "&") {
+		if raw == "" {
+			continue
+		}
+		pair := MagnetQueryPair{Raw: raw}
+		key, value, ok := strings.Cut(raw, "=")
+		if !ok {
+			pair.Err = fmt.Errorf("%w: Missing value [%v]", ErrMalformedMagnetQuery, raw)
+		}
+		if k, err := url.PathUnescape(key); err == nil {
+			key = k
+		} else if pair.Err == nil {
+			pair.Err = fmt.Errorf("%w: Invalid key [%v]", ErrMalformedMagnetQuery, raw)
+		}
+		pair.Key = strings.ToLower(key)
+		pair.Value = value
+		escaped := value
+		if magnetQueryPlusAsSpace[pair.Key] {
+			escaped = strings.ReplaceAll(escaped, "+", "%20")
+		}
+		if v, err := url.PathUnescape(escaped); err == nil {
+			pair.Value = v
+		} else if pair.Err == nil {
+			pair.Err = fmt.Errorf("%w: Invalid value [%v]", ErrMalformedMagnetQuery, raw)
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// parseMagnetLinkRawQuery splits the magnet link uri by ParseMagnetQuery, malformed pairs are returned as issues
+func parseMagnetLinkRawQuery(uri string) (q url.Values, issues []error, err error) {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok || strings.ToLower(scheme) != "magnet" {
+		return nil, nil, fmt.Errorf("%w: Invalid scheme", ErrMalformedMagnetLink)
+	}
+	_, query, _ := strings.Cut(rest, "?")
+	q = make(url.Values)
+	for _, pair := range ParseMagnetQuery(query) {
+		if pair.Err != nil {
+			issues = append(issues, fmt.Errorf("%w: %v", ErrMalformedMagnetLink, pair.Err))
+			continue
+		}
+		q[pair.Key] = append(q[pair.Key], pair.Value)
+	}
+	return q, issues, nil
+}
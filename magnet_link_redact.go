@@ -0,0 +1,150 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:18:55
+//
+// File Name: magnet_link_redact.go
+// Description:
+//
+//	Redact magnet links for logs: passkeys of tracker and source urls are masked, display names are truncated
+//
+
+package transmission
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Redaction defaults
+const (
+	DefaultRedactedDnLength = 24         // In runes
+	redactedMask            = "REDACTED" // Replaces the secrets
+)
+
+// Redacted returns the log-safe uri of magnet link. The passkeys (see ParseTrackerURL) and user info of the urls of
+// tr, as and xs are masked, dn is truncated to DefaultRedactedDnLength runes, and the experimental and unknown
+// parameters are dropped as they may carry anything.
+func (l *MagnetLink) Redacted(opts ...MagnetLinkRedactOption) string {
+	option := magnetLinkRedactOption{DnLength: DefaultRedactedDnLength}
+	for _, opt := range opts {
+		if opt != nil {
+			opt.set(&option)
+		}
+	}
+	redacted := MagnetLink{Xt: l.Xt}
+	if option.InfoHashOnly {
+		redacted.Xt = nil
+		for _, xt := range l.Xt {
+			switch strings.ToLower(xt.Nid) {
+			case "btih", "btmh":
+				redacted.Xt = append(redacted.Xt, xt)
+			}
+		}
+		return redacted.String()
+	}
+	redacted.Xl, redacted.Kt, redacted.Mt, redacted.So = l.Xl, l.Kt, l.Mt, l.So
+	if option.DnLength > 0 {
+		for _, dn := range l.Dn {
+			redacted.Dn = append(redacted.Dn, truncateRunes(dn, option.DnLength))
+		}
+	}
+	redacted.Tr = redactURLs(l.Tr)
+	redacted.As = redactURLs(l.As)
+	redacted.Xs = redactURLs(l.Xs)
+	return redacted.String()
+}
+
+// RedactURL masks the passkey (see ParseTrackerURL) and the user info of url, the url is masked entirely if it
+// cannot be parsed
+func RedactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return redactedMask
+	}
+	if u.User != nil {
+		u.User = url.User(redactedMask)
+	}
+	if u.Opaque != "" {
+		// e.g. urn:btih:..., nothing to mask
+		return u.String()
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if len(segment) >= 16 && isAlphanumeric(segment) {
+			segments[i] = redactedMask
+		}
+	}
+	u.Path, u.RawPath = strings.Join(segments, "/"), ""
+	if u.RawQuery != "" {
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			key, _, _ := strings.Cut(param, "=")
+			if name, err := url.QueryUnescape(key); err == nil && isPasskeyParam(name) {
+				params[i] = key + "=" + redactedMask
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	return u.String()
+}
+
+func redactURLs(urls []string) []string {
+	var redacted []string
+	for _, s := range urls {
+		redacted = append(redacted, RedactURL(s))
+	}
+	return redacted
+}
+
+func isPasskeyParam(name string) bool {
+	for _, param := range trackerPasskeyParams {
+		if strings.EqualFold(name, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateRunes truncates s to n runes, an ellipsis is appended if truncated
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// MagnetLinkRedactOption defines the magnet link redact option
+type MagnetLinkRedactOption interface {
+	set(option *magnetLinkRedactOption)
+}
+
+type magnetLinkRedactOption struct {
+	InfoHashOnly bool
+	DnLength     int
+}
+type magnetLinkRedactOptionSetterFunc func(options *magnetLinkRedactOption)
+type magnetLinkRedactOptionSetter struct {
+	f magnetLinkRedactOptionSetterFunc
+}
+
+func (setter magnetLinkRedactOptionSetter) set(option *magnetLinkRedactOption) {
+	setter.f(option)
+}
+
+// WithMagnetLinkRedactInfoHashOnlyOption keeps only the info hashs (btih and btmh xt)
+func WithMagnetLinkRedactInfoHashOnlyOption(infoHashOnly bool) MagnetLinkRedactOption {
+	return magnetLinkRedactOptionSetter{
+		func(option *magnetLinkRedactOption) {
+			option.InfoHashOnly = infoHashOnly
+		},
+	}
+}
+
+// WithMagnetLinkRedactDnLengthOption defines the max runes of display names, dn is dropped if not positive
+func WithMagnetLinkRedactDnLengthOption(length int) MagnetLinkRedactOption {
+	return magnetLinkRedactOptionSetter{
+		func(option *magnetLinkRedactOption) {
+			option.DnLength = length
+		},
+	}
+}
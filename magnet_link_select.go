@@ -0,0 +1,118 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:16:46
+//
+// File Name: magnet_link_select.go
+// Description:
+//
+//	Translate select only (so) of magnet link to the wanted and unwanted files of transmission, waiting for the
+//	metadata of the torrent added paused
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0053.html
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Errors
+var (
+	ErrSelectOnly = errors.New("Failed to select files")
+)
+
+// SelectFiles returns the indexes of wanted and unwanted files of t by the select only ranges. All files are wanted
+// if there's no range. Padding files are never wanted.
+func SelectFiles(so []NumRange, t *TorrentFile) (wanted, unwanted []int) {
+	files := t.Info.Files
+	if len(files) == 0 {
+		// Single file
+		files = []TorrentFileEntry{{Length: t.Info.Length}}
+	}
+	for i, file := range files {
+		if !file.IsPadding() && (len(so) == 0 || numRangesContain(so, i)) {
+			wanted = append(wanted, i)
+		} else {
+			unwanted = append(unwanted, i)
+		}
+	}
+	return wanted, unwanted
+}
+
+func numRangesContain(ranges []NumRange, num int) bool {
+	for _, r := range ranges {
+		if r.Contains(num) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectOnlyAdder adds magnet links with select only: the torrent is added paused, then the files are selected
+// once the metadata is received and the torrent is started. The steps are done by the functions, e.g. by the
+// torrent-add (paused), torrent-set (files-wanted, files-unwanted) and torrent-start of transmission rpc.
+type SelectOnlyAdder struct {
+	// Bus receives MetadataReceivedEvent of the added torrents
+	Bus *EventBus
+	// Add adds the magnet link and returns the torrent, with the metadata if it's already known (e.g. the torrent
+	// exists or the metadata is cached)
+	Add      func(ctx context.Context, l *MagnetLink, paused bool) (EventTorrent, *TorrentFile, error)
+	SetFiles func(ctx context.Context, t EventTorrent, wanted, unwanted []int) error
+	Start    func(ctx context.Context, t EventTorrent) error
+}
+
+// AddMagnetLink adds the magnet link and selects its files by so. A link without so is added and started directly.
+// It blocks until the metadata is received, the torrent is left paused if ctx is done before.
+func (a *SelectOnlyAdder) AddMagnetLink(ctx context.Context, l *MagnetLink) (EventTorrent, error) {
+	if len(l.So) == 0 {
+		t, _, err := a.Add(ctx, l, false)
+		return t, err
+	}
+	torrentLink, err := l.AsTorrent()
+	if err != nil {
+		return EventTorrent{}, err
+	}
+
+	// Subscribe before adding so the event is not missed
+	received := make(chan *TorrentFile, 1)
+	unsubscribe := a.Bus.SubscribeFunc(func(e Event) {
+		event, ok := e.(MetadataReceivedEvent)
+		if !ok || event.Metadata == nil || !shareInfoHash(torrentLink.InfoHashs, []HashValue{event.Torrent.InfoHash}) {
+			return
+		}
+		select {
+		case received <- event.Metadata:
+		default:
+		}
+	}, EventMetadataReceived)
+	defer unsubscribe()
+
+	t, metadata, err := a.Add(ctx, l, true)
+	if err != nil {
+		return t, err
+	}
+	if metadata == nil {
+		select {
+		case metadata = <-received:
+		case <-ctx.Done():
+			return t, fmt.Errorf("%w: Waiting for metadata [%v] [%v]", ErrSelectOnly, t.Name, ctx.Err())
+		}
+	}
+
+	wanted, unwanted := SelectFiles(l.So, metadata)
+	if len(wanted) == 0 {
+		// Nothing in range, leave it paused rather than downloading everything
+		return t, fmt.Errorf("%w: No file in range [%v] [%v]", ErrSelectOnly, t.Name, EncodeNumRanges(l.So))
+	}
+	if err := a.SetFiles(ctx, t, wanted, unwanted); err != nil {
+		return t, fmt.Errorf("%w: [%v] [%v]", ErrSelectOnly, t.Name, err)
+	}
+	if err := a.Start(ctx, t); err != nil {
+		return t, fmt.Errorf("%w: Start [%v] [%v]", ErrSelectOnly, t.Name, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,169 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:43:33
+//
+// File Name: magnet_link_sign.go
+// Description:
+//
+//	Sign magnet links with ed25519 so that links distributed through untrusted channels are tamper-evident.
+//
+//	The signature is put in the experimental parameter x.sig and the public key (optional, to select the key
+//	when verifying) in x.pk, both are unpadded base64 url encoded. The signed message is the uri (see String)
+//	of the magnet link without x.sig, so any modification of the other parameters breaks the signature.
+//
+//	Reference:
+//
+//		https://www.rfc-editor.org/rfc/rfc8032
+//
+
+package transmission
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Signature parameters, the keys of MagnetLink.Exps
+const (
+	MagnetLinkSignatureParameter = "sig"
+	MagnetLinkPublicKeyParameter = "pk"
+)
+
+// Errors
+var (
+	ErrMagnetLinkNotSigned        = errors.New("Magnet link is not signed")
+	ErrInvalidMagnetLinkSignature = errors.New("Invalid magnet link signature")
+	ErrUntrustedMagnetLinkKey     = errors.New("Untrusted magnet link key")
+	ErrMalformedMagnetLinkKey     = errors.New("Malformed magnet link signing key")
+)
+
+// Sign signs the magnet link by key, the x.sig and x.pk parameters are replaced.
+// Don't modify the link after signing, otherwise the signature is invalid.
+func (l *MagnetLink) Sign(key ed25519.PrivateKey) {
+	if l.Exps == nil {
+		l.Exps = make(map[string][]string)
+	}
+	delete(l.Exps, MagnetLinkSignatureParameter)
+	l.Exps[MagnetLinkPublicKeyParameter] = []string{EncodeMagnetLinkKey(key.Public().(ed25519.PublicKey))}
+	sig := ed25519.Sign(key, []byte(magnetLinkSignedMessage(l)))
+	l.Exps[MagnetLinkSignatureParameter] = []string{base64.RawURLEncoding.EncodeToString(sig)}
+	l.ResetCache()
+}
+
+// VerifySignature verifies the signature of magnet link by the trusted keys, the key which signed the link is
+// returned. If x.pk exists, it must be one of the trusted keys. The link should be parsed without
+// WithMagnetLinkParseDnCharsetOption, since the converted display names are not the signed ones.
+func (l *MagnetLink) VerifySignature(trusted ...ed25519.PublicKey) (ed25519.PublicKey, error) {
+	sigs := l.Exps[MagnetLinkSignatureParameter]
+	if len(sigs) == 0 {
+		return nil, ErrMagnetLinkNotSigned
+	}
+	if len(sigs) > 1 {
+		return nil, fmt.Errorf("%w: Multiple signatures", ErrInvalidMagnetLinkSignature)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigs[0])
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: Malformed signature", ErrInvalidMagnetLinkSignature)
+	}
+
+	keys := trusted
+	if pks := l.Exps[MagnetLinkPublicKeyParameter]; len(pks) > 0 {
+		if len(pks) > 1 {
+			return nil, fmt.Errorf("%w: Multiple public keys", ErrInvalidMagnetLinkSignature)
+		}
+		pk, err := ParseMagnetLinkPublicKey(pks[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMagnetLinkSignature, err)
+		}
+		keys = nil
+		for _, key := range trusted {
+			if key.Equal(pk) {
+				keys = []ed25519.PublicKey{key}
+				break
+			}
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("%w: [%v]", ErrUntrustedMagnetLinkKey, pks[0])
+		}
+	}
+
+	message := []byte(magnetLinkSignedMessage(l))
+	for _, key := range keys {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, message, sig) {
+			return key, nil
+		}
+	}
+	return nil, ErrInvalidMagnetLinkSignature
+}
+
+// magnetLinkSignedMessage returns the uri of magnet link without the signature
+func magnetLinkSignedMessage(l *MagnetLink) string {
+	m := MagnetLink{
+		Dn:       l.Dn,
+		Xt:       l.Xt,
+		Xl:       l.Xl,
+		As:       l.As,
+		Xs:       l.Xs,
+		Kt:       l.Kt,
+		Mt:       l.Mt,
+		Tr:       l.Tr,
+		So:       l.So,
+		Exps:     make(map[string][]string, len(l.Exps)),
+		Unknowns: l.Unknowns,
+	}
+	for key, values := range l.Exps {
+		if key != MagnetLinkSignatureParameter {
+			m.Exps[key] = values
+		}
+	}
+	return m.String()
+}
+
+//
+//
+//
+// Keys
+//
+//
+//
+
+// GenerateMagnetLinkKey generates a new ed25519 key pair to sign magnet links
+func GenerateMagnetLinkKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// EncodeMagnetLinkKey encodes the public key, or the seed of private key (ed25519.PrivateKey.Seed), as unpadded
+// base64 url encoding
+func EncodeMagnetLinkKey(key []byte) string {
+	return base64.RawURLEncoding.EncodeToString(key)
+}
+
+// ParseMagnetLinkPublicKey parses the public key encoded by EncodeMagnetLinkKey
+func ParseMagnetLinkPublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedMagnetLinkKey, err)
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: Bad public key length [%v]", ErrMalformedMagnetLinkKey, len(b))
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParseMagnetLinkPrivateKey parses the private key encoded by EncodeMagnetLinkKey, either the seed or the whole key
+func ParseMagnetLinkPrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedMagnetLinkKey, err)
+	}
+	switch len(b) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(b), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(b), nil
+	default:
+		return nil, fmt.Errorf("%w: Bad private key length [%v]", ErrMalformedMagnetLinkKey, len(b))
+	}
+}
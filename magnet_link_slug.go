@@ -0,0 +1,121 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:43:53
+//
+// File Name: magnet_link_slug.go
+// Description:
+//
+//	Short url-safe slugs of torrent magnet links, e.g. for link shortening front ends
+//
+
+package transmission
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+)
+
+// slugHashBytes defines the number of info hash bytes in slug, the slug is 16 base32 chars and 1 checksum char
+const slugHashBytes = 10
+
+const slugAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+
+var slugEncoding = base32.NewEncoding(slugAlphabet).WithPadding(base32.NoPadding)
+
+// Errors
+var (
+	ErrInvalidSlug   = errors.New("Invalid slug")
+	ErrSlugCollision = errors.New("Slug collision")
+)
+
+// Slug returns the short url-safe identifier of the first info hash: the lower case base32 of the first 10 bytes,
+// followed by a checksum char. It's deterministic, different torrents may have the same slug in theory.
+func (l *TorrentMagnetLink) Slug() string {
+	if len(l.InfoHashs) == 0 {
+		return ""
+	}
+	return InfoHashSlug(l.InfoHashs[0])
+}
+
+// InfoHashSlug returns the slug of info hash, see TorrentMagnetLink.Slug
+func InfoHashSlug(infoHash HashValue) string {
+	b := infoHash.Value
+	if len(b) > slugHashBytes {
+		b = b[:slugHashBytes]
+	}
+	encoded := slugEncoding.EncodeToString(b)
+	return encoded + string(slugChecksum(encoded))
+}
+
+// ValidateSlug checks the format and checksum of slug, the slug is case insensitive
+func ValidateSlug(slug string) error {
+	slug = strings.ToLower(slug)
+	if len(slug) != slugEncoding.EncodedLen(slugHashBytes)+1 {
+		return fmt.Errorf("%w: Bad length", ErrInvalidSlug)
+	}
+	encoded := slug[:len(slug)-1]
+	if _, err := slugEncoding.DecodeString(encoded); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSlug, err)
+	}
+	if slug[len(slug)-1] != slugChecksum(encoded) {
+		return fmt.Errorf("%w: Bad checksum", ErrInvalidSlug)
+	}
+	return nil
+}
+
+func slugChecksum(encoded string) byte {
+	return slugAlphabet[crc32.ChecksumIEEE([]byte(encoded))%32]
+}
+
+// SlugMap resolves slugs to magnet links. It's safe for concurrent use.
+type SlugMap struct {
+	mutex sync.RWMutex
+	links map[string]*TorrentMagnetLink
+}
+
+// NewSlugMap creates a new SlugMap
+func NewSlugMap() *SlugMap {
+	return &SlugMap{links: make(map[string]*TorrentMagnetLink)}
+}
+
+// Add adds the magnet link and returns its slug. Adding a link with the same info hash replaces the old one, if
+// another torrent has the same slug, ErrSlugCollision is returned.
+func (m *SlugMap) Add(l *TorrentMagnetLink) (string, error) {
+	slug := l.Slug()
+	if slug == "" {
+		return "", fmt.Errorf("%w: No info hash", ErrWrongMagnetLinkType)
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if old, ok := m.links[slug]; ok && !bytes.Equal(old.InfoHashs[0].Value, l.InfoHashs[0].Value) {
+		return "", fmt.Errorf("%w: [%v]", ErrSlugCollision, slug)
+	}
+	m.links[slug] = l
+	return slug, nil
+}
+
+// Resolve returns the magnet link of slug, the slug is case insensitive
+func (m *SlugMap) Resolve(slug string) (*TorrentMagnetLink, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	l, ok := m.links[strings.ToLower(slug)]
+	return l, ok
+}
+
+// Remove removes the slug
+func (m *SlugMap) Remove(slug string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.links, strings.ToLower(slug))
+}
+
+// Len returns the number of slugs
+func (m *SlugMap) Len() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.links)
+}
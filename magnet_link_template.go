@@ -0,0 +1,106 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:39:50
+//
+// File Name: magnet_link_template.go
+// Description:
+//
+//	Format magnet links (or other links, e.g. of indexer sites) by templates
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var (
+	ErrMalformedMagnetLinkTemplate = errors.New("Malformed magnet link template")
+)
+
+// DefaultMagnetLinkTemplate defines the default magnet link template
+const DefaultMagnetLinkTemplate = "magnet:?xt=urn:btih:{infoHash}&dn={name}&xl={size}{trackers}"
+
+// MagnetLinkTemplateData defines the data of magnet link template.
+//
+// Placeholders:
+//
+//	{infoHash} or {infohash}  Lower case hex of info hash
+//	{INFOHASH}                Upper case hex of info hash
+//	{infoHashBase32}          Base32 of info hash
+//	{name}                    Query escaped name
+//	{size}                    Total length in bytes
+//	{trackers}                "&tr=..." of each tracker (query escaped), empty if no tracker
+type MagnetLinkTemplateData struct {
+	InfoHash HashValue
+	Name     string
+	Size     int64
+	Trackers []string
+}
+
+// NewMagnetLinkTemplateData creates the template data of torrent magnet link, the first info hash is used
+func NewMagnetLinkTemplateData(l *TorrentMagnetLink) MagnetLinkTemplateData {
+	data := MagnetLinkTemplateData{Trackers: l.Tr}
+	if len(l.InfoHashs) > 0 {
+		data.InfoHash = l.InfoHashs[0]
+	}
+	if len(l.Dn) > 0 {
+		data.Name = l.Dn[0]
+	}
+	if len(l.Xl) > 0 {
+		data.Size = int64(l.Xl[0])
+	}
+	return data
+}
+
+// NewTorrentMagnetLinkTemplateData creates the template data of torrent file, the first info hash is used
+func NewTorrentMagnetLinkTemplateData(t *TorrentFile) MagnetLinkTemplateData {
+	data := MagnetLinkTemplateData{Name: t.Info.Name, Size: t.TotalLength(), Trackers: t.Trackers()}
+	if len(t.InfoHashs) > 0 {
+		data.InfoHash = t.InfoHashs[0]
+	}
+	return data
+}
+
+// FormatMagnetLink formats the template with data, see MagnetLinkTemplateData for placeholders.
+// Unknown placeholders are errors.
+func FormatMagnetLink(tmpl string, data MagnetLinkTemplateData) (string, error) {
+	var builder strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			builder.WriteString(tmpl)
+			return builder.String(), nil
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: Unclosed placeholder", ErrMalformedMagnetLinkTemplate)
+		}
+		builder.WriteString(tmpl[:start])
+		name := tmpl[start+1 : start+end]
+		switch name {
+		case "infoHash", "infohash":
+			builder.WriteString(data.InfoHash.InfoHashString(InfoHashEncodingHex))
+		case "INFOHASH":
+			builder.WriteString(strings.ToUpper(data.InfoHash.InfoHashString(InfoHashEncodingHex)))
+		case "infoHashBase32":
+			builder.WriteString(data.InfoHash.InfoHashString(InfoHashEncodingBase32))
+		case "name":
+			builder.WriteString(url.QueryEscape(data.Name))
+		case "size":
+			builder.WriteString(strconv.FormatInt(data.Size, 10))
+		case "trackers":
+			for _, tracker := range data.Trackers {
+				builder.WriteString("&tr=")
+				builder.WriteString(url.QueryEscape(tracker))
+			}
+		default:
+			return "", fmt.Errorf("%w: Unknown placeholder [%v]", ErrMalformedMagnetLinkTemplate, name)
+		}
+		tmpl = tmpl[start+end+1:]
+	}
+}
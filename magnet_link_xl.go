@@ -0,0 +1,40 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:19:34
+//
+// File Name: magnet_link_xl.go
+// Description:
+//
+//	Cross validate exact length (xl) against the torrent metadata
+//
+
+package transmission
+
+import (
+	"fmt"
+)
+
+// ExactLengthMismatch is the warning reported when xl doesn't match the total content length of metadata.
+// Some indexers put wrong sizes in links, so it's a warning rather than a parse error.
+type ExactLengthMismatch struct {
+	Xl     []int // Exact lengths in magnet link
+	Actual int64 // Total content length in metadata
+}
+
+func (w *ExactLengthMismatch) Error() string {
+	return fmt.Sprintf("Exact length mismatch: xl %v, metadata %v", w.Xl, w.Actual)
+}
+
+// ValidateExactLength compares Xl with the total content length of the torrent file.
+// Returns nil if there's no xl or any xl matches.
+func (l *MagnetLink) ValidateExactLength(t *TorrentFile) *ExactLengthMismatch {
+	if len(l.Xl) == 0 {
+		return nil
+	}
+	total := t.TotalLength()
+	for _, xl := range l.Xl {
+		if int64(xl) == total {
+			return nil
+		}
+	}
+	return &ExactLengthMismatch{Xl: l.Xl, Actual: total}
+}
@@ -0,0 +1,33 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:34:02
+//
+// File Name: mmap_other.go
+// Description:
+//
+//	Memory mapped files are not supported on this platform, readers fall back to normal reads
+//
+
+//go:build !unix
+
+package transmission
+
+import (
+	"errors"
+)
+
+// mmapFile defines a read only memory mapped file
+type mmapFile struct{}
+
+func openMmapFile(path string) (*mmapFile, error) {
+	return nil, errors.New("Mmap is not supported")
+}
+
+// ReadAt implements io.ReaderAt
+func (m *mmapFile) ReadAt(p []byte, off int64) (int, error) {
+	return 0, errors.New("Mmap is not supported")
+}
+
+// Close unmaps the file
+func (m *mmapFile) Close() error {
+	return nil
+}
@@ -0,0 +1,62 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:34:02
+//
+// File Name: mmap_unix.go
+// Description:
+//
+//	Read only memory mapped files
+//
+
+//go:build unix
+
+package transmission
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// mmapFile defines a read only memory mapped file
+type mmapFile struct {
+	data []byte
+}
+
+func openMmapFile(path string) (*mmapFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size <= 0 || int64(int(size)) != size {
+		return nil, errors.New("Cannot map file by size")
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mmapFile{data}, nil
+}
+
+// ReadAt implements io.ReaderAt
+func (m *mmapFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.data)) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
+
+// Close unmaps the file
+func (m *mmapFile) Close() error {
+	return syscall.Munmap(m.data)
+}
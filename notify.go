@@ -0,0 +1,192 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:25:15
+//
+// File Name: notify.go
+// Description:
+//
+//	Notify torrent lifecycle events, e.g. by webhooks
+//
+
+package transmission
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Errors
+var (
+	ErrNotify = errors.New("Failed to notify")
+)
+
+// Webhook defaults
+const (
+	DefaultWebhookMaxRetries = 3
+	DefaultWebhookRetryDelay = time.Second
+)
+
+// WebhookSignatureHeader defines the header of payload signature, the value is "sha256=" + hex(hmac-sha256(secret, body))
+const WebhookSignatureHeader = "X-Gtransmission-Signature"
+
+// Notifier defines the notifier interface
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// NotifierFunc is an adapter to use functions as Notifier
+type NotifierFunc func(ctx context.Context, e Event) error
+
+// Notify implements Notifier
+func (f NotifierFunc) Notify(ctx context.Context, e Event) error {
+	return f(ctx, e)
+}
+
+// RunNotifier notifies the events of types (all types if empty) from the bus until ctx is done.
+// Notify errors are passed to onError if it's not nil.
+func RunNotifier(ctx context.Context, bus *EventBus, notifier Notifier, onError func(e Event, err error), types ...string) error {
+	events, unsubscribe := bus.Subscribe(64, types...)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := notifier.Notify(ctx, e); err != nil && onError != nil {
+				onError(e, err)
+			}
+		}
+	}
+}
+
+// WebhookPayload defines the json payload posted by WebhookNotifier
+type WebhookPayload struct {
+	Event   string         `json:"event"`
+	Time    time.Time      `json:"time"`
+	Torrent WebhookTorrent `json:"torrent"`
+	Tracker string         `json:"tracker,omitempty"` // For EventTrackerError
+	Error   string         `json:"error,omitempty"`   // For EventTrackerError
+}
+
+// WebhookTorrent defines the torrent in webhook payload
+type WebhookTorrent struct {
+	ID          string   `json:"id,omitempty"`
+	InfoHash    string   `json:"infoHash,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	DownloadDir string   `json:"downloadDir,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// NewWebhookPayload creates the webhook payload of event
+func NewWebhookPayload(e Event) WebhookPayload {
+	t := e.EventTorrent()
+	payload := WebhookPayload{
+		Event: e.EventType(),
+		Time:  e.EventTime(),
+		Torrent: WebhookTorrent{
+			ID:          t.ID,
+			InfoHash:    hex.EncodeToString(t.InfoHash.Value),
+			Name:        t.Name,
+			DownloadDir: t.DownloadDir,
+			Labels:      t.Labels,
+		},
+	}
+	if e, ok := e.(TrackerErrorEvent); ok {
+		payload.Tracker = e.Tracker
+		if e.Err != nil {
+			payload.Error = e.Err.Error()
+		}
+	}
+	return payload
+}
+
+// WebhookNotifier posts json payloads (WebhookPayload) to webhook urls
+type WebhookNotifier struct {
+	URLs       []string
+	Secret     []byte       // Sign the payload by hmac-sha256 if not empty, see WebhookSignatureHeader
+	Client     *http.Client // http.DefaultClient is used if nil
+	MaxRetries int          // DefaultWebhookMaxRetries is used if zero, negative means no retry
+	RetryDelay time.Duration
+}
+
+// Notify implements Notifier, the payload is posted to all urls
+func (n *WebhookNotifier) Notify(ctx context.Context, e Event) error {
+	body, err := json.Marshal(NewWebhookPayload(e))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotify, err)
+	}
+	var errs []error
+	for _, url := range n.URLs {
+		if err := n.post(ctx, url, body); err != nil {
+			errs = append(errs, fmt.Errorf("%w: [%v] %v", ErrNotify, url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *WebhookNotifier) post(ctx context.Context, url string, body []byte) error {
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	maxRetries := n.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultWebhookMaxRetries
+	}
+	delay := n.RetryDelay
+	if delay <= 0 {
+		delay = DefaultWebhookRetryDelay
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries || attempt == 0; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay << uint(attempt-1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		var retryable bool
+		retryable, lastErr = n.postOnce(ctx, client, url, body)
+		if lastErr == nil || !retryable {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func (n *WebhookNotifier) postOnce(ctx context.Context, client *http.Client, url string, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.Secret) > 0 {
+		mac := hmac.New(sha256.New, n.Secret)
+		mac.Write(body)
+		req.Header.Set(WebhookSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable = resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("Unexpected status [%v]", resp.Status)
+}
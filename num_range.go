@@ -9,58 +9,325 @@ package transmission
 
 import (
 	"errors"
+	"sort"
 	"strconv"
 	"strings"
+	"unsafe"
 )
 
-// NumRange defines a number range
-type NumRange struct {
-	Start        int
-	End          int
+// Integer defines the integer types which can be used in Range
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Range defines a number range of integer type T.
+// An unbounded side is the inclusive min (or max) value of T, see NewRangeFrom and NewRangeUntil.
+type Range[T Integer] struct {
+	Start        T
+	End          T
 	IncludeStart bool
 	IncludeEnd   bool
 }
 
+// NumRange defines a number range
+type NumRange = Range[int]
+
+// Int64Range defines a number range of int64, e.g. byte ranges
+type Int64Range = Range[int64]
+
 // NewSingleNumRange creates a new NumRange by a single number
 func NewSingleNumRange(num int) NumRange {
-	return NumRange{num, num, true, true}
+	return NewSingleRange(num)
+}
+
+// NewSingleRange creates a new Range by a single number
+func NewSingleRange[T Integer](num T) Range[T] {
+	return NewClosedRange(num, num)
+}
+
+// NewClosedRange creates a new Range [start, end]
+func NewClosedRange[T Integer](start, end T) Range[T] {
+	return Range[T]{Start: start, End: end, IncludeStart: true, IncludeEnd: true}
+}
+
+// NewOpenRange creates a new Range (start, end)
+func NewOpenRange[T Integer](start, end T) Range[T] {
+	return Range[T]{Start: start, End: end}
+}
+
+// NewHalfOpenRange creates a new Range [start, end)
+func NewHalfOpenRange[T Integer](start, end T) Range[T] {
+	return Range[T]{Start: start, End: end, IncludeStart: true}
+}
+
+// NewRangeFrom creates a new Range [start, +inf)
+func NewRangeFrom[T Integer](start T) Range[T] {
+	return Range[T]{Start: start, End: maxInteger[T](), IncludeStart: true, IncludeEnd: true}
+}
+
+// NewRangeUntil creates a new Range (-inf, end]
+func NewRangeUntil[T Integer](end T) Range[T] {
+	return Range[T]{Start: minInteger[T](), End: end, IncludeStart: true, IncludeEnd: true}
 }
 
 // ParseNumRangeFromString parses a number range from string
 // Format:
-//	\d+
-//	\d+\-\d+
+//
+//	\d+       A single number
+//	\d+\-\d+  Closed range
+//	\d+\-     Unbounded end
+//	\-\d+     Unbounded start (unsigned types only, the start is 0)
+//
+// A number of signed types may have a leading "-", the range separator is the first "-" following a digit, e.g. "-5"
+// is the single number -5 and "-5--3" is [-5, -3].
+//
+// A range can be surrounded by brackets to specify the bounds, "[" / "]" means inclusive (the default) and "(" / ")" means
+// exclusive, e.g. "[0-10)", "(5-]"
 func ParseNumRangeFromString(s string) (r NumRange, err error) {
-	strs := strings.Split(s, "-")
+	return ParseRangeFromString[int](s)
+}
+
+// ParseInt64RangeFromString parses an int64 range from string, the format is the same as ParseNumRangeFromString
+func ParseInt64RangeFromString(s string) (r Int64Range, err error) {
+	return ParseRangeFromString[int64](s)
+}
+
+// ParseRangeFromString parses a range of integer type T from string, the format is the same as ParseNumRangeFromString
+func ParseRangeFromString[T Integer](s string) (r Range[T], err error) {
+	r.IncludeStart = true
+	r.IncludeEnd = true
+	if strings.HasPrefix(s, "[") {
+		s = s[1:]
+	} else if strings.HasPrefix(s, "(") {
+		r.IncludeStart = false
+		s = s[1:]
+	}
+	if strings.HasSuffix(s, "]") {
+		s = s[:len(s)-1]
+	} else if strings.HasSuffix(s, ")") {
+		r.IncludeEnd = false
+		s = s[:len(s)-1]
+	}
+
+	strs := splitRangeString[T](s)
 	if len(strs) == 1 {
-		var num int
-		num, err = strconv.Atoi(s)
+		var num T
+		num, err = parseInteger[T](s)
 		if err != nil {
 			return
 		}
 		r.Start = num
 		r.End = num
-		r.IncludeStart = true
-		r.IncludeEnd = true
 		return
 	} else if len(strs) == 2 {
-		var num int
+		var num T
 		// Start
-		num, err = strconv.Atoi(strs[0])
-		if err != nil {
-			return
+		if strs[0] == "" {
+			r.Start = minInteger[T]()
+			r.IncludeStart = true
+		} else {
+			num, err = parseInteger[T](strs[0])
+			if err != nil {
+				return
+			}
+			r.Start = num
 		}
-		r.Start = num
-		r.IncludeStart = true
 		// End
-		num, err = strconv.Atoi(strs[1])
-		if err != nil {
-			return
+		if strs[1] == "" {
+			r.End = maxInteger[T]()
+			r.IncludeEnd = true
+		} else {
+			num, err = parseInteger[T](strs[1])
+			if err != nil {
+				return
+			}
+			r.End = num
 		}
-		r.End = num
-		r.IncludeEnd = true
+		return
 	}
 
 	err = errors.New("Malformed num range string")
 	return
 }
+
+// splitRangeString splits s by the range separator, the first "-" following a digit. A leading "-" is the separator
+// for unsigned types (and of the range "-").
+func splitRangeString[T Integer](s string) []string {
+	if strings.HasPrefix(s, "-") && (!isSignedInteger[T]() || s == "-") {
+		return []string{"", s[1:]}
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] == '-' && s[i-1] >= '0' && s[i-1] <= '9' {
+			return []string{s[:i], s[i+1:]}
+		}
+	}
+	return []string{s}
+}
+
+func isSignedInteger[T Integer]() bool {
+	var zero T
+	return zero-1 < zero
+}
+
+func minInteger[T Integer]() T {
+	if isSignedInteger[T]() {
+		var zero T
+		return ^T(0) << (unsafe.Sizeof(zero)*8 - 1)
+	}
+	return 0
+}
+
+func maxInteger[T Integer]() T {
+	return ^minInteger[T]()
+}
+
+func parseInteger[T Integer](s string) (T, error) {
+	var zero T
+	bitSize := int(unsafe.Sizeof(zero)) * 8
+	if isSignedInteger[T]() {
+		num, err := strconv.ParseInt(s, 10, bitSize)
+		return T(num), err
+	}
+	num, err := strconv.ParseUint(s, 10, bitSize)
+	return T(num), err
+}
+
+func formatInteger[T Integer](num T) string {
+	if isSignedInteger[T]() {
+		return strconv.FormatInt(int64(num), 10)
+	}
+	return strconv.FormatUint(uint64(num), 10)
+}
+
+// String encodes the range in the format of ParseNumRangeFromString.
+// Exclusive bounds are converted to inclusive ones, brackets are only used for empty ranges. The start is always
+// written (an unbounded start as the min value of T), so negative bounds are parsed back as is.
+func (r Range[T]) String() string {
+	n, ok := r.normalize()
+	if !ok {
+		var builder strings.Builder
+		if r.IncludeStart {
+			builder.WriteByte('[')
+		} else {
+			builder.WriteByte('(')
+		}
+		builder.WriteString(formatInteger(r.Start))
+		builder.WriteByte('-')
+		builder.WriteString(formatInteger(r.End))
+		if r.IncludeEnd {
+			builder.WriteByte(']')
+		} else {
+			builder.WriteByte(')')
+		}
+		return builder.String()
+	}
+	if n.Start == n.End {
+		return formatInteger(n.Start)
+	}
+	start, end := formatInteger(n.Start), ""
+	if !n.IsUnboundedEnd() {
+		end = formatInteger(n.End)
+	}
+	return start + "-" + end
+}
+
+// IsUnboundedStart checks if the range has no lower bound, the unsigned ranges starting from 0 are not unbounded
+func (r Range[T]) IsUnboundedStart() bool {
+	return isSignedInteger[T]() && r.Start == minInteger[T]() && r.IncludeStart
+}
+
+// IsUnboundedEnd checks if the range has no upper bound
+func (r Range[T]) IsUnboundedEnd() bool {
+	return r.End == maxInteger[T]() && r.IncludeEnd
+}
+
+// Contains checks if num is in the range
+func (r Range[T]) Contains(num T) bool {
+	if num < r.Start || (num == r.Start && !r.IncludeStart) {
+		return false
+	}
+	if num > r.End || (num == r.End && !r.IncludeEnd) {
+		return false
+	}
+	return true
+}
+
+// IsEmpty checks if the range contains no number
+func (r Range[T]) IsEmpty() bool {
+	_, ok := r.normalize()
+	return !ok
+}
+
+// normalize converts the sides to inclusive, ok is false if the range is empty
+func (r Range[T]) normalize() (n Range[T], ok bool) {
+	n = Range[T]{Start: r.Start, End: r.End, IncludeStart: true, IncludeEnd: true}
+	if !r.IncludeStart {
+		if r.Start+1 < r.Start {
+			// Exclusive max value
+			return n, false
+		}
+		n.Start++
+	}
+	if !r.IncludeEnd {
+		if r.End-1 > r.End {
+			// Exclusive min value
+			return n, false
+		}
+		n.End--
+	}
+	if n.Start > n.End {
+		return n, false
+	}
+	return n, true
+}
+
+// MergeNumRanges sorts the ranges and merges the overlapped or adjacent ones. Empty ranges are dropped.
+// The returned ranges are inclusive.
+func MergeNumRanges(ranges []NumRange) []NumRange {
+	return MergeRanges(ranges)
+}
+
+// MergeRanges sorts the ranges and merges the overlapped or adjacent ones. Empty ranges are dropped.
+// The returned ranges are inclusive.
+func MergeRanges[T Integer](ranges []Range[T]) []Range[T] {
+	var merged []Range[T]
+	for _, r := range ranges {
+		if n, ok := r.normalize(); ok {
+			merged = append(merged, n)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Start < merged[j].Start })
+
+	var n int
+	for i := range merged {
+		if n > 0 {
+			prev := &merged[n-1]
+			// NOTE: Compare by Start-1 rather than End+1 to avoid overflow
+			if merged[i].Start <= prev.End || merged[i].Start-1 == prev.End {
+				if merged[i].End > prev.End {
+					prev.End = merged[i].End
+				}
+				continue
+			}
+		}
+		merged[n] = merged[i]
+		n++
+	}
+	return merged[:n]
+}
+
+// EncodeNumRanges encodes the ranges to the compact comma / hyphen form, e.g. "0,2-4,7".
+// Overlapped and adjacent ranges are merged.
+func EncodeNumRanges(ranges []NumRange) string {
+	return EncodeRanges(ranges)
+}
+
+// EncodeRanges encodes the ranges to the compact comma / hyphen form, see EncodeNumRanges
+func EncodeRanges[T Integer](ranges []Range[T]) string {
+	merged := MergeRanges(ranges)
+	strs := make([]string, len(merged))
+	for i, r := range merged {
+		strs[i] = r.String()
+	}
+	return strings.Join(strs, ",")
+}
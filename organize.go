@@ -0,0 +1,225 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:24:52
+//
+// File Name: organize.go
+// Description:
+//
+//	Organize completed downloads into category directories by rules
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Errors
+var (
+	ErrUnsafeTorrentName = errors.New("Unsafe torrent name")
+)
+
+// Organize action
+const (
+	OrganizeMove     = "move"
+	OrganizeHardlink = "hardlink"
+)
+
+// OrganizeRule defines an organize rule. All the non-nil patterns must match
+type OrganizeRule struct {
+	Name    *regexp.Regexp // Matches the torrent name
+	Label   *regexp.Regexp // Matches any label
+	Tracker *regexp.Regexp // Matches any tracker
+	Dir     string         // The category directory
+	Action  string         // OrganizeMove or OrganizeHardlink
+}
+
+// Match checks if the rule matches the torrent
+func (r *OrganizeRule) Match(t EventTorrent) bool {
+	if r.Name != nil && !r.Name.MatchString(t.Name) {
+		return false
+	}
+	if r.Label != nil && !matchAnyString(r.Label, t.Labels) {
+		return false
+	}
+	if r.Tracker != nil && !matchAnyString(r.Tracker, t.Trackers) {
+		return false
+	}
+	return true
+}
+
+func matchAnyString(re *regexp.Regexp, strs []string) bool {
+	for _, s := range strs {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizeResult defines the result of organizing a torrent
+type OrganizeResult struct {
+	Torrent EventTorrent
+	Rule    *OrganizeRule
+	Source  string // The content path, DownloadDir/Name
+	Target  string // The organized path, Rule.Dir/Name
+	Err     error
+}
+
+// Organizer organizes completed downloads. The first matched rule is applied
+type Organizer struct {
+	Rules       []OrganizeRule
+	OnOrganized func(result OrganizeResult) // Called after a torrent is organized (or failed to), e.g. to notify
+}
+
+// Organize applies the first matched rule to the torrent, returns false if no rule matches
+func (o *Organizer) Organize(t EventTorrent) (OrganizeResult, bool) {
+	for i := range o.Rules {
+		rule := &o.Rules[i]
+		if !rule.Match(t) {
+			continue
+		}
+		result := OrganizeResult{Torrent: t, Rule: rule}
+		result.Source, result.Target, result.Err = organizePaths(t, rule.Dir)
+		if result.Err == nil {
+			result.Err = organize(result.Source, result.Target, rule.Action)
+		}
+		if o.OnOrganized != nil {
+			o.OnOrganized(result)
+		}
+		return result, true
+	}
+	return OrganizeResult{}, false
+}
+
+// Run organizes torrents on EventDownloadComplete events from the bus until ctx is done
+func (o *Organizer) Run(ctx context.Context, bus *EventBus) error {
+	events, unsubscribe := bus.Subscribe(64, EventDownloadComplete)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+			o.Organize(e.EventTorrent())
+		}
+	}
+}
+
+func organizePaths(t EventTorrent, dir string) (source, target string, err error) {
+	if t.Name == "" || t.Name == "." || t.Name == ".." || strings.ContainsAny(t.Name, `/\`) {
+		return "", "", fmt.Errorf("%w: [%v]", ErrUnsafeTorrentName, t.Name)
+	}
+	return filepath.Join(t.DownloadDir, t.Name), filepath.Join(dir, t.Name), nil
+}
+
+func organize(source, target, action string) error {
+	if _, err := os.Lstat(target); err == nil {
+		return fmt.Errorf("Target already exists [%v]", target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	switch action {
+	case OrganizeMove, "":
+		err := os.Rename(source, target)
+		var linkErr *os.LinkError
+		if errors.As(err, &linkErr) && isCrossDevice(linkErr.Err) {
+			// The category directory is on another filesystem
+			return moveByCopy(source, target)
+		}
+		return err
+	case OrganizeHardlink:
+		return filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(source, p)
+			if err != nil {
+				return err
+			}
+			dst := filepath.Join(target, rel)
+			if d.IsDir() {
+				return os.MkdirAll(dst, 0755)
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			return os.Link(p, dst)
+		})
+	default:
+		return fmt.Errorf("Unknown organize action [%v]", action)
+	}
+}
+
+// moveByCopy moves source to target by copying the tree and removing source. The partial copy is removed if the copy
+// fails, the source is kept.
+func moveByCopy(source, target string) error {
+	if err := copyTree(source, target); err != nil {
+		os.RemoveAll(target)
+		return err
+	}
+	return os.RemoveAll(source)
+}
+
+// copyTree copies the directories, regular files and symlinks of source to target, modes and modification times are
+// kept
+func copyTree(source, target string) error {
+	return filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dst, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, dst)
+		case d.Type().IsRegular():
+			if err := copyFile(p, dst, info.Mode().Perm()); err != nil {
+				return err
+			}
+			return os.Chtimes(dst, info.ModTime(), info.ModTime())
+		}
+		return nil
+	})
+}
+
+func copyFile(source, target string, perm fs.FileMode) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,217 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:59:29
+//
+// File Name: peer_client.go
+// Description:
+//
+//	Client fingerprinting by the peer id (Azureus style, Shadow style and Mainline style) and the "v" field of the
+//	extended handshake
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0020.html
+//		https://www.bittorrent.org/beps/bep_0010.html
+//		https://wiki.theory.org/BitTorrentSpecification#peer_id
+//
+
+package transmission
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PeerClient defines the client of a peer
+type PeerClient struct {
+	Code    string // Client code of peer id, e.g. "TR" of Azureus style, "S" of Shadow style, "M" of Mainline
+	Name    string // Empty if unknown
+	Version string
+	PeerID  string
+}
+
+// String returns the name and version, e.g. "Transmission 4.0.5"
+func (c PeerClient) String() string {
+	switch {
+	case c.Name == "" && c.Code == "":
+		return "Unknown"
+	case c.Name == "":
+		return strings.TrimSpace("Unknown (" + c.Code + ") " + c.Version)
+	}
+	return strings.TrimSpace(c.Name + " " + c.Version)
+}
+
+// KnownPeerClients defines the clients of the Azureus style peer id codes (e.g. "-TR4050-")
+var KnownPeerClients = map[string]string{
+	"7T": "aTorrent", "AG": "Ares", "A~": "Ares", "AR": "Arctic", "AT": "Artemis", "AV": "Avicora",
+	"AX": "BitPump", "AZ": "Vuze", "BB": "BitBuddy", "BC": "BitComet", "BE": "Baretorrent", "BF": "Bitflu",
+	"BG": "BTG", "BI": "BiglyBT", "BL": "BitCometLite", "BP": "BitTorrent Pro", "BR": "BitRocket",
+	"BS": "BTSlave", "BT": "BitTorrent", "BW": "BitWombat", "BX": "BittorrentX", "CD": "Enhanced CTorrent",
+	"CT": "CTorrent", "DE": "Deluge", "DP": "Propagate Data Client", "EB": "EBit", "ES": "Electric Sheep",
+	"FC": "FileCroc", "FD": "Free Download Manager", "FG": "FlashGet", "FT": "FoxTorrent", "FW": "FrostWire",
+	"FX": "Freebox BitTorrent", "GS": "GSTorrent", "HK": "Hekate", "HL": "Halite", "HM": "hMule",
+	"HN": "Hydranode", "IL": "iLivid", "JS": "Justseed.it", "JT": "JavaTorrent", "KG": "KGet",
+	"KT": "KTorrent", "LC": "LeechCraft", "LH": "LH-ABC", "LP": "Lphant", "LT": "libtorrent (Rasterbar)",
+	"lt": "libTorrent (Rakshasa)", "LW": "LimeWire", "MG": "MediaGet", "MK": "Meerkat", "MO": "MonoTorrent",
+	"MP": "MooPolice", "MR": "Miro", "MT": "MoonlightTorrent", "NB": "Net::BitTorrent", "NX": "Net Transport",
+	"OS": "OneSwarm", "OT": "OmegaTorrent", "PB": "Protocol::BitTorrent", "PD": "Pando", "PI": "PicoTorrent",
+	"PT": "PHPTracker", "qB": "qBittorrent", "QD": "QQDownload", "QT": "Qt 4 Torrent example",
+	"RT": "Retriever", "RZ": "RezTorrent", "S~": "Shareaza alpha/beta", "SB": "Swiftbit", "SD": "Thunder",
+	"SM": "SoMud", "SP": "BitSpirit", "SS": "SwarmScope", "ST": "SymTorrent", "st": "sharktorrent",
+	"SZ": "Shareaza", "TB": "Torch", "TE": "terasaur Seed Bank", "TL": "Tribler", "TN": "TorrentDotNET",
+	"TR": "Transmission", "TS": "Torrentstorm", "TT": "TuoTu", "UL": "uLeecher!", "UM": "µTorrent Mac",
+	"UT": "µTorrent", "UW": "µTorrent Web", "VG": "Vagaa", "WD": "WebTorrent Desktop", "WT": "BitLet",
+	"WW": "WebTorrent", "WY": "FireTorrent", "XF": "Xfplay", "XL": "Xunlei", "XS": "XSwifter",
+	"XT": "XanTorrent", "XX": "Xtorrent", "ZT": "ZipTorrent",
+}
+
+// shadowPeerClients defines the clients of the Shadow style peer id codes (e.g. "S58B-----")
+var shadowPeerClients = map[byte]string{
+	'A': "ABC", 'O': "Osprey Permaseed", 'Q': "BTQueue", 'R': "Tribler", 'S': "Shadow", 'T': "BitTornado",
+	'U': "UPnP NAT Bit Torrent",
+}
+
+// ParsePeerID parses the client of peer id
+func ParsePeerID(peerID []byte) PeerClient {
+	client := PeerClient{PeerID: string(peerID)}
+	switch {
+	case len(peerID) >= 8 && peerID[0] == '-' && peerID[7] == '-':
+		// Azureus style: -XXnnnn-
+		client.Code = string(peerID[1:3])
+		client.Name = KnownPeerClients[client.Code]
+		client.Version = azureusPeerIDVersion(client.Code, string(peerID[3:7]))
+	case len(peerID) >= 3 && string(peerID[:3]) == "TIX":
+		// Tixati: TIXnnnn
+		client.Code, client.Name = "TIX", "Tixati"
+		if len(peerID) >= 7 {
+			if n, err := strconv.Atoi(string(peerID[3:7])); err == nil {
+				client.Version = strconv.Itoa(n/100) + "." + strconv.Itoa(n%100)
+			}
+		}
+	case len(peerID) >= 2 && peerID[0] == 'M' && peerID[1] >= '0' && peerID[1] <= '9':
+		// Mainline style: Mn-n-n- or Mn-nn-n-
+		client.Code, client.Name = "M", "BitTorrent"
+		var parts []string
+		for _, part := range strings.Split(string(peerID[1:min(len(peerID), 8)]), "-") {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		client.Version = strings.Join(parts, ".")
+	case len(peerID) >= 6 && shadowPeerClients[peerID[0]] != "" && (peerID[4] == '-' || peerID[5] == '-'):
+		// Shadow style: Xnnn--
+		client.Code, client.Name = string(peerID[:1]), shadowPeerClients[peerID[0]]
+		var parts []string
+		for _, c := range peerID[1:6] {
+			if c == '-' {
+				break
+			}
+			if n, ok := shadowVersionDigit(c); ok {
+				parts = append(parts, strconv.Itoa(n))
+			}
+		}
+		client.Version = strings.Join(parts, ".")
+	}
+	return client
+}
+
+// ParseClientVersion parses the "v" field of extended handshake, e.g. "qBittorrent/4.6.2" or "µTorrent 3.5.5"
+func ParseClientVersion(v string) PeerClient {
+	v = strings.TrimSpace(v)
+	i := strings.LastIndexAny(v, "/ ")
+	if i < 0 || !strings.ContainsAny(v[i+1:], "0123456789") {
+		return PeerClient{Name: v}
+	}
+	return PeerClient{Name: strings.TrimSpace(v[:i]), Version: strings.TrimPrefix(strings.TrimPrefix(v[i+1:], "v"), "V")}
+}
+
+// IdentifyPeerClient identifies the client by peer id and the "v" field of extended handshake (empty if not
+// received). The name and version of "v" are preferred since they're more precise and not limited to the known codes.
+func IdentifyPeerClient(peerID []byte, v string) PeerClient {
+	client := ParsePeerID(peerID)
+	if v != "" {
+		if c := ParseClientVersion(v); c.Name != "" {
+			client.Name, client.Version = c.Name, c.Version
+		}
+	}
+	return client
+}
+
+// PeerClientFilter matches clients, e.g. to ban known bad clients. Matching is case insensitive.
+type PeerClientFilter struct {
+	Codes    []string // Client codes, e.g. "XL"
+	Names    []string // Substrings of the client name, e.g. "xunlei"
+	Prefixes []string // Prefixes of the raw peer id, e.g. "-SD0100-"
+}
+
+// Match checks if the client matches any rule
+func (f PeerClientFilter) Match(c PeerClient) bool {
+	for _, code := range f.Codes {
+		if c.Code != "" && strings.EqualFold(code, c.Code) {
+			return true
+		}
+	}
+	name := strings.ToLower(c.Name)
+	for _, s := range f.Names {
+		if s != "" && strings.Contains(name, strings.ToLower(s)) {
+			return true
+		}
+	}
+	peerID := strings.ToLower(c.PeerID)
+	for _, prefix := range f.Prefixes {
+		if prefix != "" && strings.HasPrefix(peerID, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// azureusPeerIDVersion decodes the 4 version chars of Azureus style peer id
+func azureusPeerIDVersion(code, v string) string {
+	if code == "TR" && len(v) == 4 {
+		// Transmission: major, 2 digits of minor and a suffix (Z or X for development builds, B for beta)
+		version := v[:1] + "." + v[1:3]
+		if v[0] == '0' {
+			// 0.x: -TR0072-
+			version = "0." + strings.TrimLeft(v[1:3], "0") + v[3:]
+		} else {
+			switch v[3] {
+			case 'Z', 'X':
+				version += "+"
+			case 'B':
+				version += " beta"
+			}
+		}
+		return version
+	}
+	if (code == "UT" || code == "UM" || code == "UW") && len(v) == 4 {
+		// µTorrent: the last char is the release type, e.g. S for stable and B for beta
+		v = v[:3]
+	}
+	var parts []string
+	for i := 0; i < len(v); i++ {
+		n, err := strconv.ParseInt(v[i:i+1], 36, 0)
+		if err != nil {
+			return v
+		}
+		parts = append(parts, strconv.Itoa(int(n)))
+	}
+	// The last part is the build number, omitted if zero
+	if len(parts) == 4 && parts[3] == "0" {
+		parts = parts[:3]
+	}
+	return strings.Join(parts, ".")
+}
+
+func shadowVersionDigit(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10, true
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 36, true
+	case c == '.':
+		return 62, true
+	}
+	return 0, false
+}
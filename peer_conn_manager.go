@@ -0,0 +1,282 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:47:08
+//
+// File Name: peer_conn_manager.go
+// Description:
+//
+//	Peer connection admission shared across torrents: global, per torrent, half-open and per ip limits, and
+//	eviction of the worst performing connection when an incoming connection arrives at a full limit.
+//
+//	It only does the bookkeeping, the caller dials, accepts and closes the connections.
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Peer connection limit defaults, the global and per torrent limits are the same as transmission
+const (
+	DefaultPeerLimitGlobal     = 200
+	DefaultPeerLimitPerTorrent = 50
+	DefaultPeerLimitHalfOpen   = 32
+	DefaultPeerLimitPerIP      = 4
+	DefaultPeerEvictionGrace   = time.Minute
+)
+
+// Errors
+var (
+	ErrPeerConnLimit   = errors.New("Peer connection limit reached")
+	ErrUnknownPeerConn = errors.New("Unknown peer connection")
+)
+
+// PeerConnLimits defines the peer connection limits, no limit if zero
+type PeerConnLimits struct {
+	Global        int           // Established and half-open connections of all torrents
+	PerTorrent    int           // Established and half-open connections of a torrent
+	HalfOpen      int           // Outgoing connections not established yet
+	PerIP         int           // Connections to the same ip of all torrents
+	EvictionGrace time.Duration // Connections younger than this are not evicted
+}
+
+// PeerConnLimitsFromSettings creates the limits from transmission settings (peer-limit-global,
+// peer-limit-per-torrent), the other limits are the defaults
+func PeerConnLimitsFromSettings(s *TransmissionSettings) PeerConnLimits {
+	limits := PeerConnLimits{
+		Global:        DefaultPeerLimitGlobal,
+		PerTorrent:    DefaultPeerLimitPerTorrent,
+		HalfOpen:      DefaultPeerLimitHalfOpen,
+		PerIP:         DefaultPeerLimitPerIP,
+		EvictionGrace: DefaultPeerEvictionGrace,
+	}
+	if s.PeerLimitGlobal != nil {
+		limits.Global = *s.PeerLimitGlobal
+	}
+	if s.PeerLimitPerTorrent != nil {
+		limits.PerTorrent = *s.PeerLimitPerTorrent
+	}
+	return limits
+}
+
+// PeerConnID identifies a connection in PeerConnManager
+type PeerConnID uint64
+
+// PeerConnStats defines the connection counts
+type PeerConnStats struct {
+	Established int
+	HalfOpen    int
+	Evicted     int64 // Total evicted connections
+}
+
+// PeerConnManager admits peer connections by limits. It's safe for concurrent use.
+type PeerConnManager struct {
+	Limits PeerConnLimits
+
+	mutex    sync.Mutex
+	nextID   PeerConnID
+	conns    map[PeerConnID]*peerConnEntry
+	torrents map[string]int
+	ips      map[string]int
+	halfOpen int
+	evicted  int64
+}
+
+type peerConnEntry struct {
+	Torrent  string
+	IP       string
+	HalfOpen bool
+	Since    time.Time // When the connection is established
+	Rate     *RateEstimator
+	Client   PeerClient
+}
+
+// NewPeerConnManager creates a new PeerConnManager
+func NewPeerConnManager(limits PeerConnLimits) *PeerConnManager {
+	return &PeerConnManager{
+		Limits:   limits,
+		conns:    make(map[PeerConnID]*peerConnEntry),
+		torrents: make(map[string]int),
+		ips:      make(map[string]int),
+	}
+}
+
+// Dial reserves a half-open slot to dial the peer of torrent (e.g. hex of info hash), Connected or Close must be
+// called with the returned id after dialing. Outgoing connections never evict others.
+func (m *PeerConnManager) Dial(torrent string, ip net.IP) (PeerConnID, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := ip.String()
+	switch {
+	case m.Limits.HalfOpen > 0 && m.halfOpen >= m.Limits.HalfOpen:
+		return 0, fmt.Errorf("%w: Half open", ErrPeerConnLimit)
+	case m.Limits.PerIP > 0 && m.ips[key] >= m.Limits.PerIP:
+		return 0, fmt.Errorf("%w: Per ip [%v]", ErrPeerConnLimit, key)
+	case m.Limits.PerTorrent > 0 && m.torrents[torrent] >= m.Limits.PerTorrent:
+		return 0, fmt.Errorf("%w: Per torrent", ErrPeerConnLimit)
+	case m.Limits.Global > 0 && len(m.conns) >= m.Limits.Global:
+		return 0, fmt.Errorf("%w: Global", ErrPeerConnLimit)
+	}
+	m.halfOpen++
+	return m.add(&peerConnEntry{Torrent: torrent, IP: key, HalfOpen: true}), nil
+}
+
+// Connected marks the dialed connection as established
+func (m *PeerConnManager) Connected(id PeerConnID, now time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, ok := m.conns[id]
+	if !ok {
+		return ErrUnknownPeerConn
+	}
+	if entry.HalfOpen {
+		entry.HalfOpen, entry.Since = false, now
+		m.halfOpen--
+	}
+	return nil
+}
+
+// Accept admits an incoming connection of torrent. If the torrent or global limit is reached, the established
+// connection with the lowest rate (of the torrent if the torrent limit is reached) is evicted and returned, the
+// caller must close it. The per ip limit is never bypassed.
+func (m *PeerConnManager) Accept(torrent string, ip net.IP, now time.Time) (id PeerConnID, evicted PeerConnID, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	key := ip.String()
+	if m.Limits.PerIP > 0 && m.ips[key] >= m.Limits.PerIP {
+		return 0, 0, fmt.Errorf("%w: Per ip [%v]", ErrPeerConnLimit, key)
+	}
+	torrentFull := m.Limits.PerTorrent > 0 && m.torrents[torrent] >= m.Limits.PerTorrent
+	globalFull := m.Limits.Global > 0 && len(m.conns) >= m.Limits.Global
+	if torrentFull || globalFull {
+		var ok bool
+		if evicted, ok = m.worst(torrent, torrentFull, now); !ok {
+			return 0, 0, fmt.Errorf("%w: No connection to evict", ErrPeerConnLimit)
+		}
+		m.remove(evicted)
+		m.evicted++
+	}
+	id = m.add(&peerConnEntry{Torrent: torrent, IP: key, Since: now})
+	return id, evicted, nil
+}
+
+// worst returns the established connection with the lowest rate which is older than the eviction grace
+func (m *PeerConnManager) worst(torrent string, sameTorrent bool, now time.Time) (PeerConnID, bool) {
+	var (
+		worstID   PeerConnID
+		worstRate float64
+		found     bool
+	)
+	for id, entry := range m.conns {
+		if entry.HalfOpen || (sameTorrent && entry.Torrent != torrent) || now.Sub(entry.Since) < m.Limits.EvictionGrace {
+			continue
+		}
+		rate := entry.Rate.Rate(now)
+		if !found || rate < worstRate || (rate == worstRate && id < worstID) {
+			worstID, worstRate, found = id, rate, true
+		}
+	}
+	return worstID, found
+}
+
+// Record records the bytes transferred (both directions) of the connection, which rates the connection for eviction
+func (m *PeerConnManager) Record(id PeerConnID, n int64, now time.Time) {
+	m.mutex.Lock()
+	entry, ok := m.conns[id]
+	m.mutex.Unlock()
+	if ok {
+		entry.Rate.Add(n, now)
+	}
+}
+
+// Close releases the connection, closing an unknown (e.g. evicted) connection is a no-op
+func (m *PeerConnManager) Close(id PeerConnID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.remove(id)
+}
+
+// SetClient sets the client of the connection identified by the handshake (see IdentifyPeerClient)
+func (m *PeerConnManager) SetClient(id PeerConnID, client PeerClient) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, ok := m.conns[id]
+	if !ok {
+		return ErrUnknownPeerConn
+	}
+	entry.Client = client
+	return nil
+}
+
+// Client returns the client of the connection, the bool is false if the connection is unknown
+func (m *PeerConnManager) Client(id PeerConnID) (PeerClient, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, ok := m.conns[id]
+	if !ok {
+		return PeerClient{}, false
+	}
+	return entry.Client, true
+}
+
+// ClientCounts returns the number of established connections by client name ("Unknown" if not identified)
+func (m *PeerConnManager) ClientCounts() map[string]int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	counts := make(map[string]int)
+	for _, entry := range m.conns {
+		if entry.HalfOpen {
+			continue
+		}
+		name := entry.Client.Name
+		if name == "" {
+			name = "Unknown"
+		}
+		counts[name]++
+	}
+	return counts
+}
+
+// Stats returns the connection counts
+func (m *PeerConnManager) Stats() PeerConnStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return PeerConnStats{Established: len(m.conns) - m.halfOpen, HalfOpen: m.halfOpen, Evicted: m.evicted}
+}
+
+// TorrentConns returns the number of connections (established and half-open) of torrent
+func (m *PeerConnManager) TorrentConns(torrent string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.torrents[torrent]
+}
+
+func (m *PeerConnManager) add(entry *peerConnEntry) PeerConnID {
+	m.nextID++
+	entry.Rate = NewRateEstimator(DefaultRateHalfLife)
+	m.conns[m.nextID] = entry
+	m.torrents[entry.Torrent]++
+	m.ips[entry.IP]++
+	return m.nextID
+}
+
+func (m *PeerConnManager) remove(id PeerConnID) {
+	entry, ok := m.conns[id]
+	if !ok {
+		return
+	}
+	delete(m.conns, id)
+	if entry.HalfOpen {
+		m.halfOpen--
+	}
+	if m.torrents[entry.Torrent]--; m.torrents[entry.Torrent] <= 0 {
+		delete(m.torrents, entry.Torrent)
+	}
+	if m.ips[entry.IP]--; m.ips[entry.IP] <= 0 {
+		delete(m.ips, entry.IP)
+	}
+}
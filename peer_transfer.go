@@ -0,0 +1,150 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:45:08
+//
+// File Name: peer_transfer.go
+// Description:
+//
+//	Per-peer upload and download accounting, which feeds the rates of the choker
+//
+
+package transmission
+
+import (
+	"sync"
+	"time"
+)
+
+// PeerTransfer defines the transferred bytes and the rates of a peer
+type PeerTransfer struct {
+	Uploaded     int64   // To the peer
+	Downloaded   int64   // From the peer
+	UploadRate   float64 // Bytes per second
+	DownloadRate float64 // Bytes per second
+}
+
+// Ratio returns the uploaded bytes per downloaded byte, zero if nothing is downloaded
+func (t PeerTransfer) Ratio() float64 {
+	if t.Downloaded == 0 {
+		return 0
+	}
+	return float64(t.Uploaded) / float64(t.Downloaded)
+}
+
+// PeerTransferLedger accounts the bytes transferred with each peer of a torrent. It's safe for concurrent use.
+type PeerTransferLedger struct {
+	HalfLife time.Duration // Of the rate estimators, DefaultRateHalfLife if zero
+
+	mutex sync.Mutex
+	peers map[PeerConnID]*peerTransferEntry
+	total PeerTransfer // Of the removed peers
+}
+
+type peerTransferEntry struct {
+	uploaded, downloaded         int64
+	uploadRate, downloadRate     *RateEstimator
+	lastUploaded, lastDownloaded time.Time
+}
+
+// NewPeerTransferLedger creates a new PeerTransferLedger
+func NewPeerTransferLedger() *PeerTransferLedger {
+	return &PeerTransferLedger{peers: make(map[PeerConnID]*peerTransferEntry)}
+}
+
+// Uploaded records n bytes (the piece data, not the protocol overhead) uploaded to peer at now
+func (l *PeerTransferLedger) Uploaded(id PeerConnID, n int64, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	entry := l.entry(id)
+	entry.uploaded += n
+	entry.lastUploaded = now
+	entry.uploadRate.Add(n, now)
+}
+
+// Downloaded records n bytes (the piece data, not the protocol overhead) downloaded from peer at now
+func (l *PeerTransferLedger) Downloaded(id PeerConnID, n int64, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	entry := l.entry(id)
+	entry.downloaded += n
+	entry.lastDownloaded = now
+	entry.downloadRate.Add(n, now)
+}
+
+// Peer returns the transfer of peer at now, false if nothing is recorded
+func (l *PeerTransferLedger) Peer(id PeerConnID, now time.Time) (PeerTransfer, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	entry, ok := l.peers[id]
+	if !ok {
+		return PeerTransfer{}, false
+	}
+	return PeerTransfer{
+		Uploaded:     entry.uploaded,
+		Downloaded:   entry.downloaded,
+		UploadRate:   entry.uploadRate.Rate(now),
+		DownloadRate: entry.downloadRate.Rate(now),
+	}, true
+}
+
+// LastUploaded returns when the last byte was uploaded to peer, e.g. to choke the peers which are unchoked but
+// request nothing
+func (l *PeerTransferLedger) LastUploaded(id PeerConnID) time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if entry, ok := l.peers[id]; ok {
+		return entry.lastUploaded
+	}
+	return time.Time{}
+}
+
+// Remove removes the peer when it's disconnected, its bytes are kept in Total
+func (l *PeerTransferLedger) Remove(id PeerConnID) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if entry, ok := l.peers[id]; ok {
+		l.total.Uploaded += entry.uploaded
+		l.total.Downloaded += entry.downloaded
+		delete(l.peers, id)
+	}
+}
+
+// Total returns the bytes transferred with all peers, including the removed ones, and the sum of the rates of the
+// current peers
+func (l *PeerTransferLedger) Total(now time.Time) PeerTransfer {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	total := l.total
+	for _, entry := range l.peers {
+		total.Uploaded += entry.uploaded
+		total.Downloaded += entry.downloaded
+		total.UploadRate += entry.uploadRate.Rate(now)
+		total.DownloadRate += entry.downloadRate.Rate(now)
+	}
+	return total
+}
+
+// FillChokePeers sets the rates of the peers for Choker, the peers without records have zero rates
+func (l *PeerTransferLedger) FillChokePeers(peers []ChokePeer, now time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for i := range peers {
+		if entry, ok := l.peers[peers[i].ID]; ok {
+			peers[i].UploadRate = entry.uploadRate.Rate(now)
+			peers[i].DownloadRate = entry.downloadRate.Rate(now)
+		} else {
+			peers[i].UploadRate, peers[i].DownloadRate = 0, 0
+		}
+	}
+}
+
+func (l *PeerTransferLedger) entry(id PeerConnID) *peerTransferEntry {
+	if l.peers == nil {
+		l.peers = make(map[PeerConnID]*peerTransferEntry)
+	}
+	entry, ok := l.peers[id]
+	if !ok {
+		entry = &peerTransferEntry{uploadRate: NewRateEstimator(l.HalfLife), downloadRate: NewRateEstimator(l.HalfLife)}
+		l.peers[id] = entry
+	}
+	return entry
+}
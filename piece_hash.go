@@ -0,0 +1,333 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:32:55
+//
+// File Name: piece_hash.go
+// Description:
+//
+//	Hash and verify pieces in parallel by a bounded worker pool
+//
+
+package transmission
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Errors
+var (
+	ErrHashPieces = errors.New("Failed to hash pieces")
+)
+
+// Hasher creates the hash of pieces. It can be replaced by other (e.g. assembly accelerated) implementations.
+// Note that crypto/sha1 and crypto/sha256 already use the SHA extensions of cpu when available.
+type Hasher interface {
+	New() hash.Hash
+}
+
+// HasherFunc is an adapter to use functions as Hasher
+type HasherFunc func() hash.Hash
+
+// New implements Hasher
+func (f HasherFunc) New() hash.Hash {
+	return f()
+}
+
+// Hashers
+var (
+	SHA1Hasher   Hasher = HasherFunc(sha1.New)
+	SHA256Hasher Hasher = HasherFunc(sha256.New)
+)
+
+// HashPieces hashes the pieces of the content in parallel, returns the hashes in the order of pieces.
+// SHA1Hasher is used by default.
+func HashPieces(ctx context.Context, r io.ReaderAt, length, pieceLength int64, opts ...PieceHashOption) ([][]byte, error) {
+	if length < 0 {
+		return nil, fmt.Errorf("%w: Invalid length", ErrHashPieces)
+	}
+	if err := checkHashPieceLength(pieceLength); err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, (length+pieceLength-1)/pieceLength)
+	var (
+		mutex    sync.Mutex
+		firstErr error
+	)
+	err := hashPieces(ctx, r, length, pieceLength, newPieceHashOption(opts), func(piece int, sum []byte, err error) {
+		if err != nil {
+			mutex.Lock()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%w: Piece [%v] %v", ErrHashPieces, piece, err)
+			}
+			mutex.Unlock()
+			return
+		}
+		hashes[piece] = sum
+	})
+	if err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return hashes, nil
+}
+
+// VerifyTorrentPieces verifies the v1 pieces of torrent against the content in dir (the parent of the torrent name),
+// returns whether each piece is valid. Missing or short files make the pieces invalid rather than failing.
+// The hasher, if specified, must be a SHA-1 implementation.
+func VerifyTorrentPieces(ctx context.Context, t *TorrentFile, dir string, opts ...PieceHashOption) ([]bool, error) {
+	if len(t.Info.Pieces) == 0 {
+		return nil, fmt.Errorf("%w: No v1 piece hashes", ErrHashPieces)
+	}
+	option := newPieceHashOption(opts)
+	r, err := newTorrentContentReader(t, dir)
+	if err != nil {
+		return nil, err
+	}
+	if option.Mmap {
+		r.mmaps = make(map[string]*mmapFile)
+		defer r.Close()
+	}
+	valid := make([]bool, t.PieceCount())
+	err = hashPieces(ctx, r, r.Length, t.Info.PieceLength, option, func(piece int, sum []byte, err error) {
+		if err == nil && piece < len(valid) {
+			valid[piece] = bytes.Equal(sum, t.Info.Pieces[piece*sha1.Size:(piece+1)*sha1.Size])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return valid, nil
+}
+
+// checkHashPieceLength rejects the piece lengths which cannot be hashed, every worker allocates a piece so the
+// length of an untrusted torrent must be bounded
+func checkHashPieceLength(pieceLength int64) error {
+	if pieceLength <= 0 || pieceLength > MaxPieceLength {
+		return fmt.Errorf("%w: Invalid piece length [%v]", ErrHashPieces, pieceLength)
+	}
+	return nil
+}
+
+// hashPieces hashes the pieces by workers, done is called concurrently
+func hashPieces(ctx context.Context, r io.ReaderAt, length, pieceLength int64, option pieceHashOption, done func(piece int, sum []byte, err error)) error {
+	if err := checkHashPieceLength(pieceLength); err != nil {
+		return err
+	}
+	pieces := make(chan int)
+	go func() {
+		defer close(pieces)
+		for i := 0; int64(i)*pieceLength < length; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case pieces <- i:
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < option.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, pieceLength)
+			h := option.Hasher.New()
+			for piece := range pieces {
+				offset := int64(piece) * pieceLength
+				n := min(pieceLength, length-offset)
+				// io.EOF is fine only if the whole piece is read, the buf of the previous piece must not be hashed
+				if m, err := r.ReadAt(buf[:n], offset); int64(m) < n {
+					if err == nil || err == io.EOF {
+						err = io.ErrUnexpectedEOF
+					}
+					done(piece, nil, err)
+					continue
+				}
+				h.Reset()
+				h.Write(buf[:n])
+				done(piece, h.Sum(nil), nil)
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// torrentContentReader reads the concatenated files of torrent
+type torrentContentReader struct {
+	Files  []torrentContentFile
+	Length int64
+
+	mutex sync.Mutex
+	mmaps map[string]*mmapFile // Nil if mmap is not enabled, the value is nil if the file cannot be mapped
+}
+
+type torrentContentFile struct {
+	Path    string
+	Offset  int64
+	Length  int64
+	Padding bool // Padding files are not on disk and read as zeros
+}
+
+func newTorrentContentReader(t *TorrentFile, dir string) (*torrentContentReader, error) {
+	var r torrentContentReader
+	for _, file := range torrentFilePaths(t) {
+		if !filepath.IsLocal(filepath.FromSlash(file.Path)) {
+			return nil, fmt.Errorf("%w: [%v]", ErrUnsafeTorrentName, file.Path)
+		}
+		r.Files = append(r.Files, torrentContentFile{filepath.Join(dir, filepath.FromSlash(file.Path)), r.Length, file.Length, file.Padding})
+		r.Length += file.Length
+	}
+	return &r, nil
+}
+
+// ReadAt implements io.ReaderAt
+func (r *torrentContentReader) ReadAt(p []byte, off int64) (int, error) {
+	var n int
+	for _, file := range r.Files {
+		if n == len(p) {
+			break
+		}
+		if off+int64(n) >= file.Offset+file.Length || file.Length == 0 {
+			continue
+		}
+		pos := off + int64(n) - file.Offset
+		size := min(int64(len(p)-n), file.Length-pos)
+		if file.Padding {
+			clear(p[n : n+int(size)])
+			n += int(size)
+			continue
+		}
+		read, err := r.readFileAt(file.Path, p[n:n+int(size)], pos)
+		n += read
+		if err != nil {
+			return n, err
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close unmaps the mapped files
+func (r *torrentContentReader) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var errs []error
+	for path, m := range r.mmaps {
+		if m != nil {
+			errs = append(errs, m.Close())
+		}
+		delete(r.mmaps, path)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *torrentContentReader) readFileAt(path string, p []byte, off int64) (int, error) {
+	if r.mmaps != nil {
+		r.mutex.Lock()
+		m, ok := r.mmaps[path]
+		if !ok {
+			// Fallback to read if the file cannot be mapped, e.g. mmap is not supported
+			m, _ = openMmapFile(path)
+			r.mmaps[path] = m
+		}
+		r.mutex.Unlock()
+		if m != nil {
+			return m.ReadAt(p, off)
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n, err := f.ReadAt(p, off)
+	if err == io.EOF && n < len(p) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+//
+//
+//
+// Options
+//
+//
+//
+
+// PieceHashOption defines the piece hash option
+type PieceHashOption interface {
+	set(option *pieceHashOption)
+}
+type pieceHashOption struct {
+	Workers int
+	Hasher  Hasher
+	Mmap    bool
+}
+type pieceHashOptionSetterFunc func(option *pieceHashOption)
+type pieceHashOptionSetter struct {
+	f pieceHashOptionSetterFunc
+}
+
+func (setter pieceHashOptionSetter) set(option *pieceHashOption) {
+	setter.f(option)
+}
+
+func newPieceHashOption(opts []PieceHashOption) pieceHashOption {
+	var option pieceHashOption
+	for _, opt := range opts {
+		if opt != nil {
+			opt.set(&option)
+		}
+	}
+	if option.Workers <= 0 {
+		option.Workers = runtime.GOMAXPROCS(0)
+	}
+	if option.Hasher == nil {
+		option.Hasher = SHA1Hasher
+	}
+	return option
+}
+
+// WithPieceHashWorkersOption defines the number of hashing goroutines, GOMAXPROCS by default
+func WithPieceHashWorkersOption(workers int) PieceHashOption {
+	return pieceHashOptionSetter{
+		func(option *pieceHashOption) {
+			option.Workers = workers
+		},
+	}
+}
+
+// WithPieceHashHasherOption defines the hasher, SHA1Hasher by default
+func WithPieceHashHasherOption(hasher Hasher) PieceHashOption {
+	return pieceHashOptionSetter{
+		func(option *pieceHashOption) {
+			option.Hasher = hasher
+		},
+	}
+}
+
+// WithPieceHashMmapOption reads files by memory mapping when verifying, which reduces syscalls on full verifies of large
+// files. It falls back to normal reads on platforms without mmap
+func WithPieceHashMmapOption(mmap bool) PieceHashOption {
+	return pieceHashOptionSetter{
+		func(option *pieceHashOption) {
+			option.Mmap = mmap
+		},
+	}
+}
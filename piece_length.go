@@ -0,0 +1,132 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:37:12
+//
+// File Name: piece_length.go
+// Description:
+//
+//	Choose and validate the piece length of torrents.
+//
+//	Smaller pieces make the torrent file (the pieces field) larger, bigger pieces waste more on a hash failure and
+//	make partial pieces at the end of files more costly. The policies keep the piece count in a sane range.
+//
+//	Reference:
+//
+//		https://github.com/transmission/transmission/blob/3.00/libtransmission/makemeta.c
+//		https://github.com/arvidn/libtorrent/blob/RC_2_0/src/create_torrent.cpp
+//		https://github.com/pobrn/mktorrent/blob/v1.1/init.c
+//		https://www.bittorrent.org/beps/bep_0052.html
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// Errors
+var (
+	ErrInvalidPieceLength = errors.New("Invalid piece length")
+)
+
+// Piece length bounds
+const (
+	MinPieceLength = 16 * 1024        // The block size, also the minimum of v2 torrents (BEP 52)
+	MaxPieceLength = 64 * 1024 * 1024 // Larger pieces are not supported by most clients
+)
+
+// PieceLengthPolicy chooses the piece length by the total length of contents
+type PieceLengthPolicy interface {
+	PieceLength(totalLength int64) int64
+}
+
+// TargetPieceCountPolicy chooses the smallest power of two piece length which makes the piece count not more than
+// TargetPieces, bounded by [MinLength, MaxLength]
+type TargetPieceCountPolicy struct {
+	TargetPieces int
+	MinLength    int64 // MinPieceLength is used if zero
+	MaxLength    int64 // MaxPieceLength is used if zero
+}
+
+// PieceLength implements PieceLengthPolicy
+func (p TargetPieceCountPolicy) PieceLength(totalLength int64) int64 {
+	minLength, maxLength := p.MinLength, p.MaxLength
+	if minLength <= 0 {
+		minLength = MinPieceLength
+	}
+	if maxLength <= 0 {
+		maxLength = MaxPieceLength
+	}
+	length := minLength
+	for p.TargetPieces > 0 && length < maxLength && (totalLength+length-1)/length > int64(p.TargetPieces) {
+		length *= 2
+	}
+	return min(length, maxLength)
+}
+
+// PieceLengthThreshold defines a threshold of PieceLengthTable
+type PieceLengthThreshold struct {
+	MinTotalLength int64
+	PieceLength    int64
+}
+
+// PieceLengthTable chooses the piece length of the first threshold whose MinTotalLength is not more than the total
+// length. The thresholds must be sorted by MinTotalLength in descending order
+type PieceLengthTable []PieceLengthThreshold
+
+// PieceLength implements PieceLengthPolicy
+func (t PieceLengthTable) PieceLength(totalLength int64) int64 {
+	for _, threshold := range t {
+		if totalLength >= threshold.MinTotalLength {
+			return threshold.PieceLength
+		}
+	}
+	if len(t) > 0 {
+		return t[len(t)-1].PieceLength
+	}
+	return MinPieceLength
+}
+
+// Piece length policy presets
+var (
+	// TransmissionPieceLengthPolicy is the table of transmission 3.x (bestPieceSize of makemeta.c)
+	TransmissionPieceLengthPolicy = PieceLengthTable{
+		{2 << 30, 2 << 20},
+		{1 << 30, 1 << 20},
+		{512 << 20, 512 << 10},
+		{350 << 20, 256 << 10},
+		{150 << 20, 128 << 10},
+		{50 << 20, 64 << 10},
+		{0, 32 << 10},
+	}
+	// LibtorrentPieceLengthPolicy is the automatic piece length of libtorrent (create_torrent), which targets a torrent
+	// file of about 40KB, i.e. 2048 pieces. libtorrent allows up to 128MB, which is bounded to MaxPieceLength here.
+	LibtorrentPieceLengthPolicy = TargetPieceCountPolicy{TargetPieces: 2048}
+	// QBittorrentPieceLengthPolicy is the "Auto" piece length of qBittorrent, which leaves it to libtorrent
+	QBittorrentPieceLengthPolicy = LibtorrentPieceLengthPolicy
+	// MktorrentPieceLengthPolicy is the table of mktorrent 1.1 when -l is not given (init.c), the upper bounds of
+	// its size ranges are inclusive
+	MktorrentPieceLengthPolicy = PieceLengthTable{
+		{2<<30 + 1, 2 << 20},
+		{1<<30 + 1, 1 << 20},
+		{512<<20 + 1, 512 << 10},
+		{350<<20 + 1, 256 << 10},
+		{150<<20 + 1, 128 << 10},
+		{50<<20 + 1, 64 << 10},
+		{0, 32 << 10},
+	}
+	// DefaultPieceLengthPolicy targets about 1500 pieces, which keeps the torrent file around 30KB
+	DefaultPieceLengthPolicy = TargetPieceCountPolicy{TargetPieces: 1500}
+)
+
+// ValidatePieceLength checks if the piece length is a power of two in [MinPieceLength, MaxPieceLength]
+func ValidatePieceLength(length int64) error {
+	if length < MinPieceLength || length > MaxPieceLength {
+		return fmt.Errorf("%w: [%v] Out of range [%v, %v]", ErrInvalidPieceLength, length, MinPieceLength, MaxPieceLength)
+	}
+	if bits.OnesCount64(uint64(length)) != 1 {
+		return fmt.Errorf("%w: [%v] Not a power of two", ErrInvalidPieceLength, length)
+	}
+	return nil
+}
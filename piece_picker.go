@@ -0,0 +1,198 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:33:32
+//
+// File Name: piece_picker.go
+// Description:
+//
+//	Piece picking algorithms which decide the pieces to request from a peer. Rarest-first, sequential and the
+//	priority window (for streaming) are provided, others can be plugged in by implementing PiecePicker.
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0003.html
+//		https://wiki.theory.org/BitTorrentSpecification#Piece_downloading_strategy
+//
+
+package transmission
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Piece picking defaults
+const (
+	DefaultPriorityWindowPieces = 8
+)
+
+// PickPiece defines the state of a piece to decide picking
+type PickPiece struct {
+	Index        int
+	Availability int // The connected peers having the piece, see PieceAvailability
+	Priority     int // Higher is picked first, e.g. by the priorities of the files the piece belongs to
+}
+
+// PiecePicker decides the pieces to request from a peer. The candidates are the wanted pieces the peer has, which
+// are neither downloaded nor being requested, see PickCandidates. It returns at most n indexes in request order.
+type PiecePicker interface {
+	Pick(candidates []PickPiece, n int) []int
+}
+
+// PiecePickerFunc implements PiecePicker by function
+type PiecePickerFunc func(candidates []PickPiece, n int) []int
+
+// Pick implements PiecePicker
+func (f PiecePickerFunc) Pick(candidates []PickPiece, n int) []int {
+	return f(candidates, n)
+}
+
+// PickCandidates returns the pieces peer has but have doesn't (the bitfields, see PieceAvailability) with their
+// availability. The caller drops the unwanted and requested pieces and sets the priorities.
+func PickCandidates(pieceCount int, have, peer []byte, availability []int) []PickPiece {
+	var candidates []PickPiece
+	for i := 0; i < pieceCount; i++ {
+		if bitfieldHas(have, i) || !bitfieldHas(peer, i) {
+			continue
+		}
+		candidate := PickPiece{Index: i}
+		if i < len(availability) {
+			candidate.Availability = availability[i]
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+func bitfieldHas(bitfield []byte, i int) bool {
+	return i/8 < len(bitfield) && bitfield[i/8]&(0x80>>uint(i%8)) != 0
+}
+
+// RarestFirstPicker implements the standard picking algorithm: the pieces with the least availability are picked
+// first so they spread over the swarm, ties are broken randomly so the peers don't download the same pieces. Higher
+// priorities come first. It's safe for concurrent use.
+type RarestFirstPicker struct {
+	Rand  *rand.Rand // The global source is used if nil
+	mutex sync.Mutex // Guards Rand, which is not safe for concurrent use
+}
+
+// NewRarestFirstPicker creates a new RarestFirstPicker
+func NewRarestFirstPicker() *RarestFirstPicker {
+	return &RarestFirstPicker{}
+}
+
+// Pick implements PiecePicker
+func (p *RarestFirstPicker) Pick(candidates []PickPiece, n int) []int {
+	if n <= 0 || len(candidates) == 0 {
+		return nil
+	}
+	sorted := make([]PickPiece, len(candidates))
+	copy(sorted, candidates)
+	p.mutex.Lock()
+	if p.Rand != nil {
+		p.Rand.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+	} else {
+		rand.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+	}
+	p.mutex.Unlock()
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].Availability < sorted[j].Availability
+	})
+	return pickedIndexes(sorted, n)
+}
+
+// SequentialPicker picks the pieces in index order, higher priorities come first. It suits the previews and the
+// streaming of small files, but hurts the swarm as every peer wants the same pieces.
+type SequentialPicker struct{}
+
+// Pick implements PiecePicker
+func (SequentialPicker) Pick(candidates []PickPiece, n int) []int {
+	if n <= 0 || len(candidates) == 0 {
+		return nil
+	}
+	sorted := make([]PickPiece, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority > sorted[j].Priority
+		}
+		return sorted[i].Index < sorted[j].Index
+	})
+	return pickedIndexes(sorted, n)
+}
+
+// PriorityWindowPicker picks the pieces in the window from the playback position sequentially, the others by
+// Fallback. The window moves with the playback by Seek. It's safe for concurrent use, use one per stream.
+type PriorityWindowPicker struct {
+	Window   int         // In pieces, DefaultPriorityWindowPieces if zero
+	Fallback PiecePicker // A RarestFirstPicker if nil
+	mutex    sync.Mutex
+	position int
+	fallback *RarestFirstPicker
+}
+
+// NewPriorityWindowPicker creates a new PriorityWindowPicker
+func NewPriorityWindowPicker(window int) *PriorityWindowPicker {
+	return &PriorityWindowPicker{Window: window}
+}
+
+// Seek moves the window to the piece of playback position
+func (p *PriorityWindowPicker) Seek(piece int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.position = max(piece, 0)
+}
+
+// Position returns the piece of playback position
+func (p *PriorityWindowPicker) Position() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.position
+}
+
+// Pick implements PiecePicker
+func (p *PriorityWindowPicker) Pick(candidates []PickPiece, n int) []int {
+	if n <= 0 || len(candidates) == 0 {
+		return nil
+	}
+	window := p.Window
+	if window <= 0 {
+		window = DefaultPriorityWindowPieces
+	}
+	p.mutex.Lock()
+	position := p.position
+	fallback := p.Fallback
+	if fallback == nil {
+		if p.fallback == nil {
+			p.fallback = NewRarestFirstPicker()
+		}
+		fallback = p.fallback
+	}
+	p.mutex.Unlock()
+
+	var inWindow, others []PickPiece
+	for _, c := range candidates {
+		if c.Index >= position && c.Index < position+window {
+			inWindow = append(inWindow, c)
+		} else {
+			others = append(others, c)
+		}
+	}
+	sort.Slice(inWindow, func(i, j int) bool { return inWindow[i].Index < inWindow[j].Index })
+	picked := pickedIndexes(inWindow, n)
+	if len(picked) < n && len(others) > 0 {
+		picked = append(picked, fallback.Pick(others, n-len(picked))...)
+	}
+	return picked
+}
+
+func pickedIndexes(sorted []PickPiece, n int) []int {
+	indexes := make([]int, 0, min(n, len(sorted)))
+	for _, piece := range sorted[:min(n, len(sorted))] {
+		indexes = append(indexes, piece.Index)
+	}
+	return indexes
+}
@@ -0,0 +1,160 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:49:21
+//
+// File Name: read_cache.go
+// Description:
+//
+//	LRU read cache of pieces for uploading. The cached pieces are suggested to peers (BEP 6 suggest piece) to
+//	concentrate their requests on the pieces in memory.
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0006.html
+//
+
+package transmission
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultReadCacheSize defines the default size of read cache
+const DefaultReadCacheSize = 64 << 20
+
+// ReadCacheStats defines the stats of read cache
+type ReadCacheStats struct {
+	Hits    int64
+	Misses  int64
+	Pieces  int
+	Bytes   int64
+	Evicted int64
+}
+
+// HitRate returns the rate of gets served from cache
+func (s ReadCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type readCacheKey struct {
+	Torrent string
+	Piece   int
+}
+
+type readCacheEntry struct {
+	Key  readCacheKey
+	Data []byte
+}
+
+// PieceReadCache caches the pieces by (torrent, piece), the least recently used pieces are evicted when the size
+// is exceeded. It's safe for concurrent use.
+type PieceReadCache struct {
+	Size int64 // DefaultReadCacheSize if zero
+
+	mutex   sync.Mutex
+	lru     list.List // Front is the most recently used
+	entries map[readCacheKey]*list.Element
+	bytes   int64
+	stats   ReadCacheStats
+}
+
+// NewPieceReadCache creates a new PieceReadCache
+func NewPieceReadCache(size int64) *PieceReadCache {
+	return &PieceReadCache{Size: size, entries: make(map[readCacheKey]*list.Element)}
+}
+
+// Get returns the cached piece of torrent (e.g. hex of info hash). The data must not be modified.
+func (c *PieceReadCache) Get(torrent string, piece int) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[readCacheKey{torrent, piece}]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(element)
+	return element.Value.(*readCacheEntry).Data, true
+}
+
+// Put caches the piece, the data must not be modified after
+func (c *PieceReadCache) Put(torrent string, piece int, data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[readCacheKey]*list.Element)
+	}
+	key := readCacheKey{torrent, piece}
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*readCacheEntry)
+		c.bytes += int64(len(data) - len(entry.Data))
+		entry.Data = data
+		c.lru.MoveToFront(element)
+	} else {
+		c.entries[key] = c.lru.PushFront(&readCacheEntry{key, data})
+		c.bytes += int64(len(data))
+	}
+	size := c.Size
+	if size <= 0 {
+		size = DefaultReadCacheSize
+	}
+	for c.bytes > size && c.lru.Len() > 1 {
+		c.removeElement(c.lru.Back())
+		c.stats.Evicted++
+	}
+}
+
+// Load returns the cached piece, or reads it by read and caches it
+func (c *PieceReadCache) Load(torrent string, piece int, read func() ([]byte, error)) ([]byte, error) {
+	if data, ok := c.Get(torrent, piece); ok {
+		return data, nil
+	}
+	data, err := read()
+	if err != nil {
+		return nil, err
+	}
+	c.Put(torrent, piece, data)
+	return data, nil
+}
+
+// Suggest returns up to n cached pieces of torrent, the most recently used first, to send as suggest piece messages
+func (c *PieceReadCache) Suggest(torrent string, n int) []int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var pieces []int
+	for element := c.lru.Front(); element != nil && len(pieces) < n; element = element.Next() {
+		if entry := element.Value.(*readCacheEntry); entry.Key.Torrent == torrent {
+			pieces = append(pieces, entry.Key.Piece)
+		}
+	}
+	return pieces
+}
+
+// Remove drops the cached pieces of torrent, e.g. when it's removed or rechecked
+func (c *PieceReadCache) Remove(torrent string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, element := range c.entries {
+		if key.Torrent == torrent {
+			c.removeElement(element)
+		}
+	}
+}
+
+// Stats returns the stats
+func (c *PieceReadCache) Stats() ReadCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Pieces, stats.Bytes = len(c.entries), c.bytes
+	return stats
+}
+
+func (c *PieceReadCache) removeElement(element *list.Element) {
+	entry := c.lru.Remove(element).(*readCacheEntry)
+	delete(c.entries, entry.Key)
+	c.bytes -= int64(len(entry.Data))
+}
@@ -0,0 +1,179 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:01:35
+//
+// File Name: remove_policy.go
+// Description:
+//
+//	Auto-remove policy of seeding torrents by rules of ratio, seed time, idle time, tracker and label, with dry run
+//	and the hardlink safety check before deleting data
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// Errors
+var (
+	ErrRemoveTorrent = errors.New("Failed to remove torrent")
+)
+
+// RemoveCandidate defines a torrent to evaluate by remove policy
+type RemoveCandidate struct {
+	Torrent EventTorrent
+	Stats   SeedStats
+}
+
+// RemoveRule defines a remove rule. All the non-zero conditions must match
+type RemoveRule struct {
+	Name        string         // For reporting
+	MinRatio    float64        // The ratio is at least this
+	MinSeedTime time.Duration  // Seeding (since done) for at least this
+	MinIdle     time.Duration  // No upload (since done) for at least this
+	Tracker     *regexp.Regexp // Matches any tracker
+	Label       *regexp.Regexp // Matches any label
+	DeleteData  bool           // Delete the downloaded data as well
+}
+
+// Match checks if the rule matches the candidate at now. Unfinished torrents never match.
+func (r *RemoveRule) Match(c RemoveCandidate, now time.Time) bool {
+	if c.Stats.DoneTime.IsZero() {
+		return false
+	}
+	if r.MinRatio > 0 && c.Stats.Ratio() < r.MinRatio {
+		return false
+	}
+	if r.MinSeedTime > 0 && now.Sub(c.Stats.DoneTime) < r.MinSeedTime {
+		return false
+	}
+	if r.MinIdle > 0 {
+		last := c.Stats.ActivityTime
+		if last.Before(c.Stats.DoneTime) {
+			last = c.Stats.DoneTime
+		}
+		if now.Sub(last) < r.MinIdle {
+			return false
+		}
+	}
+	if r.Tracker != nil && !matchAnyString(r.Tracker, c.Torrent.Trackers) {
+		return false
+	}
+	if r.Label != nil && !matchAnyString(r.Label, c.Torrent.Labels) {
+		return false
+	}
+	return true
+}
+
+// RemoveDecision defines the decision of a candidate matched by a rule
+type RemoveDecision struct {
+	Candidate  RemoveCandidate
+	Rule       *RemoveRule
+	DeleteData bool     // False if the rule deletes data but some files are hardlinked or the check failed
+	Hardlinked []string // The files linked elsewhere, their data is kept
+	Removed    bool     // False in dry run or if the removal failed
+	Err        error
+}
+
+// RemovePolicy removes the torrents matching its rules. The first matched rule is applied. The removal is done by
+// Remove, e.g. by a local engine or the torrent-remove of transmission rpc.
+type RemovePolicy struct {
+	Rules  []RemoveRule
+	DryRun bool // Only report the decisions
+	// CheckHardlinks keeps the data if any file of the torrent (DownloadDir/Name) is hardlinked elsewhere, e.g.
+	// organized by OrganizeHardlink or imported to a media library. If the check fails (e.g. the link count is not
+	// available on the platform), the torrent is not removed.
+	CheckHardlinks bool
+	Remove         func(ctx context.Context, t EventTorrent, deleteData bool) error
+}
+
+// Evaluate returns the decisions of the candidates at now without removing anything. The hardlink check is done if
+// enabled.
+func (p *RemovePolicy) Evaluate(candidates []RemoveCandidate, now time.Time) []RemoveDecision {
+	var decisions []RemoveDecision
+	for _, c := range candidates {
+		for i := range p.Rules {
+			rule := &p.Rules[i]
+			if !rule.Match(c, now) {
+				continue
+			}
+			decision := RemoveDecision{Candidate: c, Rule: rule, DeleteData: rule.DeleteData}
+			if decision.DeleteData && p.CheckHardlinks {
+				decision.Hardlinked, decision.Err = hardlinkedFiles(c.Torrent)
+				if decision.Err != nil || len(decision.Hardlinked) > 0 {
+					decision.DeleteData = false
+				}
+			}
+			decisions = append(decisions, decision)
+			break
+		}
+	}
+	return decisions
+}
+
+// Apply evaluates the candidates and removes the matched torrents unless in dry run. The decisions are returned
+// with the results, the error is returned if any removal failed.
+func (p *RemovePolicy) Apply(ctx context.Context, candidates []RemoveCandidate, now time.Time) ([]RemoveDecision, error) {
+	decisions := p.Evaluate(candidates, now)
+	if p.DryRun {
+		return decisions, nil
+	}
+	var errs []error
+	for i := range decisions {
+		decision := &decisions[i]
+		if decision.Err != nil && decision.Rule.DeleteData {
+			// The hardlink check failed, the torrent is kept for the next run
+			errs = append(errs, decision.Err)
+			continue
+		}
+		if p.Remove == nil {
+			return decisions, fmt.Errorf("%w: No remove function", ErrRemoveTorrent)
+		}
+		if err := p.Remove(ctx, decision.Candidate.Torrent, decision.DeleteData); err != nil {
+			decision.Err = fmt.Errorf("%w: [%v] [%v]", ErrRemoveTorrent, decision.Candidate.Torrent.Name, err)
+			errs = append(errs, decision.Err)
+			continue
+		}
+		decision.Removed = true
+	}
+	return decisions, errors.Join(errs...)
+}
+
+// hardlinkedFiles returns the regular files of torrent content with more than one link
+func hardlinkedFiles(t EventTorrent) ([]string, error) {
+	source, _, err := organizePaths(t, "")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = filepath.WalkDir(source, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		links, ok := fileLinkCount(info)
+		if !ok {
+			return fmt.Errorf("Link count is not available [%v]", p)
+		}
+		if links > 1 {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
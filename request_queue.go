@@ -0,0 +1,98 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:48:08
+//
+// File Name: request_queue.go
+// Description:
+//
+//	Adaptive per-peer request queue depth by bandwidth-delay product
+//
+
+package transmission
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Request queue defaults
+const (
+	BlockSize              = 16 * 1024 // The size of a block request
+	DefaultMinRequestQueue = 2
+	DefaultMaxRequestQueue = 500
+	DefaultRTTWindow       = 30 * time.Second
+)
+
+// RequestQueueSizer sizes the request queue (outstanding block requests) of a peer. The size is twice the
+// bandwidth-delay product, the measured download rate times the base round trip time, in blocks. Twice is to let
+// the rate grow, since the rate can't exceed what the queue allows. The base round trip time is the minimum sample
+// of the recent windows, which excludes the queueing delay caused by the queue itself (bufferbloat).
+// It's safe for concurrent use.
+type RequestQueueSizer struct {
+	Min       int           // DefaultMinRequestQueue if zero
+	Max       int           // DefaultMaxRequestQueue if zero
+	RTTWindow time.Duration // DefaultRTTWindow if zero
+
+	mutex       sync.Mutex
+	rate        RateEstimator
+	windowStart time.Time
+	minRTT      time.Duration // Of the current window
+	prevMinRTT  time.Duration // Of the previous window
+}
+
+// NewRequestQueueSizer creates a new RequestQueueSizer
+func NewRequestQueueSizer() *RequestQueueSizer {
+	return &RequestQueueSizer{}
+}
+
+// Received records a block of n bytes received at now, rtt is the time since its request was sent
+func (s *RequestQueueSizer) Received(n int64, rtt time.Duration, now time.Time) {
+	s.rate.Add(n, now)
+	if rtt <= 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	window := s.RTTWindow
+	if window <= 0 {
+		window = DefaultRTTWindow
+	}
+	if s.windowStart.IsZero() || now.Sub(s.windowStart) >= window {
+		s.windowStart, s.prevMinRTT, s.minRTT = now, s.minRTT, 0
+	}
+	if s.minRTT == 0 || rtt < s.minRTT {
+		s.minRTT = rtt
+	}
+}
+
+// BaseRTT returns the base round trip time, zero if unknown
+func (s *RequestQueueSizer) BaseRTT() time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch {
+	case s.minRTT == 0:
+		return s.prevMinRTT
+	case s.prevMinRTT == 0:
+		return s.minRTT
+	default:
+		return min(s.minRTT, s.prevMinRTT)
+	}
+}
+
+// Size returns the number of blocks to keep requested from the peer
+func (s *RequestQueueSizer) Size(now time.Time) int {
+	minSize, maxSize := s.Min, s.Max
+	if minSize <= 0 {
+		minSize = DefaultMinRequestQueue
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxRequestQueue
+	}
+	rtt := s.BaseRTT()
+	if rtt <= 0 {
+		return minSize
+	}
+	bdp := s.rate.Rate(now) * rtt.Seconds()
+	size := int(math.Ceil(2 * bdp / BlockSize))
+	return max(minSize, min(size, maxSize))
+}
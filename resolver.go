@@ -0,0 +1,366 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:46:13
+//
+// File Name: resolver.go
+// Description:
+//
+//	Host resolution with caching and happy eyeballs dialing, shared by the components dialing trackers and peers
+//	so the resolution behavior is consistent. The lookup is pluggable, e.g. a net.Resolver with custom dns server
+//	or DNS over HTTPS.
+//
+//	Reference:
+//
+//		https://www.rfc-editor.org/rfc/rfc8305
+//		https://developers.google.com/speed/public-dns/docs/doh/json
+//
+
+package transmission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors
+var (
+	ErrResolveHost = errors.New("Cannot resolve host")
+)
+
+// Resolver defaults
+const (
+	DefaultResolverTTL            = 5 * time.Minute
+	DefaultConnectionAttemptDelay = 250 * time.Millisecond // RFC 8305
+)
+
+// DNS over HTTPS json endpoints
+const (
+	CloudflareDoHJSONEndpoint = "https://cloudflare-dns.com/dns-query"
+	GoogleDoHJSONEndpoint     = "https://dns.google/resolve"
+)
+
+// dns record types
+const (
+	dnsTypeA    = 1
+	dnsTypeAAAA = 28
+)
+
+// maxDoHResponseSize defines the max size of DNS over HTTPS response
+const maxDoHResponseSize = 64 << 10
+
+// HostLookup looks up the ip addresses of host, network is "ip", "ip4" or "ip6". *net.Resolver implements it.
+type HostLookup interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+}
+
+// Resolver resolves hosts with cache and dials addresses with happy eyeballs. It's safe for concurrent use.
+type Resolver struct {
+	Lookup                 HostLookup    // net.DefaultResolver if nil
+	Dialer                 *net.Dialer   // A zero net.Dialer if nil
+	TTL                    time.Duration // DefaultResolverTTL if zero, resolved hosts are not cached if negative
+	ConnectionAttemptDelay time.Duration // DefaultConnectionAttemptDelay if zero
+	Cache                  Cache         // An in-memory cache is used if nil
+
+	cacheOnce sync.Once
+}
+
+// NewResolver creates a new Resolver by lookup
+func NewResolver(lookup HostLookup) *Resolver {
+	return &Resolver{Lookup: lookup}
+}
+
+// LookupIP returns the ip addresses of host, the idn host is converted to ascii (see ToASCIIHost) first.
+// The network is "ip", "ip4" or "ip6".
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return filterIPs([]net.IP{ip}, network), nil
+	}
+	host, err := ToASCIIHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResolveHost, err)
+	}
+
+	r.cacheOnce.Do(func() {
+		if r.Cache == nil {
+			r.Cache = NewMemoryCache()
+		}
+	})
+	key := "resolve:" + network + ":" + host
+	if data, ok := r.Cache.Get(key); ok {
+		var ips []net.IP
+		for _, s := range strings.Split(string(data), ",") {
+			if ip := net.ParseIP(s); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+		return ips, nil
+	}
+
+	lookup := r.Lookup
+	if lookup == nil {
+		lookup = net.DefaultResolver
+	}
+	ips, err := lookup.LookupIP(ctx, network, host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrResolveHost, err)
+	}
+	ips = filterIPs(ips, network)
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%w: No address [%v]", ErrResolveHost, host)
+	}
+	if ttl := r.TTL; ttl >= 0 {
+		if ttl == 0 {
+			ttl = DefaultResolverTTL
+		}
+		strs := make([]string, len(ips))
+		for i, ip := range ips {
+			strs[i] = ip.String()
+		}
+		// Failing to cache is not fatal
+		r.Cache.Set(key, []byte(strings.Join(strs, ",")), ttl)
+	}
+	return ips, nil
+}
+
+// DialContext resolves the host of address and dials it, which can be used as http.Transport.DialContext.
+// For tcp the addresses are tried by happy eyeballs (RFC 8305): IPv6 and IPv4 addresses are interleaved and a new
+// attempt is started every ConnectionAttemptDelay or when the previous one fails, the first established
+// connection wins. For other networks (e.g. udp) the first address is dialed.
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ipNetwork := "ip"
+	if strings.HasSuffix(network, "4") {
+		ipNetwork = "ip4"
+	} else if strings.HasSuffix(network, "6") {
+		ipNetwork = "ip6"
+	}
+	ips, err := r.LookupIP(ctx, ipNetwork, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("%w: No address [%v]", ErrResolveHost, host)
+	}
+	dialer := r.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	if !strings.HasPrefix(network, "tcp") || len(ips) == 1 {
+		return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+	}
+	delay := r.ConnectionAttemptDelay
+	if delay <= 0 {
+		delay = DefaultConnectionAttemptDelay
+	}
+	return dialHappyEyeballs(ctx, dialer, network, interleaveIPs(ips), port, delay)
+}
+
+type dialResult struct {
+	Conn net.Conn
+	Err  error
+}
+
+func dialHappyEyeballs(ctx context.Context, dialer *net.Dialer, network string, ips []net.IP, port string, delay time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		results = make(chan dialResult, len(ips))
+		next    int
+		pending int
+		errs    []error
+	)
+	start := func() {
+		address := net.JoinHostPort(ips[next].String(), port)
+		next++
+		pending++
+		go func() {
+			conn, err := dialer.DialContext(ctx, network, address)
+			results <- dialResult{conn, err}
+		}()
+	}
+	start()
+	for pending > 0 {
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if next < len(ips) {
+			timer = time.NewTimer(delay)
+			timeout = timer.C
+		}
+		select {
+		case result := <-results:
+			pending--
+			if result.Err == nil {
+				// Close the connections established by the other attempts
+				go func(n int) {
+					for i := 0; i < n; i++ {
+						if result := <-results; result.Conn != nil {
+							result.Conn.Close()
+						}
+					}
+				}(pending)
+				if timer != nil {
+					timer.Stop()
+				}
+				return result.Conn, nil
+			}
+			errs = append(errs, result.Err)
+			if next < len(ips) {
+				start()
+			}
+		case <-timeout:
+			start()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// interleaveIPs interleaves the addresses by family, starting with the family of the first address
+func interleaveIPs(ips []net.IP) []net.IP {
+	var first, second []net.IP
+	isV4 := ips[0].To4() != nil
+	for _, ip := range ips {
+		if (ip.To4() != nil) == isV4 {
+			first = append(first, ip)
+		} else {
+			second = append(second, ip)
+		}
+	}
+	result := make([]net.IP, 0, len(ips))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			result = append(result, first[i])
+		}
+		if i < len(second) {
+			result = append(result, second[i])
+		}
+	}
+	return result
+}
+
+func filterIPs(ips []net.IP, network string) []net.IP {
+	if network != "ip4" && network != "ip6" {
+		return ips
+	}
+	var result []net.IP
+	for _, ip := range ips {
+		if (ip.To4() != nil) == (network == "ip4") {
+			result = append(result, ip)
+		}
+	}
+	return result
+}
+
+//
+//
+//
+// DNS over HTTPS
+//
+//
+//
+
+// DoHLookup implements HostLookup by the json api of DNS over HTTPS (e.g. CloudflareDoHJSONEndpoint,
+// GoogleDoHJSONEndpoint)
+type DoHLookup struct {
+	Client   *http.Client // http.DefaultClient if nil
+	Endpoint string
+}
+
+// NewDoHLookup creates a new DoHLookup
+func NewDoHLookup(client *http.Client, endpoint string) *DoHLookup {
+	return &DoHLookup{client, endpoint}
+}
+
+type dohJSONResponse struct {
+	Status int `json:"Status"`
+	Answer []struct {
+		Type int    `json:"type"`
+		Data string `json:"data"`
+	} `json:"Answer"`
+}
+
+// LookupIP implements HostLookup
+func (l *DoHLookup) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	var types []int
+	switch network {
+	case "ip4":
+		types = []int{dnsTypeA}
+	case "ip6":
+		types = []int{dnsTypeAAAA}
+	default:
+		types = []int{dnsTypeAAAA, dnsTypeA}
+	}
+	var (
+		ips     []net.IP
+		lastErr error
+	)
+	for _, t := range types {
+		result, err := l.lookup(ctx, host, t)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips = append(ips, result...)
+	}
+	if len(ips) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return ips, nil
+}
+
+func (l *DoHLookup) lookup(ctx context.Context, host string, t int) ([]net.IP, error) {
+	query := url.Values{"name": {host}, "type": {fmt.Sprint(t)}}
+	endpoint := l.Endpoint
+	if strings.Contains(endpoint, "?") {
+		endpoint += "&" + query.Encode()
+	} else {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/dns-json")
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status [%v]", resp.Status)
+	}
+	var result dohJSONResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDoHResponseSize)).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Status != 0 {
+		return nil, fmt.Errorf("Dns error [rcode %v]", result.Status)
+	}
+	var ips []net.IP
+	for _, answer := range result.Answer {
+		// Skip CNAME and others
+		if answer.Type == t {
+			if ip := net.ParseIP(answer.Data); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return ips, nil
+}
@@ -0,0 +1,85 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:27:25
+//
+// File Name: script_hook.go
+// Description:
+//
+//	Execute user scripts on torrent lifecycle events (like transmission's script-torrent-done)
+//
+//	Reference:
+//
+//		https://github.com/transmission/transmission/blob/main/docs/Scripts.md
+//
+
+package transmission
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrScriptHook = errors.New("Script hook failed")
+)
+
+// DefaultScriptHookTimeout defines the default timeout of script execution
+const DefaultScriptHookTimeout = 5 * time.Minute
+
+// ScriptHook executes a script with the environment variables of the event. It implements Notifier so it can be driven by
+// RunNotifier, e.g. RunNotifier(ctx, bus, hook, nil, EventDownloadComplete) mirrors script-torrent-done.
+type ScriptHook struct {
+	Path    string
+	Args    []string
+	Env     []string      // Extra environment variables, "KEY=VALUE"
+	Timeout time.Duration // DefaultScriptHookTimeout is used if zero
+}
+
+// Notify implements Notifier, runs the script and waits for it to exit
+func (h *ScriptHook) Notify(ctx context.Context, e Event) error {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultScriptHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Path, h.Args...)
+	cmd.Env = append(append(os.Environ(), ScriptHookEnv(e)...), h.Env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 1024 {
+			msg = msg[len(msg)-1024:]
+		}
+		return fmt.Errorf("%w: [%v] %v %v", ErrScriptHook, h.Path, err, msg)
+	}
+	return nil
+}
+
+// ScriptHookEnv returns the environment variables of event, the same as the ones transmission sets, plus TR_EVENT
+func ScriptHookEnv(e Event) []string {
+	t := e.EventTorrent()
+	eventTime := e.EventTime()
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	return []string{
+		"TR_EVENT=" + e.EventType(),
+		"TR_TIME_LOCALTIME=" + eventTime.Local().Format(time.ANSIC),
+		"TR_TORRENT_DIR=" + t.DownloadDir,
+		"TR_TORRENT_HASH=" + hex.EncodeToString(t.InfoHash.Value),
+		"TR_TORRENT_ID=" + t.ID,
+		"TR_TORRENT_LABELS=" + strings.Join(t.Labels, ","),
+		"TR_TORRENT_NAME=" + t.Name,
+		"TR_TORRENT_TRACKERS=" + strings.Join(t.Trackers, ","),
+	}
+}
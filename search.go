@@ -0,0 +1,283 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:56:37
+//
+// File Name: search.go
+// Description:
+//
+//	In-memory full-text search over the indexed torrents (name, file names and keywords) with prefix and fuzzy
+//	matching of query tokens
+//
+
+package transmission
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DefaultSearchLimit defines the default max number of search results
+const DefaultSearchLimit = 50
+
+// Weights of the fields and the match kinds of search
+const (
+	searchNameWeight    = 3
+	searchKeywordWeight = 2
+	searchFileWeight    = 1
+
+	searchExactMatch  = 1
+	searchPrefixMatch = 0.7
+	searchFuzzyMatch  = 0.5
+)
+
+// SearchOptions defines the options of search
+type SearchOptions struct {
+	Limit int  // DefaultSearchLimit if zero
+	Exact bool // Only match the whole tokens, no prefix or fuzzy matching
+}
+
+// SearchResult defines a search result
+type SearchResult struct {
+	Entry      IndexEntry
+	Score      float64
+	MagnetLink string // The magnet link of entry, or built by the info hash, name, size and keywords if not set
+}
+
+// SearchIndex is an inverted index of the tokens of torrent names, file names and keywords. All query tokens must
+// match a token of the torrent, exactly, by prefix, or by edit distance (1 for tokens of 4+ runes, 2 for 8+). It's
+// safe for concurrent use.
+type SearchIndex struct {
+	mutex    sync.Mutex
+	entries  map[string]IndexEntry         // Hex of info hash -> entry
+	postings map[string]map[string]float64 // Token -> hex of info hash -> weight
+	tokens   []string                      // Sorted tokens for prefix matching, nil if outdated
+}
+
+// NewSearchIndex creates a new SearchIndex
+func NewSearchIndex() *SearchIndex {
+	return &SearchIndex{entries: make(map[string]IndexEntry), postings: make(map[string]map[string]float64)}
+}
+
+// Load adds all entries of store, e.g. the store of Index. The entries added to the store later must be added to the
+// search index as well.
+func (s *SearchIndex) Load(ctx context.Context, store IndexStore) error {
+	return store.Range(ctx, func(entry IndexEntry) error {
+		s.Add(entry)
+		return nil
+	})
+}
+
+// Add adds or replaces the entry
+func (s *SearchIndex) Add(entry IndexEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]IndexEntry)
+		s.postings = make(map[string]map[string]float64)
+	}
+	key := entry.InfoHash.InfoHashString(InfoHashEncodingHex)
+	s.remove(key)
+	s.entries[key] = cloneIndexEntry(entry)
+
+	weights := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, token := range SearchTokens(text) {
+			weights[token] = max(weights[token], weight)
+		}
+	}
+	add(entry.Name, searchNameWeight)
+	for _, keyword := range entry.Keywords {
+		add(keyword, searchKeywordWeight)
+	}
+	for _, file := range entry.Files {
+		add(file, searchFileWeight)
+	}
+	for token, weight := range weights {
+		if s.postings[token] == nil {
+			s.postings[token] = make(map[string]float64)
+			s.tokens = nil
+		}
+		s.postings[token][key] = weight
+	}
+}
+
+// Remove removes the entry of info hash
+func (s *SearchIndex) Remove(infoHash HashValue) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.remove(infoHash.InfoHashString(InfoHashEncodingHex))
+}
+
+// Len returns the number of entries
+func (s *SearchIndex) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.entries)
+}
+
+// Search returns the entries matching query ordered by score (descending)
+func (s *SearchIndex) Search(query string, opts SearchOptions) []SearchResult {
+	queryTokens := SearchTokens(query)
+	if len(queryTokens) == 0 {
+		return nil
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.tokens == nil {
+		s.tokens = make([]string, 0, len(s.postings))
+		for token := range s.postings {
+			s.tokens = append(s.tokens, token)
+		}
+		sort.Strings(s.tokens)
+	}
+	var scores map[string]float64
+	for _, queryToken := range queryTokens {
+		tokenScores := make(map[string]float64)
+		for token, match := range s.matchTokens(queryToken, opts.Exact) {
+			for key, weight := range s.postings[token] {
+				tokenScores[key] = max(tokenScores[key], match*weight)
+			}
+		}
+		if scores == nil {
+			scores = tokenScores
+			continue
+		}
+		// All query tokens must match
+		for key, score := range scores {
+			if tokenScore, ok := tokenScores[key]; ok {
+				scores[key] = score + tokenScore
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for key, score := range scores {
+		entry := cloneIndexEntry(s.entries[key])
+		results = append(results, SearchResult{Entry: entry, Score: score, MagnetLink: indexEntryMagnetLink(entry)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Entry.Name < results[j].Entry.Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// SearchTokens splits text into lowercase tokens of letters and digits
+func SearchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (s *SearchIndex) remove(key string) {
+	if _, ok := s.entries[key]; !ok {
+		return
+	}
+	delete(s.entries, key)
+	for token, keys := range s.postings {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(s.postings, token)
+			s.tokens = nil
+		}
+	}
+}
+
+// matchTokens returns the indexed tokens matching the query token with the match weight
+func (s *SearchIndex) matchTokens(queryToken string, exact bool) map[string]float64 {
+	matches := make(map[string]float64)
+	if _, ok := s.postings[queryToken]; ok {
+		matches[queryToken] = searchExactMatch
+	}
+	if exact {
+		return matches
+	}
+	for i := sort.SearchStrings(s.tokens, queryToken); i < len(s.tokens) && strings.HasPrefix(s.tokens[i], queryToken); i++ {
+		if s.tokens[i] != queryToken {
+			matches[s.tokens[i]] = searchPrefixMatch
+		}
+	}
+	maxDistance := 0
+	if n := len([]rune(queryToken)); n >= 8 {
+		maxDistance = 2
+	} else if n >= 4 {
+		maxDistance = 1
+	}
+	if maxDistance == 0 {
+		return matches
+	}
+	for _, token := range s.tokens {
+		if _, ok := matches[token]; !ok && editDistanceWithin(queryToken, token, maxDistance) {
+			matches[token] = searchFuzzyMatch
+		}
+	}
+	return matches
+}
+
+// editDistanceWithin checks if the levenshtein distance of a and b is not greater than maxDistance
+func editDistanceWithin(a, b string, maxDistance int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra)-len(rb) > maxDistance || len(rb)-len(ra) > maxDistance {
+		return false
+	}
+	prev, cur := make([]int, len(rb)+1), make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > maxDistance {
+			return false
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)] <= maxDistance
+}
+
+// indexEntryMagnetLink returns the magnet link of entry
+func indexEntryMagnetLink(entry IndexEntry) string {
+	if entry.MagnetLink != "" {
+		return entry.MagnetLink
+	}
+	var l MagnetLink
+	switch entry.InfoHash.Type {
+	case HashSHA1:
+		l.Xt = []Urn{{"btih", hex.EncodeToString(entry.InfoHash.Value)}}
+	case HashSHA256:
+		l.Xt = []Urn{{"btmh", "1220" + hex.EncodeToString(entry.InfoHash.Value)}}
+	}
+	if entry.Name != "" {
+		l.Dn = []string{entry.Name}
+	}
+	if entry.Size > 0 {
+		l.Xl = []int{int(entry.Size)}
+	}
+	if len(entry.Keywords) > 0 {
+		l.Kt = []string{strings.Join(entry.Keywords, "+")}
+	}
+	return l.String()
+}
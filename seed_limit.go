@@ -0,0 +1,124 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:28:20
+//
+// File Name: seed_limit.go
+// Description:
+//
+//	Seed ratio, idle seeding and seed time limits (stop conditions of seeding torrents)
+//
+//	Reference:
+//
+//		https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md
+//
+
+package transmission
+
+import (
+	"time"
+)
+
+// Seed limit mode of torrents, the same values as seedRatioMode / seedIdleMode of transmission rpc
+const (
+	SeedLimitGlobal    = 0 // Follow the global limit
+	SeedLimitSingle    = 1 // Use the limit of the torrent
+	SeedLimitUnlimited = 2 // No limit
+)
+
+// Seed limit reason
+const (
+	SeedLimitReasonRatio    = "ratio"
+	SeedLimitReasonIdle     = "idle"
+	SeedLimitReasonSeedTime = "seed-time"
+)
+
+// SeedLimits defines the global seed limits
+type SeedLimits struct {
+	RatioLimit           float64
+	RatioLimitEnabled    bool
+	IdleLimit            time.Duration
+	IdleLimitEnabled     bool
+	SeedTimeLimit        time.Duration // Not a transmission setting
+	SeedTimeLimitEnabled bool
+}
+
+// SeedLimitsFromSettings creates the global seed limits from transmission settings (ratio-limit, idle-seeding-limit)
+func SeedLimitsFromSettings(s *TransmissionSettings) SeedLimits {
+	var limits SeedLimits
+	if s.RatioLimit != nil {
+		limits.RatioLimit = *s.RatioLimit
+	}
+	if s.RatioLimitEnabled != nil {
+		limits.RatioLimitEnabled = *s.RatioLimitEnabled
+	}
+	if s.IdleSeedingLimit != nil {
+		limits.IdleLimit = time.Duration(*s.IdleSeedingLimit) * time.Minute
+	}
+	if s.IdleSeedingLimitEnabled != nil {
+		limits.IdleLimitEnabled = *s.IdleSeedingLimitEnabled
+	}
+	return limits
+}
+
+// TorrentSeedLimits defines the seed limits of a torrent, the modes are SeedLimitXXX
+type TorrentSeedLimits struct {
+	RatioMode     int
+	RatioLimit    float64
+	IdleMode      int
+	IdleLimit     time.Duration
+	SeedTimeMode  int
+	SeedTimeLimit time.Duration
+}
+
+// SeedStats defines the transfer stats of a torrent to check seed limits
+type SeedStats struct {
+	Uploaded     int64
+	Downloaded   int64
+	SizeWhenDone int64     // The ratio is Uploaded / max(Downloaded, SizeWhenDone), the same as transmission
+	DoneTime     time.Time // When the torrent starts seeding
+	ActivityTime time.Time // The last time of uploading
+}
+
+// Ratio returns the upload ratio, -1 if nothing is downloaded
+func (s SeedStats) Ratio() float64 {
+	base := max(s.Downloaded, s.SizeWhenDone)
+	if base <= 0 {
+		return -1
+	}
+	return float64(s.Uploaded) / float64(base)
+}
+
+// Check checks the seed limits of a torrent, returns the reason (SeedLimitReasonXXX) if any limit is reached
+func (g SeedLimits) Check(t TorrentSeedLimits, stats SeedStats, now time.Time) (string, bool) {
+	if limit, ok := seedLimit(t.RatioMode, t.RatioLimit, g.RatioLimitEnabled, g.RatioLimit); ok {
+		if ratio := stats.Ratio(); ratio >= 0 && ratio >= limit {
+			return SeedLimitReasonRatio, true
+		}
+	}
+	if limit, ok := seedLimit(t.IdleMode, t.IdleLimit, g.IdleLimitEnabled, g.IdleLimit); ok {
+		last := stats.ActivityTime
+		if last.Before(stats.DoneTime) {
+			last = stats.DoneTime
+		}
+		if !last.IsZero() && now.Sub(last) >= limit {
+			return SeedLimitReasonIdle, true
+		}
+	}
+	if limit, ok := seedLimit(t.SeedTimeMode, t.SeedTimeLimit, g.SeedTimeLimitEnabled, g.SeedTimeLimit); ok {
+		if !stats.DoneTime.IsZero() && now.Sub(stats.DoneTime) >= limit {
+			return SeedLimitReasonSeedTime, true
+		}
+	}
+	return "", false
+}
+
+// seedLimit returns the effective limit by the mode of torrent
+func seedLimit[T float64 | time.Duration](mode int, limit T, globalEnabled bool, globalLimit T) (T, bool) {
+	switch mode {
+	case SeedLimitSingle:
+		return limit, true
+	case SeedLimitUnlimited:
+		return 0, false
+	default:
+		return globalLimit, globalEnabled
+	}
+}
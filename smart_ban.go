@@ -0,0 +1,158 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:48:31
+//
+// File Name: smart_ban.go
+// Description:
+//
+//	Smart ban: identify and ban the peers sending corrupt data.
+//
+//	When a piece fails the hash check, the hash of each block and the peer sending it are recorded. When the
+//	piece passes later, the recorded blocks which differ from the good data identify the offending peers.
+//
+
+package transmission
+
+import (
+	"crypto/sha1"
+	"sort"
+	"sync"
+)
+
+// SmartBanBlock defines a block of piece and the peer sent it (e.g. the ip)
+type SmartBanBlock struct {
+	Peer string
+	Data []byte
+}
+
+// SmartBanStats defines the stats of smart ban
+type SmartBanStats struct {
+	HashFailures   int64 // Pieces failed the hash check
+	Identified     int64 // Failed pieces passed later, whose offending peers are identified
+	BannedPeers    int   // Currently banned peers
+	PendingPieces  int   // Failed pieces not passed yet
+	CorruptBlocks  int64 // Corrupt blocks found
+	SoleSourceBans int64 // Peers banned since they sent the whole failed piece
+}
+
+type smartBanPiece struct {
+	Torrent string
+	Piece   int
+}
+
+type smartBanRecord struct {
+	Peer string
+	Hash [sha1.Size]byte
+}
+
+// SmartBan tracks the blocks of failed pieces and bans the peers sending corrupt data. It's safe for concurrent use.
+type SmartBan struct {
+	mutex  sync.Mutex
+	failed map[smartBanPiece]map[int][]smartBanRecord // Block index -> records of the failed attempts
+	banned map[string]bool
+	stats  SmartBanStats
+}
+
+// NewSmartBan creates a new SmartBan
+func NewSmartBan() *SmartBan {
+	return &SmartBan{
+		failed: make(map[smartBanPiece]map[int][]smartBanRecord),
+		banned: make(map[string]bool),
+	}
+}
+
+// PieceFailed records the blocks of the piece of torrent (e.g. hex of info hash) which failed the hash check.
+// If all blocks are sent by the same peer, it's banned at once and returned.
+func (b *SmartBan) PieceFailed(torrent string, piece int, blocks []SmartBanBlock) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.stats.HashFailures++
+	key := smartBanPiece{torrent, piece}
+	records := b.failed[key]
+	if records == nil {
+		records = make(map[int][]smartBanRecord)
+		b.failed[key] = records
+	}
+	sole := ""
+	for i, block := range blocks {
+		records[i] = append(records[i], smartBanRecord{block.Peer, sha1.Sum(block.Data)})
+		if i == 0 {
+			sole = block.Peer
+		} else if block.Peer != sole {
+			sole = ""
+		}
+	}
+	if sole != "" && !b.banned[sole] {
+		b.banned[sole] = true
+		b.stats.SoleSourceBans++
+		return []string{sole}
+	}
+	return nil
+}
+
+// PiecePassed compares the good blocks of the piece with the blocks recorded by PieceFailed, the peers sent
+// different data are banned and returned (sorted)
+func (b *SmartBan) PiecePassed(torrent string, piece int, blocks []SmartBanBlock) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	key := smartBanPiece{torrent, piece}
+	records, ok := b.failed[key]
+	if !ok {
+		return nil
+	}
+	delete(b.failed, key)
+	b.stats.Identified++
+	offenders := make(map[string]bool)
+	for i, block := range blocks {
+		hash := sha1.Sum(block.Data)
+		for _, record := range records[i] {
+			if record.Hash != hash {
+				b.stats.CorruptBlocks++
+				offenders[record.Peer] = true
+			}
+		}
+	}
+	var banned []string
+	for peer := range offenders {
+		if !b.banned[peer] {
+			b.banned[peer] = true
+			banned = append(banned, peer)
+		}
+	}
+	sort.Strings(banned)
+	return banned
+}
+
+// Forget drops the records of torrent, e.g. when it's removed
+func (b *SmartBan) Forget(torrent string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for key := range b.failed {
+		if key.Torrent == torrent {
+			delete(b.failed, key)
+		}
+	}
+}
+
+// IsBanned checks if the peer is banned
+func (b *SmartBan) IsBanned(peer string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.banned[peer]
+}
+
+// Unban unbans the peer
+func (b *SmartBan) Unban(peer string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.banned, peer)
+}
+
+// Stats returns the stats
+func (b *SmartBan) Stats() SmartBanStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	stats := b.stats
+	stats.BannedPeers = len(b.banned)
+	stats.PendingPieces = len(b.failed)
+	return stats
+}
@@ -0,0 +1,248 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:50:50
+//
+// File Name: state_store.go
+// Description:
+//
+//	Persistent state of torrents (torrent list, progress, transfer stats and labels), so long running sessions
+//	survive crashes
+//
+
+package transmission
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Errors
+var (
+	ErrTorrentRecordNotFound = errors.New("Torrent record not found")
+)
+
+// TorrentRecord defines the persisted state of a torrent
+type TorrentRecord struct {
+	InfoHash     string // Hex of info hash, the key
+	Name         string
+	MagnetLink   string // To re-add the torrent if the metadata is lost
+	DownloadDir  string
+	Labels       []string
+	Paused       bool
+	Pieces       []byte // Bitfield of verified pieces
+	Downloaded   int64
+	Uploaded     int64
+	Corrupt      int64
+	AddedDate    time.Time
+	DoneDate     time.Time
+	ActivityDate time.Time
+}
+
+// StateStore stores the torrent records
+type StateStore interface {
+	// PutTorrent inserts or replaces the record
+	PutTorrent(ctx context.Context, r TorrentRecord) error
+	// GetTorrent returns ErrTorrentRecordNotFound if the record doesn't exist
+	GetTorrent(ctx context.Context, infoHash string) (*TorrentRecord, error)
+	// DeleteTorrent deletes the record and its stats, deleting a nonexistent record is not an error
+	DeleteTorrent(ctx context.Context, infoHash string) error
+	// Torrents returns all records ordered by info hash
+	Torrents(ctx context.Context) ([]TorrentRecord, error)
+	// AddStats adds the transferred bytes to the sample of the minute (truncated) of torrent
+	AddStats(ctx context.Context, infoHash string, sample StatsSample) error
+	// StatsHistory returns the samples of torrent in the recent window ordered by minute, minutes without
+	// transfer are omitted
+	StatsHistory(ctx context.Context, infoHash string, window time.Duration) ([]StatsSample, error)
+	// PruneStats deletes the samples before the time of all torrents
+	PruneStats(ctx context.Context, before time.Time) error
+}
+
+// StatsSample defines the bytes transferred in a minute
+type StatsSample struct {
+	Minute     time.Time
+	Downloaded int64
+	Uploaded   int64
+}
+
+//
+//
+//
+// Memory state store
+//
+//
+//
+
+// MemoryStateStore implements in-memory StateStore, e.g. for tests or sessions which don't need persistence
+type MemoryStateStore struct {
+	mutex   sync.Mutex
+	records map[string]TorrentRecord
+	stats   map[string]map[int64]StatsSample // Info hash -> unix minute -> sample
+}
+
+// NewMemoryStateStore creates a new MemoryStateStore
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{records: make(map[string]TorrentRecord), stats: make(map[string]map[int64]StatsSample)}
+}
+
+// PutTorrent implements StateStore
+func (s *MemoryStateStore) PutTorrent(ctx context.Context, r TorrentRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.records == nil {
+		s.records = make(map[string]TorrentRecord)
+	}
+	s.records[r.InfoHash] = cloneTorrentRecord(r)
+	return nil
+}
+
+// GetTorrent implements StateStore
+func (s *MemoryStateStore) GetTorrent(ctx context.Context, infoHash string) (*TorrentRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	r, ok := s.records[infoHash]
+	if !ok {
+		return nil, ErrTorrentRecordNotFound
+	}
+	r = cloneTorrentRecord(r)
+	return &r, nil
+}
+
+// DeleteTorrent implements StateStore
+func (s *MemoryStateStore) DeleteTorrent(ctx context.Context, infoHash string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.records, infoHash)
+	delete(s.stats, infoHash)
+	return nil
+}
+
+// Torrents implements StateStore
+func (s *MemoryStateStore) Torrents(ctx context.Context) ([]TorrentRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	records := make([]TorrentRecord, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, cloneTorrentRecord(r))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].InfoHash < records[j].InfoHash
+	})
+	return records, nil
+}
+
+// AddStats implements StateStore
+func (s *MemoryStateStore) AddStats(ctx context.Context, infoHash string, sample StatsSample) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stats == nil {
+		s.stats = make(map[string]map[int64]StatsSample)
+	}
+	samples := s.stats[infoHash]
+	if samples == nil {
+		samples = make(map[int64]StatsSample)
+		s.stats[infoHash] = samples
+	}
+	minute := sample.Minute.Truncate(time.Minute)
+	old := samples[minute.Unix()]
+	samples[minute.Unix()] = StatsSample{minute, old.Downloaded + sample.Downloaded, old.Uploaded + sample.Uploaded}
+	return nil
+}
+
+// StatsHistory implements StateStore
+func (s *MemoryStateStore) StatsHistory(ctx context.Context, infoHash string, window time.Duration) ([]StatsSample, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	since := time.Now().Add(-window).Truncate(time.Minute)
+	var samples []StatsSample
+	for _, sample := range s.stats[infoHash] {
+		if !sample.Minute.Before(since) {
+			samples = append(samples, sample)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Minute.Before(samples[j].Minute)
+	})
+	return samples, nil
+}
+
+// PruneStats implements StateStore
+func (s *MemoryStateStore) PruneStats(ctx context.Context, before time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for infoHash, samples := range s.stats {
+		for minute, sample := range samples {
+			if sample.Minute.Before(before) {
+				delete(samples, minute)
+			}
+		}
+		if len(samples) == 0 {
+			delete(s.stats, infoHash)
+		}
+	}
+	return nil
+}
+
+func cloneTorrentRecord(r TorrentRecord) TorrentRecord {
+	r.Labels = append([]string(nil), r.Labels...)
+	r.Pieces = append([]byte(nil), r.Pieces...)
+	return r
+}
+
+//
+//
+//
+// Stats recorder
+//
+//
+//
+
+type statsRecorderKey struct {
+	InfoHash string
+	Minute   int64
+}
+
+// StatsRecorder accumulates the transferred bytes of torrents per minute in memory and writes them to the store
+// on Flush, which should be called periodically (e.g. every minute) and at shutdown. It's safe for concurrent use.
+type StatsRecorder struct {
+	Store StateStore
+
+	mutex   sync.Mutex
+	pending map[statsRecorderKey]StatsSample
+}
+
+// NewStatsRecorder creates a new StatsRecorder
+func NewStatsRecorder(store StateStore) *StatsRecorder {
+	return &StatsRecorder{Store: store, pending: make(map[statsRecorderKey]StatsSample)}
+}
+
+// Record records the bytes transferred of torrent at now
+func (r *StatsRecorder) Record(infoHash string, downloaded, uploaded int64, now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[statsRecorderKey]StatsSample)
+	}
+	minute := now.Truncate(time.Minute)
+	key := statsRecorderKey{infoHash, minute.Unix()}
+	old := r.pending[key]
+	r.pending[key] = StatsSample{minute, old.Downloaded + downloaded, old.Uploaded + uploaded}
+}
+
+// Flush writes the recorded samples to the store, the samples failed to write are kept to retry
+func (r *StatsRecorder) Flush(ctx context.Context) error {
+	r.mutex.Lock()
+	pending := r.pending
+	r.pending = make(map[statsRecorderKey]StatsSample)
+	r.mutex.Unlock()
+
+	var errs []error
+	for key, sample := range pending {
+		if err := r.Store.AddStats(ctx, key.InfoHash, sample); err != nil {
+			errs = append(errs, err)
+			r.Record(key.InfoHash, sample.Downloaded, sample.Uploaded, sample.Minute)
+		}
+	}
+	return errors.Join(errs...)
+}
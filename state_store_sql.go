@@ -0,0 +1,268 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:50:50
+//
+// File Name: state_store_sql.go
+// Description:
+//
+//	SQLite backed StateStore by database/sql. The driver (e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3)
+//	is registered by the caller, so the tables can be queried by any sqlite tool as well.
+//
+
+package transmission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors
+var (
+	ErrStateStore = errors.New("State store error")
+)
+
+var sqliteStateStoreSchema = []string{
+	`CREATE TABLE IF NOT EXISTS torrents (
+		info_hash     TEXT PRIMARY KEY,
+		name          TEXT NOT NULL DEFAULT '',
+		magnet_link   TEXT NOT NULL DEFAULT '',
+		download_dir  TEXT NOT NULL DEFAULT '',
+		paused        INTEGER NOT NULL DEFAULT 0,
+		pieces        BLOB,
+		downloaded    INTEGER NOT NULL DEFAULT 0,
+		uploaded      INTEGER NOT NULL DEFAULT 0,
+		corrupt       INTEGER NOT NULL DEFAULT 0,
+		added_date    INTEGER NOT NULL DEFAULT 0,
+		done_date     INTEGER NOT NULL DEFAULT 0,
+		activity_date INTEGER NOT NULL DEFAULT 0
+	)`,
+	`CREATE TABLE IF NOT EXISTS torrent_labels (
+		info_hash TEXT NOT NULL,
+		position  INTEGER NOT NULL,
+		label     TEXT NOT NULL,
+		PRIMARY KEY (info_hash, position)
+	)`,
+	`CREATE TABLE IF NOT EXISTS torrent_stats (
+		info_hash  TEXT NOT NULL,
+		minute     INTEGER NOT NULL,
+		downloaded INTEGER NOT NULL DEFAULT 0,
+		uploaded   INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (info_hash, minute)
+	)`,
+}
+
+const sqliteTorrentColumns = `info_hash, name, magnet_link, download_dir, paused, pieces, downloaded, uploaded, corrupt,
+	added_date, done_date, activity_date`
+
+// SQLiteStateStore implements StateStore by a sqlite database. Times are stored as unix seconds (0 if not set),
+// the stats samples are in torrent_stats whose minute is the unix seconds of the minute.
+type SQLiteStateStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStateStore creates the tables if not exist and returns the store, db is not closed by the store
+func NewSQLiteStateStore(ctx context.Context, db *sql.DB) (*SQLiteStateStore, error) {
+	for _, stmt := range sqliteStateStoreSchema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("%w: Cannot create tables [%v]", ErrStateStore, err)
+		}
+	}
+	return &SQLiteStateStore{db}, nil
+}
+
+// DB returns the database, e.g. to query history
+func (s *SQLiteStateStore) DB() *sql.DB {
+	return s.db
+}
+
+// PutTorrent implements StateStore
+func (s *SQLiteStateStore) PutTorrent(ctx context.Context, r TorrentRecord) error {
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO torrents (`+sqliteTorrentColumns+`)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (info_hash) DO UPDATE SET
+				name = excluded.name,
+				magnet_link = excluded.magnet_link,
+				download_dir = excluded.download_dir,
+				paused = excluded.paused,
+				pieces = excluded.pieces,
+				downloaded = excluded.downloaded,
+				uploaded = excluded.uploaded,
+				corrupt = excluded.corrupt,
+				added_date = excluded.added_date,
+				done_date = excluded.done_date,
+				activity_date = excluded.activity_date`,
+			r.InfoHash, r.Name, r.MagnetLink, r.DownloadDir, r.Paused, r.Pieces, r.Downloaded, r.Uploaded, r.Corrupt,
+			unixOrZero(r.AddedDate), unixOrZero(r.DoneDate), unixOrZero(r.ActivityDate),
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM torrent_labels WHERE info_hash = ?`, r.InfoHash); err != nil {
+			return err
+		}
+		for i, label := range r.Labels {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO torrent_labels (info_hash, position, label) VALUES (?, ?, ?)`,
+				r.InfoHash, i, label); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetTorrent implements StateStore
+func (s *SQLiteStateStore) GetTorrent(ctx context.Context, infoHash string) (*TorrentRecord, error) {
+	records, err := s.query(ctx, `WHERE info_hash = ?`, infoHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, ErrTorrentRecordNotFound
+	}
+	return &records[0], nil
+}
+
+// DeleteTorrent implements StateStore
+func (s *SQLiteStateStore) DeleteTorrent(ctx context.Context, infoHash string) error {
+	return s.tx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM torrent_labels WHERE info_hash = ?`, infoHash); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM torrent_stats WHERE info_hash = ?`, infoHash); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM torrents WHERE info_hash = ?`, infoHash)
+		return err
+	})
+}
+
+// Torrents implements StateStore
+func (s *SQLiteStateStore) Torrents(ctx context.Context) ([]TorrentRecord, error) {
+	return s.query(ctx, "")
+}
+
+// AddStats implements StateStore
+func (s *SQLiteStateStore) AddStats(ctx context.Context, infoHash string, sample StatsSample) error {
+	if _, err := s.db.ExecContext(ctx, `INSERT INTO torrent_stats (info_hash, minute, downloaded, uploaded)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (info_hash, minute) DO UPDATE SET
+			downloaded = downloaded + excluded.downloaded,
+			uploaded = uploaded + excluded.uploaded`,
+		infoHash, sample.Minute.Truncate(time.Minute).Unix(), sample.Downloaded, sample.Uploaded,
+	); err != nil {
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return nil
+}
+
+// StatsHistory implements StateStore
+func (s *SQLiteStateStore) StatsHistory(ctx context.Context, infoHash string, window time.Duration) ([]StatsSample, error) {
+	since := time.Now().Add(-window).Truncate(time.Minute).Unix()
+	rows, err := s.db.QueryContext(ctx, `SELECT minute, downloaded, uploaded FROM torrent_stats
+		WHERE info_hash = ? AND minute >= ? ORDER BY minute`, infoHash, since)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	defer rows.Close()
+	var samples []StatsSample
+	for rows.Next() {
+		var (
+			sample StatsSample
+			minute int64
+		)
+		if err := rows.Scan(&minute, &sample.Downloaded, &sample.Uploaded); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+		}
+		sample.Minute = time.Unix(minute, 0)
+		samples = append(samples, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return samples, nil
+}
+
+// PruneStats implements StateStore
+func (s *SQLiteStateStore) PruneStats(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM torrent_stats WHERE minute < ?`, before.Unix()); err != nil {
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return nil
+}
+
+// query returns the records of the where clause (on info_hash only, which is applied to labels as well) ordered by
+// info hash
+func (s *SQLiteStateStore) query(ctx context.Context, where string, args ...interface{}) ([]TorrentRecord, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteTorrentColumns+` FROM torrents `+where+` ORDER BY info_hash`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	defer rows.Close()
+	var (
+		records []TorrentRecord
+		indexes = make(map[string]int)
+	)
+	for rows.Next() {
+		var (
+			r                                 TorrentRecord
+			addedDate, doneDate, activityDate int64
+		)
+		if err := rows.Scan(&r.InfoHash, &r.Name, &r.MagnetLink, &r.DownloadDir, &r.Paused, &r.Pieces, &r.Downloaded,
+			&r.Uploaded, &r.Corrupt, &addedDate, &doneDate, &activityDate); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+		}
+		r.AddedDate, r.DoneDate, r.ActivityDate = unixTimeOrZero(addedDate), unixTimeOrZero(doneDate), unixTimeOrZero(activityDate)
+		indexes[r.InfoHash] = len(records)
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	labelRows, err := s.db.QueryContext(ctx, `SELECT info_hash, label FROM torrent_labels `+where+` ORDER BY info_hash, position`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	defer labelRows.Close()
+	for labelRows.Next() {
+		var infoHash, label string
+		if err := labelRows.Scan(&infoHash, &label); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+		}
+		if i, ok := indexes[infoHash]; ok {
+			records[i].Labels = append(records[i].Labels, label)
+		}
+	}
+	if err := labelRows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return records, nil
+}
+
+func (s *SQLiteStateStore) tx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrStateStore, err)
+	}
+	return nil
+}
+
+// unixOrZero returns the unix seconds of t, 0 if t is zero
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,174 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:45:08
+//
+// File Name: super_seed.go
+// Description:
+//
+//	Super-seeding (BEP 16) for initial seeders: the seeder pretends to have no pieces and offers each peer one piece
+//	at a time, the next piece is offered only after the previous one is seen on another peer
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0016.html
+//
+
+package transmission
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// SuperSeedOffer defines a piece offered to a peer, which is announced to the peer by a have message
+type SuperSeedOffer struct {
+	Peer  PeerConnID
+	Piece int
+}
+
+// SuperSeeder chooses the pieces to offer in super-seeding mode. The peers are sent no bitfield (or have none of
+// the fast extension) but a have message of the offered piece. A peer is offered the next piece once the piece
+// offered to it is announced by another peer, so the seeder uploads every piece about once while the peers spread
+// them. The least offered and rarest pieces the peer doesn't have are offered first. It's safe for concurrent use,
+// use one per torrent.
+type SuperSeeder struct {
+	Rand *rand.Rand // The global source is used if nil
+
+	mutex        sync.Mutex
+	pieceCount   int
+	availability []int // The peers having each piece
+	offers       []int // The times each piece is offered
+	peers        map[PeerConnID]*superSeedPeer
+}
+
+type superSeedPeer struct {
+	have    []bool
+	offered int // The piece offered, -1 if none
+}
+
+// NewSuperSeeder creates a new SuperSeeder
+func NewSuperSeeder(pieceCount int) *SuperSeeder {
+	return &SuperSeeder{
+		pieceCount:   pieceCount,
+		availability: make([]int, pieceCount),
+		offers:       make([]int, pieceCount),
+		peers:        make(map[PeerConnID]*superSeedPeer),
+	}
+}
+
+// AddPeer adds a peer by its bitfield (nil if it has none) and returns the piece offered to it, false if it has
+// every piece
+func (s *SuperSeeder) AddPeer(id PeerConnID, bitfield []byte) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.removePeer(id)
+	peer := &superSeedPeer{have: make([]bool, s.pieceCount), offered: -1}
+	for i := 0; i < s.pieceCount; i++ {
+		if bitfieldHas(bitfield, i) {
+			peer.have[i] = true
+			s.availability[i]++
+		}
+	}
+	s.peers[id] = peer
+	return s.offer(peer)
+}
+
+// RemovePeer removes the disconnected peer
+func (s *SuperSeeder) RemovePeer(id PeerConnID) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.removePeer(id)
+}
+
+// Have records a have message of peer and returns the new offers: the peers whose offered piece is now seen on
+// this peer get their next pieces
+func (s *SuperSeeder) Have(id PeerConnID, piece int) []SuperSeedOffer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	peer, ok := s.peers[id]
+	if !ok || piece < 0 || piece >= s.pieceCount || peer.have[piece] {
+		return nil
+	}
+	peer.have[piece] = true
+	s.availability[piece]++
+
+	var offers []SuperSeedOffer
+	for otherID, other := range s.peers {
+		if otherID != id && other.offered == piece {
+			if next, ok := s.offer(other); ok {
+				offers = append(offers, SuperSeedOffer{otherID, next})
+			}
+		}
+	}
+	if peer.offered == -1 || peer.have[peer.offered] && s.availability[peer.offered] > 1 {
+		// The peer had nothing to download, or its piece has already spread
+		if next, ok := s.offer(peer); ok {
+			offers = append(offers, SuperSeedOffer{id, next})
+		}
+	}
+	return offers
+}
+
+// Allowed checks if the request of piece from peer should be served, only the offered piece is
+func (s *SuperSeeder) Allowed(id PeerConnID, piece int) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	peer, ok := s.peers[id]
+	return ok && peer.offered == piece && piece >= 0
+}
+
+// Offered returns the piece offered to peer, false if none
+func (s *SuperSeeder) Offered(id PeerConnID) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	peer, ok := s.peers[id]
+	if !ok || peer.offered < 0 {
+		return 0, false
+	}
+	return peer.offered, true
+}
+
+// offer picks the next piece of peer
+func (s *SuperSeeder) offer(peer *superSeedPeer) (int, bool) {
+	var candidates []int
+	for i := 0; i < s.pieceCount; i++ {
+		if peer.have[i] {
+			continue
+		}
+		if len(candidates) > 0 {
+			best := candidates[0]
+			if s.offers[i] > s.offers[best] || s.offers[i] == s.offers[best] && s.availability[i] > s.availability[best] {
+				continue
+			}
+			if s.offers[i] < s.offers[best] || s.availability[i] < s.availability[best] {
+				candidates = candidates[:0]
+			}
+		}
+		candidates = append(candidates, i)
+	}
+	if len(candidates) == 0 {
+		peer.offered = -1
+		return 0, false
+	}
+	var piece int
+	if s.Rand != nil {
+		piece = candidates[s.Rand.Intn(len(candidates))]
+	} else {
+		piece = candidates[rand.Intn(len(candidates))]
+	}
+	peer.offered = piece
+	s.offers[piece]++
+	return piece, true
+}
+
+func (s *SuperSeeder) removePeer(id PeerConnID) {
+	peer, ok := s.peers[id]
+	if !ok {
+		return
+	}
+	for i, have := range peer.have {
+		if have {
+			s.availability[i]--
+		}
+	}
+	delete(s.peers, id)
+}
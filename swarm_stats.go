@@ -0,0 +1,136 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:27:53
+//
+// File Name: swarm_stats.go
+// Description:
+//
+//	Transfer rate smoothing, ETA and swarm availability (distributed copies)
+//
+
+package transmission
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// DefaultRateHalfLife defines the default half life of RateEstimator
+const DefaultRateHalfLife = 5 * time.Second
+
+// RateEstimator estimates the transfer rate (bytes per second) by exponentially weighted moving average.
+// It's safe for concurrent use.
+type RateEstimator struct {
+	HalfLife time.Duration // DefaultRateHalfLife is used if zero
+
+	mu      sync.Mutex
+	rate    float64
+	last    time.Time
+	pending int64
+	started bool
+}
+
+// NewRateEstimator creates a new RateEstimator
+func NewRateEstimator(halfLife time.Duration) *RateEstimator {
+	return &RateEstimator{HalfLife: halfLife}
+}
+
+// Add adds the bytes transferred at now
+func (e *RateEstimator) Add(n int64, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pending += n
+	e.update(now)
+}
+
+// Rate returns the smoothed rate at now, in bytes per second. The rate decays if nothing is transferred
+func (e *RateEstimator) Rate(now time.Time) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.update(now)
+	return e.rate
+}
+
+func (e *RateEstimator) update(now time.Time) {
+	if !e.started {
+		e.started, e.last = true, now
+		return
+	}
+	dt := now.Sub(e.last).Seconds()
+	if dt <= 0 {
+		// Accumulate the bytes until time elapses
+		return
+	}
+	halfLife := e.HalfLife
+	if halfLife <= 0 {
+		halfLife = DefaultRateHalfLife
+	}
+	weight := 1 - math.Exp2(-dt/halfLife.Seconds())
+	e.rate += weight * (float64(e.pending)/dt - e.rate)
+	e.pending, e.last = 0, now
+}
+
+// ETA estimates the time remaining to transfer left bytes at rate (bytes per second).
+// Returns false if it's unknown, i.e. the rate is zero.
+func ETA(left int64, rate float64) (time.Duration, bool) {
+	if left <= 0 {
+		return 0, true
+	}
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0, false
+	}
+	seconds := float64(left) / rate
+	if seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second), true
+}
+
+// PieceAvailability counts the peers having each piece by the bitfields (BEP 3, the high bit of the first byte is piece 0)
+func PieceAvailability(pieceCount int, bitfields ...[]byte) []int {
+	availability := make([]int, pieceCount)
+	for _, bitfield := range bitfields {
+		for i := 0; i < pieceCount && i/8 < len(bitfield); i++ {
+			if bitfield[i/8]&(0x80>>uint(i%8)) != 0 {
+				availability[i]++
+			}
+		}
+	}
+	return availability
+}
+
+// DistributedCopies calculates the distributed copies of the swarm (like the "Availability" column of mainstream clients).
+// The integer part is the number of complete copies, the fraction is the share of pieces with more copies.
+func DistributedCopies(availability []int) float64 {
+	if len(availability) == 0 {
+		return 0
+	}
+	least := availability[0]
+	for _, n := range availability[1:] {
+		least = min(least, n)
+	}
+	var more int
+	for _, n := range availability {
+		if n > least {
+			more++
+		}
+	}
+	return float64(least) + float64(more)/float64(len(availability))
+}
+
+// BitfieldPieces counts the pieces a bitfield has
+func BitfieldPieces(pieceCount int, bitfield []byte) int {
+	var n int
+	for i, b := range bitfield {
+		if (i+1)*8 > pieceCount {
+			// Ignore the spare bits
+			if i*8 >= pieceCount {
+				break
+			}
+			b &= 0xff << uint(8-pieceCount%8)
+		}
+		n += bits.OnesCount8(b)
+	}
+	return n
+}
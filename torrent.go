@@ -12,3 +12,342 @@
 //
 
 package transmission
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrMalformedTorrentFile = errors.New("Malformed torrent file")
+)
+
+// TorrentVersion defines the bittorrent protocol version of torrent
+type TorrentVersion string
+
+// Torrent version
+const (
+	TorrentVersionUnknown TorrentVersion = ""
+	TorrentV1             TorrentVersion = "v1"
+	TorrentV2             TorrentVersion = "v2"
+	TorrentHybrid         TorrentVersion = "hybrid" // Both v1 and v2
+)
+
+// TorrentFile defines the torrent (metainfo) file
+type TorrentFile struct {
+	Announce     string
+	AnnounceList [][]string // Tiers of trackers (BEP 12)
+	URLList      []string   // Web seeds (BEP 19)
+	Comment      string
+	CreatedBy    string
+	CreationDate time.Time
+	Encoding     string
+	Info         TorrentInfo
+	InfoHashs    []HashValue // SHA-1 for v1 info dict, SHA-256 for v2 info dict
+	RawInfo      []byte      // The bencoded info dict
+	Raw          []byte      // The whole torrent file
+
+	infoHashCache *infoHashCache
+}
+
+// TorrentInfo defines the info dict of torrent file
+type TorrentInfo struct {
+	Name        string
+	PieceLength int64
+	Pieces      []byte // Concatenated SHA-1 hashes of pieces (v1)
+	Private     bool
+	MetaVersion int                // 2 for v2 or hybrid torrents
+	Length      int64              // Single file mode
+	Attr        string             // Attributes of single file mode (BEP 47)
+	Files       []TorrentFileEntry // Multiple files mode, or the flattened file tree of v2
+}
+
+// TorrentFileEntry defines a file in torrent
+type TorrentFileEntry struct {
+	Path        []string
+	Length      int64
+	Attr        string   // Attributes (BEP 47), "p" padding, "x" executable, "h" hidden, "l" symlink
+	SymlinkPath []string // The target of symlink relative to the torrent root (BEP 47)
+}
+
+// IsPadding checks if the file is a padding file, by the "p" attribute (BEP 47) or the conventional names
+func (e TorrentFileEntry) IsPadding() bool {
+	if strings.ContainsRune(e.Attr, 'p') {
+		return true
+	}
+	if len(e.Path) > 1 && e.Path[0] == ".pad" {
+		return true
+	}
+	// Written by old BitComet versions
+	return len(e.Path) > 0 && strings.HasPrefix(e.Path[len(e.Path)-1], "_____padding_file_")
+}
+
+// IsExecutable checks if the file has the "x" attribute
+func (e TorrentFileEntry) IsExecutable() bool {
+	return strings.ContainsRune(e.Attr, 'x')
+}
+
+// IsHidden checks if the file has the "h" attribute
+func (e TorrentFileEntry) IsHidden() bool {
+	return strings.ContainsRune(e.Attr, 'h')
+}
+
+// IsSymlink checks if the file is a symlink ("l" attribute with symlink path)
+func (e TorrentFileEntry) IsSymlink() bool {
+	return strings.ContainsRune(e.Attr, 'l') && len(e.SymlinkPath) > 0
+}
+
+// ParseTorrentFile parses torrent file content
+func ParseTorrentFile(data []byte) (*TorrentFile, error) {
+	raws, err := decodeBencodeDictRaw(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTorrentFile, err)
+	}
+	v, err := DecodeBencode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTorrentFile, err)
+	}
+	dict := v.(map[string]interface{})
+	rawInfo, ok := raws["info"]
+	if !ok {
+		return nil, fmt.Errorf("%w: Missing info", ErrMalformedTorrentFile)
+	}
+	info := bencodeDictDict(dict, "info")
+	if info == nil {
+		return nil, fmt.Errorf("%w: Invalid info", ErrMalformedTorrentFile)
+	}
+
+	t := TorrentFile{
+		Announce:  bencodeDictString(dict, "announce"),
+		URLList:   bencodeStringList(bencodeDictList(dict, "url-list")),
+		Comment:   bencodeDictString(dict, "comment"),
+		CreatedBy: bencodeDictString(dict, "created by"),
+		Encoding:  bencodeDictString(dict, "encoding"),
+		RawInfo:   rawInfo,
+		Raw:       data,
+	}
+	if s := bencodeDictString(dict, "url-list"); s != "" {
+		t.URLList = []string{s}
+	}
+	for _, tier := range bencodeDictList(dict, "announce-list") {
+		if tier, ok := tier.([]interface{}); ok {
+			if trackers := bencodeStringList(tier); len(trackers) > 0 {
+				t.AnnounceList = append(t.AnnounceList, trackers)
+			}
+		}
+	}
+	if date := bencodeDictInt(dict, "creation date"); date > 0 {
+		t.CreationDate = time.Unix(date, 0)
+	}
+
+	// Info
+	t.Info.Name = bencodeDictString(info, "name")
+	t.Info.PieceLength = bencodeDictInt(info, "piece length")
+	t.Info.Pieces = []byte(bencodeDictString(info, "pieces"))
+	t.Info.Private = bencodeDictInt(info, "private") == 1
+	t.Info.MetaVersion = int(bencodeDictInt(info, "meta version"))
+	t.Info.Attr = bencodeDictString(info, "attr")
+	if t.Info.PieceLength <= 0 {
+		return nil, fmt.Errorf("%w: Invalid piece length", ErrMalformedTorrentFile)
+	}
+	if len(t.Info.Pieces)%sha1.Size != 0 {
+		return nil, fmt.Errorf("%w: Invalid pieces", ErrMalformedTorrentFile)
+	}
+	if files, ok := info["files"].([]interface{}); ok {
+		for _, file := range files {
+			file, ok := file.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: Invalid file", ErrMalformedTorrentFile)
+			}
+			entry := TorrentFileEntry{
+				Path:        bencodeStringList(bencodeDictList(file, "path")),
+				Length:      bencodeDictInt(file, "length"),
+				Attr:        bencodeDictString(file, "attr"),
+				SymlinkPath: bencodeStringList(bencodeDictList(file, "symlink path")),
+			}
+			if len(entry.Path) == 0 || entry.Length < 0 {
+				return nil, fmt.Errorf("%w: Invalid file", ErrMalformedTorrentFile)
+			}
+			t.Info.Files = append(t.Info.Files, entry)
+		}
+	} else if length, ok := info["length"].(int64); ok {
+		if length < 0 {
+			return nil, fmt.Errorf("%w: Invalid length [%v]", ErrMalformedTorrentFile, length)
+		}
+		t.Info.Length = length
+	} else if tree := bencodeDictDict(info, "file tree"); tree != nil && t.Info.MetaVersion == 2 {
+		t.Info.Files = flattenTorrentFileTree(tree, nil)
+		for _, entry := range t.Info.Files {
+			if entry.Length < 0 {
+				return nil, fmt.Errorf("%w: Invalid file", ErrMalformedTorrentFile)
+			}
+		}
+	} else {
+		return nil, fmt.Errorf("%w: Missing files", ErrMalformedTorrentFile)
+	}
+
+	// Info hashs
+	if len(t.Info.Pieces) > 0 {
+		sum := sha1.Sum(rawInfo)
+		t.InfoHashs = append(t.InfoHashs, HashValue{HashSHA1, sum[:]})
+	}
+	if t.Info.MetaVersion == 2 {
+		sum := sha256.Sum256(rawInfo)
+		t.InfoHashs = append(t.InfoHashs, HashValue{HashSHA256, sum[:]})
+	}
+
+	return &t, nil
+}
+
+func flattenTorrentFileTree(tree map[string]interface{}, prefix []string) []TorrentFileEntry {
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	// Keys of bencoded dict are sorted
+	sort.Strings(names)
+
+	var entries []TorrentFileEntry
+	for _, name := range names {
+		node, ok := tree[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name == "" {
+			// File node
+			entries = append(entries, TorrentFileEntry{
+				Path:        append([]string(nil), prefix...),
+				Length:      bencodeDictInt(node, "length"),
+				Attr:        bencodeDictString(node, "attr"),
+				SymlinkPath: bencodeStringList(bencodeDictList(node, "symlink path")),
+			})
+			continue
+		}
+		path := append(append([]string(nil), prefix...), name)
+		entries = append(entries, flattenTorrentFileTree(node, path)...)
+	}
+	return entries
+}
+
+// ReadTorrentFile reads and parses torrent file
+func ReadTorrentFile(path string) (*TorrentFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTorrentFile(data)
+}
+
+// TotalLength returns the total length of contents
+func (t *TorrentFile) TotalLength() int64 {
+	if len(t.Info.Files) == 0 {
+		return t.Info.Length
+	}
+	var total int64
+	for _, file := range t.Info.Files {
+		total += file.Length
+	}
+	return total
+}
+
+// PieceCount returns the number of pieces
+func (t *TorrentFile) PieceCount() int {
+	if len(t.Info.Pieces) > 0 {
+		return len(t.Info.Pieces) / sha1.Size
+	}
+	return int((t.TotalLength() + t.Info.PieceLength - 1) / t.Info.PieceLength)
+}
+
+// FilePieceRange returns the pieces [start, end) of the file at index of Info.Files.
+// Padding files and empty files have no pieces.
+func (t *TorrentFile) FilePieceRange(index int) (start, end int) {
+	if index < 0 || index >= len(t.Info.Files) || t.Info.PieceLength <= 0 {
+		return 0, 0
+	}
+	var offset int64
+	for _, file := range t.Info.Files[:index] {
+		offset += file.Length
+	}
+	file := t.Info.Files[index]
+	if file.Length == 0 || file.IsPadding() {
+		return 0, 0
+	}
+	return int(offset / t.Info.PieceLength), int((offset + file.Length + t.Info.PieceLength - 1) / t.Info.PieceLength)
+}
+
+// MagnetLink returns the magnet link of torrent, with the info hashs (btih for v1, btmh for v2), name, total length
+// and trackers
+func (t *TorrentFile) MagnetLink() *MagnetLink {
+	l := MagnetLink{Tr: t.Trackers()}
+	for _, infoHash := range t.InfoHashs {
+		switch infoHash.Type {
+		case HashSHA1:
+			l.Xt = append(l.Xt, Urn{"btih", hex.EncodeToString(infoHash.Value)})
+		case HashSHA256:
+			// Multihash of sha2-256
+			l.Xt = append(l.Xt, Urn{"btmh", "1220" + hex.EncodeToString(infoHash.Value)})
+		}
+	}
+	if t.Info.Name != "" {
+		l.Dn = []string{t.Info.Name}
+	}
+	if length := t.TotalLength(); length > 0 {
+		l.Xl = []int{int(length)}
+	}
+	return &l
+}
+
+// Version returns the torrent version by the info hashs
+func (t *TorrentFile) Version() TorrentVersion {
+	return torrentVersionOf(t.InfoHashs)
+}
+
+func torrentVersionOf(infoHashs []HashValue) TorrentVersion {
+	var v1, v2 bool
+	for _, infoHash := range infoHashs {
+		switch infoHash.Type {
+		case HashSHA1:
+			v1 = true
+		case HashSHA256:
+			v2 = true
+		}
+	}
+	switch {
+	case v1 && v2:
+		return TorrentHybrid
+	case v1:
+		return TorrentV1
+	case v2:
+		return TorrentV2
+	}
+	return TorrentVersionUnknown
+}
+
+// Trackers returns all trackers (announce-list takes precedence over announce) with duplications removed
+func (t *TorrentFile) Trackers() []string {
+	var (
+		trackers []string
+		seen     = make(map[string]bool)
+	)
+	add := func(tracker string) {
+		if tracker != "" && !seen[tracker] {
+			seen[tracker] = true
+			trackers = append(trackers, tracker)
+		}
+	}
+	for _, tier := range t.AnnounceList {
+		for _, tracker := range tier {
+			add(tracker)
+		}
+	}
+	add(t.Announce)
+	return trackers
+}
@@ -0,0 +1,164 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:23:13
+//
+// File Name: torrent_describe.go
+// Description:
+//
+//	Describe torrent file (like transmission-show)
+//
+
+package transmission
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// TorrentDescribeOptions defines the options of (*TorrentFile).Describe
+type TorrentDescribeOptions struct {
+	JSON    bool // Write machine-readable json instead of text
+	Padding bool // Include padding files in the file list
+}
+
+// TorrentDescription defines the machine-readable description of torrent file
+type TorrentDescription struct {
+	Name         string                   `json:"name"`
+	InfoHashs    map[string]string        `json:"infoHashs"` // Hash type -> hex
+	CreatedBy    string                   `json:"createdBy,omitempty"`
+	CreationDate *time.Time               `json:"creationDate,omitempty"`
+	Comment      string                   `json:"comment,omitempty"`
+	Private      bool                     `json:"private"`
+	PieceLength  int64                    `json:"pieceLength"`
+	PieceCount   int                      `json:"pieceCount"`
+	TotalLength  int64                    `json:"totalLength"`
+	Trackers     [][]string               `json:"trackers"` // Tiers
+	WebSeeds     []string                 `json:"webSeeds,omitempty"`
+	Files        []TorrentFileDescription `json:"files"`
+}
+
+// TorrentFileDescription defines the description of a file in torrent
+type TorrentFileDescription struct {
+	Path   string `json:"path"`
+	Length int64  `json:"length"`
+}
+
+// Description returns the machine-readable description, padding files are excluded from the file list
+func (t *TorrentFile) Description() TorrentDescription {
+	return t.description(false)
+}
+
+func (t *TorrentFile) description(padding bool) TorrentDescription {
+	d := TorrentDescription{
+		Name:        t.Info.Name,
+		InfoHashs:   make(map[string]string),
+		CreatedBy:   t.CreatedBy,
+		Comment:     t.Comment,
+		Private:     t.Info.Private,
+		PieceLength: t.Info.PieceLength,
+		PieceCount:  t.PieceCount(),
+		TotalLength: t.TotalLength(),
+		Trackers:    t.AnnounceList,
+		WebSeeds:    t.URLList,
+	}
+	for _, infoHash := range t.InfoHashs {
+		d.InfoHashs[infoHash.Type] = hex.EncodeToString(infoHash.Value)
+	}
+	if !t.CreationDate.IsZero() {
+		creationDate := t.CreationDate
+		d.CreationDate = &creationDate
+	}
+	if len(d.Trackers) == 0 && t.Announce != "" {
+		d.Trackers = [][]string{{t.Announce}}
+	}
+	if len(t.Info.Files) == 0 {
+		d.Files = []TorrentFileDescription{{t.Info.Name, t.Info.Length}}
+	} else {
+		for _, file := range t.Info.Files {
+			if file.IsPadding() && !padding {
+				continue
+			}
+			d.Files = append(d.Files, TorrentFileDescription{
+				Path:   path.Join(append([]string{t.Info.Name}, file.Path...)...),
+				Length: file.Length,
+			})
+		}
+	}
+	return d
+}
+
+// Describe writes the summary of torrent file to w, in the format of transmission-show
+func (t *TorrentFile) Describe(w io.Writer, opts TorrentDescribeOptions) error {
+	d := t.description(opts.Padding)
+	if opts.JSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(d)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Name: %v\n\n", d.Name)
+	b.WriteString("GENERAL\n\n")
+	fmt.Fprintf(&b, "  Name: %v\n", d.Name)
+	for _, infoHash := range t.InfoHashs {
+		fmt.Fprintf(&b, "  Hash (%v): %v\n", infoHash.Type, hex.EncodeToString(infoHash.Value))
+	}
+	if d.CreatedBy != "" {
+		fmt.Fprintf(&b, "  Created by: %v\n", d.CreatedBy)
+	}
+	if d.CreationDate != nil {
+		fmt.Fprintf(&b, "  Created on: %v\n", d.CreationDate.Format(time.RFC1123))
+	}
+	if d.Comment != "" {
+		fmt.Fprintf(&b, "  Comment: %v\n", d.Comment)
+	}
+	fmt.Fprintf(&b, "  Piece Count: %v\n", d.PieceCount)
+	fmt.Fprintf(&b, "  Piece Size: %v\n", FormatSize(d.PieceLength))
+	fmt.Fprintf(&b, "  Total Size: %v\n", FormatSize(d.TotalLength))
+	if d.Private {
+		b.WriteString("  Privacy: Private torrent\n")
+	} else {
+		b.WriteString("  Privacy: Public torrent\n")
+	}
+
+	b.WriteString("\nTRACKERS\n")
+	for i, tier := range d.Trackers {
+		fmt.Fprintf(&b, "\n  Tier #%v\n", i+1)
+		for _, tracker := range tier {
+			fmt.Fprintf(&b, "  %v\n", tracker)
+		}
+	}
+
+	if len(d.WebSeeds) > 0 {
+		b.WriteString("\nWEBSEEDS\n\n")
+		for _, webSeed := range d.WebSeeds {
+			fmt.Fprintf(&b, "  %v\n", webSeed)
+		}
+	}
+
+	b.WriteString("\nFILES\n\n")
+	for _, file := range d.Files {
+		fmt.Fprintf(&b, "  %v (%v)\n", file.Path, FormatSize(file.Length))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// FormatSize formats the size in bytes with binary units, e.g. "1.50 MiB"
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%v B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 5; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
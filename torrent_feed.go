@@ -0,0 +1,214 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:39:01
+//
+// File Name: torrent_feed.go
+// Description:
+//
+//	Generate rss feeds of torrents with magnet enclosures
+//
+//	Reference:
+//
+//		https://www.rssboard.org/rss-specification
+//		http://xmlns.ezrss.it/0.1/
+//
+
+package transmission
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// torrentFeedNamespace defines the torrent namespace of rss (used by ezrss and most torrent feeds)
+const torrentFeedNamespace = "http://xmlns.ezrss.it/0.1/"
+
+// TorrentFeed defines a rss feed of torrents
+type TorrentFeed struct {
+	Title       string
+	Link        string
+	Description string
+	Items       []TorrentFeedItem
+}
+
+// TorrentFeedItem defines a torrent in feed
+type TorrentFeedItem struct {
+	Title    string
+	InfoHash string // Hex of the v1 info hash, or the v2 info hash if v1 doesn't exist
+	Magnet   string
+	Length   int64
+	PubDate  time.Time
+}
+
+// NewTorrentFeedItem creates the feed item of torrent
+func NewTorrentFeedItem(t *TorrentFile, pubDate time.Time) TorrentFeedItem {
+	item := TorrentFeedItem{
+		Title:   t.Info.Name,
+		Magnet:  t.MagnetLink().String(),
+		Length:  t.TotalLength(),
+		PubDate: pubDate,
+	}
+	if len(t.InfoHashs) > 0 {
+		item.InfoHash = hex.EncodeToString(t.InfoHashs[0].Value)
+	}
+	return item
+}
+
+// ReadTorrentFeedItems reads the .torrent files in dir as feed items, newest first.
+// The publish date is the creation date of torrent, or the modification time of file if not set.
+// Files which cannot be parsed are skipped.
+func ReadTorrentFeedItems(dir string) ([]TorrentFeedItem, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var items []TorrentFeedItem
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".torrent") {
+			continue
+		}
+		t, err := ReadTorrentFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		pubDate := t.CreationDate
+		if pubDate.IsZero() {
+			if info, err := entry.Info(); err == nil {
+				pubDate = info.ModTime()
+			}
+		}
+		items = append(items, NewTorrentFeedItem(t, pubDate))
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].PubDate.After(items[j].PubDate)
+	})
+	return items, nil
+}
+
+// RewriteTrackers rewrites the trackers of the magnet links of items, the links which cannot be parsed are kept
+func (f *TorrentFeed) RewriteTrackers(r *TrackerRewriter) {
+	for i := range f.Items {
+		l, err := ParseMagnetLink(f.Items[i].Magnet)
+		if err != nil {
+			continue
+		}
+		if r.RewriteMagnetLink(l) {
+			f.Items[i].Magnet = l.String()
+		}
+	}
+}
+
+type rssDocument struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	Namespace string     `xml:"xmlns:torrent,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	GUID          rssGUID      `xml:"guid"`
+	PubDate       string       `xml:"pubDate,omitempty"`
+	Enclosure     rssEnclosure `xml:"enclosure"`
+	InfoHash      string       `xml:"torrent:infoHash,omitempty"`
+	ContentLength int64        `xml:"torrent:contentLength,omitempty"`
+	MagnetURI     string       `xml:"torrent:magnetURI"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// WriteRSS writes the feed as rss 2.0
+func (f *TorrentFeed) WriteRSS(w io.Writer) error {
+	doc := rssDocument{
+		Version:   "2.0",
+		Namespace: torrentFeedNamespace,
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Description,
+		},
+	}
+	for _, item := range f.Items {
+		guid := item.InfoHash
+		if guid == "" {
+			guid = item.Magnet
+		}
+		rss := rssItem{
+			Title:         item.Title,
+			Link:          item.Magnet,
+			GUID:          rssGUID{false, guid},
+			Enclosure:     rssEnclosure{item.Magnet, item.Length, "application/x-bittorrent"},
+			InfoHash:      item.InfoHash,
+			ContentLength: item.Length,
+			MagnetURI:     item.Magnet,
+		}
+		if !item.PubDate.IsZero() {
+			rss.PubDate = item.PubDate.Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, rss)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// TorrentFeedHandler serves the rss feed of the .torrent files in Dir, the directory is read on every request
+type TorrentFeedHandler struct {
+	Dir         string
+	Title       string
+	Link        string
+	Description string
+	MaxItems    int              // No limit if zero
+	Rewriter    *TrackerRewriter // Rewrites the trackers of magnet links, optional
+}
+
+// ServeHTTP implements http.Handler
+func (h *TorrentFeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	items, err := ReadTorrentFeedItems(h.Dir)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if h.MaxItems > 0 && len(items) > h.MaxItems {
+		items = items[:h.MaxItems]
+	}
+	feed := TorrentFeed{Title: h.Title, Link: h.Link, Description: h.Description, Items: items}
+	if h.Rewriter != nil {
+		feed.RewriteTrackers(h.Rewriter)
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	feed.WriteRSS(w)
+}
@@ -0,0 +1,215 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:20:02
+//
+// File Name: torrent_fetch.go
+// Description:
+//
+//	Fetch the torrent file from the acceptable sources (as) and exact sources (xs) of a magnet link
+//
+
+package transmission
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrFetchTorrent = errors.New("Failed to fetch torrent")
+)
+
+// maxTorrentFileSize defines the max size of fetched torrent file
+const maxTorrentFileSize = 64 << 20
+
+// DefaultTorrentCacheTTL defines the default ttl of cached torrent files. Metadata never changes so it's long
+const DefaultTorrentCacheTTL = 30 * 24 * time.Hour
+
+// FetchTorrentFromSources downloads the torrent file from the xs / as http(s) urls of the magnet link and verifies it against
+// the info hash. Exact sources are tried before acceptable sources, sources of other schemes (dchub, gnutella, ...) are skipped.
+// A "urn:btih:" source is expanded to the torrent cache urls configured by WithTorrentFetchCacheURLsOption.
+func FetchTorrentFromSources(ctx context.Context, l *MagnetLink, opts ...TorrentFetchOption) (*TorrentFile, error) {
+	var option torrentFetchOption
+	for _, opt := range opts {
+		if opt != nil {
+			opt.set(&option)
+		}
+	}
+	client := option.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	torrentMagnetLink, err := l.AsTorrent()
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache
+	if option.Cache != nil {
+		for _, infoHash := range torrentMagnetLink.InfoHashs {
+			data, ok := option.Cache.Get(torrentCacheKey(infoHash))
+			if !ok {
+				continue
+			}
+			if t, err := ParseTorrentFile(data); err == nil && shareInfoHash(torrentMagnetLink.InfoHashs, t.InfoHashs) {
+				return t, nil
+			}
+		}
+	}
+
+	var (
+		sources []string
+		seen    = make(map[string]bool)
+	)
+	for _, source := range append(append([]string(nil), l.Xs...), l.As...) {
+		for _, s := range expandTorrentSource(source, torrentMagnetLink.InfoHashs, option.CacheURLs) {
+			if !seen[s] {
+				seen[s] = true
+				sources = append(sources, s)
+			}
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("%w: No http source", ErrFetchTorrent)
+	}
+
+	var lastErr error
+	for _, source := range sources {
+		t, err := fetchTorrentFile(ctx, client, source)
+		if err != nil {
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if !shareInfoHash(torrentMagnetLink.InfoHashs, t.InfoHashs) {
+			lastErr = fmt.Errorf("%w: [%v]", ErrInfoHashMismatch, source)
+			continue
+		}
+		if option.Cache != nil {
+			for _, infoHash := range t.InfoHashs {
+				// Failing to cache is not fatal
+				option.Cache.Set(torrentCacheKey(infoHash), t.Raw, DefaultTorrentCacheTTL)
+			}
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("%w: %v", ErrFetchTorrent, lastErr)
+}
+
+func torrentCacheKey(infoHash HashValue) string {
+	return "torrent:" + infoHash.Type + ":" + hex.EncodeToString(infoHash.Value)
+}
+
+func expandTorrentSource(source string, infoHashs []HashValue, cacheURLs []string) []string {
+	if urn, err := ParseUrn(source); err == nil {
+		if nid := strings.ToLower(urn.Nid); nid != "btih" && nid != "btmh" {
+			return nil
+		}
+		var urls []string
+		for _, infoHash := range infoHashs {
+			h := hex.EncodeToString(infoHash.Value)
+			for _, cacheURL := range cacheURLs {
+				cacheURL = strings.ReplaceAll(cacheURL, "{infohash}", h)
+				cacheURL = strings.ReplaceAll(cacheURL, "{INFOHASH}", strings.ToUpper(h))
+				urls = append(urls, cacheURL)
+			}
+		}
+		return urls
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return nil
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return []string{source}
+	}
+	return nil
+}
+
+func fetchTorrentFile(ctx context.Context, client *http.Client, source string) (*TorrentFile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status [%v] [%v]", resp.Status, source)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTorrentFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxTorrentFileSize {
+		return nil, fmt.Errorf("Torrent file too large [%v]", source)
+	}
+	return ParseTorrentFile(data)
+}
+
+//
+//
+//
+// Options
+//
+//
+//
+
+// TorrentFetchOption defines the torrent fetch option
+type TorrentFetchOption interface {
+	set(option *torrentFetchOption)
+}
+type torrentFetchOption struct {
+	Client    *http.Client
+	CacheURLs []string
+	Cache     Cache
+}
+type torrentFetchOptionSetterFunc func(option *torrentFetchOption)
+type torrentFetchOptionSetter struct {
+	f torrentFetchOptionSetterFunc
+}
+
+func (setter torrentFetchOptionSetter) set(option *torrentFetchOption) {
+	setter.f(option)
+}
+
+// WithTorrentFetchHTTPClientOption defines the http client option
+func WithTorrentFetchHTTPClientOption(client *http.Client) TorrentFetchOption {
+	return torrentFetchOptionSetter{
+		func(option *torrentFetchOption) {
+			option.Client = client
+		},
+	}
+}
+
+// WithTorrentFetchCacheURLsOption defines the torrent cache url templates used for "urn:btih:" sources.
+// "{infohash}" and "{INFOHASH}" are replaced by the lower and upper case hex info hash.
+func WithTorrentFetchCacheURLsOption(urls ...string) TorrentFetchOption {
+	return torrentFetchOptionSetter{
+		func(option *torrentFetchOption) {
+			option.CacheURLs = urls
+		},
+	}
+}
+
+// WithTorrentFetchCacheOption caches fetched torrent files by info hash
+func WithTorrentFetchCacheOption(cache Cache) TorrentFetchOption {
+	return torrentFetchOptionSetter{
+		func(option *torrentFetchOption) {
+			option.Cache = cache
+		},
+	}
+}
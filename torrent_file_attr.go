@@ -0,0 +1,102 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:36:37
+//
+// File Name: torrent_file_attr.go
+// Description:
+//
+//	Apply file attributes (BEP 47) to the downloaded files
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0047.html
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors
+var (
+	ErrUnsafeSymlink = errors.New("Unsafe symlink")
+)
+
+// ApplyTorrentFileAttrs applies the attributes of torrent files in dir (the parent of the torrent name):
+// executable files are made executable and symlinks are created. Symlinks pointing out of the torrent root are refused.
+// Hidden attributes are not applied, they are the dot-prefixed names on unix.
+func ApplyTorrentFileAttrs(t *TorrentFile, dir string) error {
+	if !filepath.IsLocal(t.Info.Name) {
+		return fmt.Errorf("%w: [%v]", ErrUnsafeTorrentName, t.Info.Name)
+	}
+	if len(t.Info.Files) == 0 {
+		if strings.ContainsRune(t.Info.Attr, 'x') {
+			return makeExecutable(filepath.Join(dir, t.Info.Name))
+		}
+		return nil
+	}
+
+	root := filepath.Join(dir, t.Info.Name)
+	var errs []error
+	for _, file := range t.Info.Files {
+		rel := filepath.Join(file.Path...)
+		if !filepath.IsLocal(rel) {
+			errs = append(errs, fmt.Errorf("%w: [%v]", ErrUnsafeTorrentName, rel))
+			continue
+		}
+		p := filepath.Join(root, rel)
+		if file.IsSymlink() {
+			if err := createTorrentSymlink(root, rel, filepath.Join(file.SymlinkPath...)); err != nil {
+				errs = append(errs, err)
+			}
+		} else if file.IsExecutable() && !file.IsPadding() {
+			if err := makeExecutable(p); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createTorrentSymlink creates the symlink rel -> target, both are relative to root
+func createTorrentSymlink(root, rel, target string) error {
+	if !filepath.IsLocal(target) {
+		return fmt.Errorf("%w: [%v] -> [%v]", ErrUnsafeSymlink, rel, target)
+	}
+	// Link by relative path so the content can be moved
+	linkTarget, err := filepath.Rel(filepath.Dir(filepath.Join(root, rel)), filepath.Join(root, target))
+	if err != nil {
+		return fmt.Errorf("%w: [%v] -> [%v] %v", ErrUnsafeSymlink, rel, target, err)
+	}
+	p := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	if current, err := os.Readlink(p); err == nil && current == linkTarget {
+		return nil
+	}
+	if info, err := os.Lstat(p); err == nil && info.Mode().IsRegular() && info.Size() == 0 {
+		// An empty placeholder, e.g. created by a client without symlink support
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(linkTarget, p)
+}
+
+func makeExecutable(p string) error {
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	// Add x where r is set, like chmod +x respecting the read permissions
+	mode := info.Mode().Perm()
+	return os.Chmod(p, mode|(mode&0444)>>2)
+}
@@ -0,0 +1,171 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:23:37
+//
+// File Name: torrent_similarity.go
+// Description:
+//
+//	Compare torrents (or a torrent and a directory) to detect re-packs and cross-seeding opportunities
+//
+
+package transmission
+
+import (
+	"crypto/sha1"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// TorrentFileMatch defines a matched file
+type TorrentFileMatch struct {
+	Path      string // The path in torrent, prefixed by the torrent name for multiple files torrent
+	OtherPath string // The path in the other torrent, or the file path in the directory
+	Length    int64
+	SameName  bool // The base names are equal
+}
+
+// TorrentSimilarity defines the comparison result
+type TorrentSimilarity struct {
+	Score        float64 // Matched bytes / total bytes of the torrent, in [0, 1]
+	MatchedBytes int64
+	MatchedFiles []TorrentFileMatch
+	SharedPieces int     // The number of identical piece hashes. Only counted when the piece lengths are equal
+	PieceScore   float64 // SharedPieces / piece count of the torrent
+}
+
+type similarityFile struct {
+	Path    string
+	Length  int64
+	Padding bool
+}
+
+// torrentFilePaths returns the paths (prefixed by name for multiple files torrent) and lengths of files
+func torrentFilePaths(t *TorrentFile) []similarityFile {
+	if len(t.Info.Files) == 0 {
+		return []similarityFile{{t.Info.Name, t.Info.Length, false}}
+	}
+	files := make([]similarityFile, 0, len(t.Info.Files))
+	for _, file := range t.Info.Files {
+		files = append(files, similarityFile{path.Join(append([]string{t.Info.Name}, file.Path...)...), file.Length, file.IsPadding()})
+	}
+	return files
+}
+
+// CompareTorrents compares torrent a to torrent b by file names, sizes and piece hashes.
+// The score is relative to a, i.e. how much of a's content can be found in b.
+func CompareTorrents(a, b *TorrentFile) TorrentSimilarity {
+	similarity := compareFiles(torrentFilePaths(a), torrentFilePaths(b))
+
+	// Pieces
+	if a.Info.PieceLength == b.Info.PieceLength && len(a.Info.Pieces) > 0 && len(b.Info.Pieces) > 0 {
+		pieces := make(map[string]bool, len(b.Info.Pieces)/sha1.Size)
+		for i := 0; i+sha1.Size <= len(b.Info.Pieces); i += sha1.Size {
+			pieces[string(b.Info.Pieces[i:i+sha1.Size])] = true
+		}
+		for i := 0; i+sha1.Size <= len(a.Info.Pieces); i += sha1.Size {
+			if pieces[string(a.Info.Pieces[i:i+sha1.Size])] {
+				similarity.SharedPieces++
+			}
+		}
+		similarity.PieceScore = float64(similarity.SharedPieces) / float64(a.PieceCount())
+	}
+	return similarity
+}
+
+// CompareTorrentWithDir compares the torrent with the files under dir (recursively) by file names and sizes.
+// OtherPath of the matched files are the paths of files on disk.
+func CompareTorrentWithDir(t *TorrentFile, dir string) (TorrentSimilarity, error) {
+	var files []similarityFile
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, similarityFile{p, info.Size(), false})
+		return nil
+	})
+	if err != nil {
+		return TorrentSimilarity{}, err
+	}
+	return compareFiles(torrentFilePaths(t), files), nil
+}
+
+// compareFiles matches files by size and base name first, then by size only when the size is unique on both sides.
+// Padding files are ignored.
+func compareFiles(files, others []similarityFile) TorrentSimilarity {
+	files, others = withoutPaddingFiles(files), withoutPaddingFiles(others)
+	var total int64
+	for _, file := range files {
+		total += file.Length
+	}
+	var (
+		similarity TorrentSimilarity
+		matched    = make([]bool, len(files))
+		used       = make([]bool, len(others))
+		bySize     = make(map[int64][]int)
+		sizeCount  = make(map[int64]int)
+	)
+	for i, other := range others {
+		bySize[other.Length] = append(bySize[other.Length], i)
+	}
+	for _, file := range files {
+		sizeCount[file.Length]++
+	}
+
+	match := func(i, j int, sameName bool) {
+		matched[i] = true
+		used[j] = true
+		similarity.MatchedBytes += files[i].Length
+		similarity.MatchedFiles = append(similarity.MatchedFiles, TorrentFileMatch{
+			Path:      files[i].Path,
+			OtherPath: others[j].Path,
+			Length:    files[i].Length,
+			SameName:  sameName,
+		})
+	}
+	// Same size and base name
+	for i, file := range files {
+		for _, j := range bySize[file.Length] {
+			if !used[j] && path.Base(filepath.ToSlash(others[j].Path)) == path.Base(file.Path) {
+				match(i, j, true)
+				break
+			}
+		}
+	}
+	// Unique size
+	for i, file := range files {
+		if matched[i] || sizeCount[file.Length] != 1 || len(bySize[file.Length]) != 1 {
+			continue
+		}
+		if j := bySize[file.Length][0]; !used[j] {
+			match(i, j, false)
+		}
+	}
+
+	sort.Slice(similarity.MatchedFiles, func(i, j int) bool {
+		return similarity.MatchedFiles[i].Path < similarity.MatchedFiles[j].Path
+	})
+	if total > 0 {
+		similarity.Score = float64(similarity.MatchedBytes) / float64(total)
+	} else if len(files) > 0 && len(similarity.MatchedFiles) == len(files) {
+		similarity.Score = 1
+	}
+	return similarity
+}
+
+func withoutPaddingFiles(files []similarityFile) []similarityFile {
+	result := make([]similarityFile, 0, len(files))
+	for _, file := range files {
+		if !file.Padding {
+			result = append(result, file)
+		}
+	}
+	return result
+}
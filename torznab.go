@@ -0,0 +1,177 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:41:38
+//
+// File Name: torznab.go
+// Description:
+//
+//	Parse torznab / newznab results of indexer aggregators (e.g. Prowlarr, Jackett)
+//
+//	Reference:
+//
+//		https://torznab.github.io/spec-1.3-draft/torznab/Specification-v1.3.html
+//
+
+package transmission
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrMalformedTorznab = errors.New("Malformed torznab result")
+)
+
+// TorznabError defines the error returned by indexer
+type TorznabError struct {
+	Code        int
+	Description string
+}
+
+func (e *TorznabError) Error() string {
+	return fmt.Sprintf("Torznab error [%v] %v", e.Code, e.Description)
+}
+
+// TorznabResult defines a result item
+type TorznabResult struct {
+	Title      string
+	GUID       string
+	Comments   string // The details page
+	PubDate    time.Time
+	Size       int64
+	Seeders    int // -1 if unknown
+	Leechers   int // -1 if unknown
+	InfoHash   string
+	MagnetURI  string
+	Magnet     *MagnetLink // Parsed MagnetURI, nil if MagnetURI is empty or invalid
+	TorrentURL string
+	Categories []int
+	Attrs      map[string]string // All torznab / newznab attributes
+}
+
+// torznabDocument defines the response document, either <rss> or <error>
+type torznabDocument struct {
+	XMLName     xml.Name
+	Code        int    `xml:"code,attr"`        // Error only
+	Description string `xml:"description,attr"` // Error only
+	Channel     struct {
+		Items []torznabXMLItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type torznabXMLItem struct {
+	Title     string `xml:"title"`
+	GUID      string `xml:"guid"`
+	Link      string `xml:"link"`
+	Comments  string `xml:"comments"`
+	PubDate   string `xml:"pubDate"`
+	Size      int64  `xml:"size"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+		Type   string `xml:"type,attr"`
+	} `xml:"enclosure"`
+	// Both torznab:attr and newznab:attr
+	Attrs []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"attr"`
+}
+
+// ParseTorznabResults parses the torznab / newznab xml response. An error response is returned as *TorznabError
+func ParseTorznabResults(r io.Reader) ([]TorznabResult, error) {
+	var doc torznabDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTorznab, err)
+	}
+	if doc.XMLName.Local == "error" {
+		return nil, &TorznabError{doc.Code, doc.Description}
+	}
+	results := make([]TorznabResult, 0, len(doc.Channel.Items))
+	for _, item := range doc.Channel.Items {
+		results = append(results, newTorznabResult(item))
+	}
+	return results, nil
+}
+
+func newTorznabResult(item torznabXMLItem) TorznabResult {
+	result := TorznabResult{
+		Title:    item.Title,
+		GUID:     item.GUID,
+		Comments: item.Comments,
+		Size:     item.Size,
+		Seeders:  -1,
+		Leechers: -1,
+		Attrs:    make(map[string]string),
+	}
+	if pubDate, err := time.Parse(time.RFC1123Z, item.PubDate); err == nil {
+		result.PubDate = pubDate
+	} else if pubDate, err := time.Parse(time.RFC1123, item.PubDate); err == nil {
+		result.PubDate = pubDate
+	}
+	if result.Size <= 0 {
+		result.Size = item.Enclosure.Length
+	}
+	for _, link := range []string{item.Link, item.Enclosure.URL} {
+		if strings.HasPrefix(strings.ToLower(link), "magnet:") {
+			result.MagnetURI = link
+		} else if link != "" && result.TorrentURL == "" {
+			result.TorrentURL = link
+		}
+	}
+
+	peers := -1
+	for _, attr := range item.Attrs {
+		name := strings.ToLower(attr.Name)
+		result.Attrs[name] = attr.Value
+		switch name {
+		case "seeders":
+			result.Seeders = atoiOr(attr.Value, -1)
+		case "leechers":
+			result.Leechers = atoiOr(attr.Value, -1)
+		case "peers":
+			peers = atoiOr(attr.Value, -1)
+		case "infohash":
+			result.InfoHash = strings.ToLower(attr.Value)
+		case "magneturl":
+			result.MagnetURI = attr.Value
+		case "size":
+			if size, err := strconv.ParseInt(attr.Value, 10, 64); err == nil && result.Size <= 0 {
+				result.Size = size
+			}
+		case "category":
+			if category, err := strconv.Atoi(attr.Value); err == nil {
+				result.Categories = append(result.Categories, category)
+			}
+		}
+	}
+	// Peers includes seeders
+	if result.Leechers < 0 && peers >= 0 && result.Seeders >= 0 && peers >= result.Seeders {
+		result.Leechers = peers - result.Seeders
+	}
+
+	if result.MagnetURI != "" {
+		if l, err := ParseMagnetLink(result.MagnetURI); err == nil {
+			result.Magnet = l
+			if t, err := l.AsTorrent(); err == nil && result.InfoHash == "" {
+				result.InfoHash = hex.EncodeToString(t.InfoHashs[0].Value)
+			}
+		}
+	}
+	return result
+}
+
+func atoiOr(s string, defaultValue int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
@@ -0,0 +1,361 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:37:48
+//
+// File Name: tracker_announce.go
+// Description:
+//
+//	Announce scheduling of a tracker: events, intervals, failure backoff and tracker id
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0003.html
+//		https://www.bittorrent.org/beps/bep_0007.html
+//		https://www.bittorrent.org/beps/bep_0023.html
+//		https://wiki.theory.org/BitTorrentSpecification#Tracker_HTTP.2FHTTPS_Protocol
+//
+
+package transmission
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Announce event
+const (
+	AnnounceEventNone      = ""
+	AnnounceEventStarted   = "started"
+	AnnounceEventCompleted = "completed"
+	AnnounceEventStopped   = "stopped"
+)
+
+// Announce defaults
+const (
+	DefaultAnnounceInterval     = 30 * time.Minute
+	DefaultAnnounceRetryDelay   = 15 * time.Second
+	DefaultAnnounceMaxRetryWait = 30 * time.Minute
+)
+
+// Errors
+var (
+	ErrTrackerFailure = errors.New("Tracker failure")
+)
+
+// TrackerAnnounceResponse defines the announce response of tracker
+type TrackerAnnounceResponse struct {
+	FailureReason  string
+	WarningMessage string // The announce succeeded, but the message should be shown to the user
+	Interval       time.Duration
+	MinInterval    time.Duration // Announces are never sent more often than this, except stopped and completed
+	TrackerID      string        // Sent back in the following announces
+	Complete       int           // Seeders, -1 if unknown
+	Incomplete     int           // Leechers, -1 if unknown
+	Peers          []TrackerPeer
+	ExternalIP     net.IP // The ip of this client seen by the tracker
+}
+
+// TrackerPeer defines a peer returned by tracker
+type TrackerPeer struct {
+	IP     net.IP
+	Port   int
+	PeerID []byte // Only in the dictionary model
+}
+
+// Addr returns the address of peer, "ip:port"
+func (p TrackerPeer) Addr() string {
+	return net.JoinHostPort(p.IP.String(), strconv.Itoa(p.Port))
+}
+
+// TrackerFailureError defines the failure reason returned by tracker
+type TrackerFailureError struct {
+	Reason string
+}
+
+func (e *TrackerFailureError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrTrackerFailure, e.Reason)
+}
+
+// Unwrap returns ErrTrackerFailure
+func (e *TrackerFailureError) Unwrap() error {
+	return ErrTrackerFailure
+}
+
+// ParseTrackerAnnounceResponse parses the bencoded announce response of http tracker. Both compact (peers, peers6) and
+// dictionary model peers are supported. If the tracker returns a failure reason, the response is returned with a
+// *TrackerFailureError.
+func ParseTrackerAnnounceResponse(data []byte) (*TrackerAnnounceResponse, error) {
+	value, err := DecodeBencode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTrackerProtocol, err)
+	}
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: Not a bencoded dictionary", ErrTrackerProtocol)
+	}
+
+	resp := TrackerAnnounceResponse{
+		FailureReason:  bencodeDictString(dict, "failure reason"),
+		WarningMessage: bencodeDictString(dict, "warning message"),
+		Interval:       time.Duration(bencodeDictInt(dict, "interval")) * time.Second,
+		MinInterval:    time.Duration(bencodeDictInt(dict, "min interval")) * time.Second,
+		TrackerID:      bencodeDictString(dict, "tracker id"),
+		Complete:       -1,
+		Incomplete:     -1,
+	}
+	if resp.FailureReason != "" {
+		return &resp, &TrackerFailureError{resp.FailureReason}
+	}
+	if n, ok := dict["complete"].(int64); ok {
+		resp.Complete = int(n)
+	}
+	if n, ok := dict["incomplete"].(int64); ok {
+		resp.Incomplete = int(n)
+	}
+	if ip := bencodeDictString(dict, "external ip"); len(ip) == net.IPv4len || len(ip) == net.IPv6len {
+		resp.ExternalIP = net.IP(ip)
+	}
+
+	switch peers := dict["peers"].(type) {
+	case string:
+		resp.Peers, err = parseCompactPeers(peers, net.IPv4len)
+	case []interface{}:
+		resp.Peers, err = parseDictPeers(peers)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if peers6, ok := dict["peers6"].(string); ok {
+		peers, err := parseCompactPeers(peers6, net.IPv6len)
+		if err != nil {
+			return nil, err
+		}
+		resp.Peers = append(resp.Peers, peers...)
+	}
+	return &resp, nil
+}
+
+// parseCompactPeers parses compact peers (BEP 23, BEP 7), each is ip followed by a big endian port
+func parseCompactPeers(peers string, ipLen int) ([]TrackerPeer, error) {
+	size := ipLen + 2
+	if len(peers)%size != 0 {
+		return nil, fmt.Errorf("%w: Invalid compact peers", ErrTrackerProtocol)
+	}
+	result := make([]TrackerPeer, 0, len(peers)/size)
+	for i := 0; i < len(peers); i += size {
+		result = append(result, TrackerPeer{
+			IP:   net.IP(peers[i : i+ipLen]),
+			Port: int(binary.BigEndian.Uint16([]byte(peers[i+ipLen : i+size]))),
+		})
+	}
+	return result, nil
+}
+
+func parseDictPeers(peers []interface{}) ([]TrackerPeer, error) {
+	result := make([]TrackerPeer, 0, len(peers))
+	for _, peer := range peers {
+		dict, ok := peer.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: Invalid peer", ErrTrackerProtocol)
+		}
+		ip := net.ParseIP(bencodeDictString(dict, "ip"))
+		port := bencodeDictInt(dict, "port")
+		if ip == nil || port <= 0 || port > 65535 {
+			// Skip peers by hostname or invalid
+			continue
+		}
+		p := TrackerPeer{IP: ip, Port: int(port)}
+		if peerID := bencodeDictString(dict, "peer id"); peerID != "" {
+			p.PeerID = []byte(peerID)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// TrackerAnnounce defines the announce to send
+type TrackerAnnounce struct {
+	Event     string // AnnounceEventXXX
+	TrackerID string
+}
+
+// TrackerAnnouncer schedules the announces of a torrent to a tracker. It doesn't send requests, the caller asks Next
+// for the announce to send and reports the result by Succeeded or Failed. It's safe for concurrent use.
+type TrackerAnnouncer struct {
+	URL string
+
+	mutex        sync.Mutex
+	running      bool // Started by Start and not stopped
+	started      bool // The started event is accepted by tracker
+	completed    bool // Completed by Complete
+	completeTodo bool // The completed event is not sent yet
+	stopTodo     bool // The stopped event is not sent yet
+	interval     time.Duration
+	minInterval  time.Duration
+	trackerID    string
+	last         time.Time // The last successful announce
+	next         time.Time
+	failures     int
+	status       TrackerStatus
+}
+
+// NewTrackerAnnouncer creates a new TrackerAnnouncer
+func NewTrackerAnnouncer(url string) *TrackerAnnouncer {
+	return &TrackerAnnouncer{URL: url}
+}
+
+// Start starts announcing, the started event is sent immediately
+func (a *TrackerAnnouncer) Start(now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.running {
+		return
+	}
+	a.running, a.stopTodo, a.failures = true, false, 0
+	a.next = now
+}
+
+// Complete sends the completed event (once) when the download is completed
+func (a *TrackerAnnouncer) Complete(now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.completed {
+		return
+	}
+	a.completed, a.completeTodo = true, true
+	if a.running && a.started {
+		a.next = now
+	}
+}
+
+// Stop stops announcing, the stopped event is sent immediately if the tracker knows the torrent is started
+func (a *TrackerAnnouncer) Stop(now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if !a.running {
+		return
+	}
+	a.running, a.failures = false, 0
+	if a.started {
+		a.stopTodo = true
+		a.next = now
+	}
+}
+
+// Next returns the announce to send and the time to send it. Returns false if nothing is to be sent.
+func (a *TrackerAnnouncer) Next() (TrackerAnnounce, time.Time, bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	announce := TrackerAnnounce{TrackerID: a.trackerID}
+	switch {
+	case a.stopTodo:
+		announce.Event = AnnounceEventStopped
+	case !a.running:
+		return TrackerAnnounce{}, time.Time{}, false
+	case !a.started:
+		announce.Event = AnnounceEventStarted
+	case a.completeTodo:
+		announce.Event = AnnounceEventCompleted
+	default:
+		// Regular announce, respect min interval
+		if !a.last.IsZero() && a.minInterval > 0 && a.next.Before(a.last.Add(a.minInterval)) {
+			return announce, a.last.Add(a.minInterval), true
+		}
+	}
+	return announce, a.next, true
+}
+
+// Succeeded reports the announce of event is accepted by tracker
+func (a *TrackerAnnouncer) Succeeded(now time.Time, event string, resp TrackerAnnounceResponse) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if resp.TrackerID != "" {
+		a.trackerID = resp.TrackerID
+	}
+	a.interval = resp.Interval
+	if a.interval <= 0 {
+		a.interval = DefaultAnnounceInterval
+	}
+	a.minInterval = resp.MinInterval
+	a.last, a.failures = now, 0
+	a.status.recordSuccess(now, resp)
+	switch event {
+	case AnnounceEventStarted:
+		a.started = true
+	case AnnounceEventCompleted:
+		a.completeTodo = false
+	case AnnounceEventStopped:
+		a.started, a.stopTodo = false, false
+	}
+	a.next = now.Add(a.interval)
+	switch {
+	case event == AnnounceEventStarted && !a.running:
+		// Stopped while the started event was in flight
+		a.stopTodo = true
+		a.next = now
+	case a.running && a.started && a.completeTodo:
+		// Completed while the started event was in flight
+		a.next = now
+	}
+}
+
+// Failed reports the announce of event is failed, the announce is retried with exponential backoff.
+// A failed stopped event is not retried, the tracker will drop the peer by timeout.
+func (a *TrackerAnnouncer) Failed(now time.Time, event string) {
+	a.FailedWithError(now, event, nil)
+}
+
+// FailedWithError is Failed with the error of announce, which is classified in Status
+func (a *TrackerAnnouncer) FailedWithError(now time.Time, event string, err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.status.recordFailure(now, err)
+	if event == AnnounceEventStopped {
+		a.started, a.stopTodo = false, false
+		return
+	}
+	a.failures++
+	delay := DefaultAnnounceRetryDelay << min(a.failures-1, 16)
+	a.next = now.Add(min(delay, DefaultAnnounceMaxRetryWait))
+}
+
+// TrackerID returns the tracker id sent by tracker, which should be persisted with the torrent
+func (a *TrackerAnnouncer) TrackerID() string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.trackerID
+}
+
+// SetTrackerID restores the persisted tracker id
+func (a *TrackerAnnouncer) SetTrackerID(trackerID string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.trackerID = trackerID
+}
+
+// Failures returns the number of consecutive failures
+func (a *TrackerAnnouncer) Failures() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.failures
+}
+
+// Status returns the announce statistics
+func (a *TrackerAnnouncer) Status() TrackerStatus {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	status := a.status
+	status.URL, status.ConsecutiveFailures, status.Running = a.URL, a.failures, a.running
+	if a.running || a.stopTodo {
+		status.NextAnnounceTime = a.next
+		if !a.last.IsZero() && a.minInterval > 0 && a.running && a.started && !a.completeTodo && !a.stopTodo &&
+			a.next.Before(a.last.Add(a.minInterval)) {
+			status.NextAnnounceTime = a.last.Add(a.minInterval)
+		}
+	}
+	return status
+}
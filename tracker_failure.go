@@ -0,0 +1,96 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:00:51
+//
+// File Name: tracker_failure.go
+// Description:
+//
+//	Classify the failure reasons returned by trackers, so automation can decide whether to remove or retry torrents
+//
+
+package transmission
+
+import (
+	"errors"
+	"regexp"
+)
+
+// TrackerFailureType defines the type of tracker failure reason
+type TrackerFailureType string
+
+// Tracker failure type
+const (
+	TrackerFailureUnknown        TrackerFailureType = ""
+	TrackerFailureUnregistered   TrackerFailureType = "unregistered"    // The torrent is not (or no longer) registered
+	TrackerFailurePasskeyInvalid TrackerFailureType = "passkey-invalid" // The passkey is invalid or the user is disabled
+	TrackerFailureRateLimited    TrackerFailureType = "rate-limited"    // Announced too often
+	TrackerFailureClientBanned   TrackerFailureType = "client-banned"   // The client (or version) is not allowed
+	TrackerFailureOverloaded     TrackerFailureType = "overloaded"      // The tracker is down, overloaded or in maintenance
+	TrackerFailureLimitReached   TrackerFailureType = "limit-reached"   // Too many torrents, ips or a bad ratio
+)
+
+// Retryable checks if announcing again later may succeed. Unknown reasons are retryable.
+func (t TrackerFailureType) Retryable() bool {
+	switch t {
+	case TrackerFailureUnregistered, TrackerFailurePasskeyInvalid, TrackerFailureClientBanned:
+		return false
+	}
+	return true
+}
+
+// trackerFailurePatterns defines the patterns (case insensitive) of failure reasons of each type, matched in order.
+// The unregistered wordings go first as some of them look like the others, e.g. opentracker's "Requested download
+// is not authorized for use with this tracker" and "Torrent not in database".
+var trackerFailurePatterns = []struct {
+	Type    TrackerFailureType
+	Pattern *regexp.Regexp
+}{
+	{TrackerFailureUnregistered, regexp.MustCompile(`(?i)\bunregistered torrent\b|\btorrent (is )?not registered\b|` +
+		`\bnot registered with this tracker\b|\b(requested )?torrent (was )?not found\b|\bunknown torrent\b|` +
+		`\btorrent does not exist\b|\binfo_?hash not found\b|\btorrent not in (the )?database\b|` +
+		`\bnot authori[sz]ed for use with this tracker\b|\btorrent has been (deleted|nuked|removed)\b|` +
+		`\btrumped\b|\bduped?\b`)},
+	{TrackerFailureClientBanned, regexp.MustCompile(`(?i)\bclient (is )?(banned|blacklisted|not allowed|not whitelisted)\b|` +
+		`\bbanned client\b|\bnot whitelisted\b|\bunsupported client\b|\bclient version\b`)},
+	{TrackerFailurePasskeyInvalid, regexp.MustCompile(`(?i)\b(passkey|authkey|torrent_pass)\b|\b(invalid|unknown) user\b|` +
+		`\buser not found\b|\baccount (is |has been )?disabled\b|\b(not authori[sz]ed|unauthori[sz]ed)\b|` +
+		`\baccess denied\b`)},
+	{TrackerFailureRateLimited, regexp.MustCompile(`(?i)\brate limit(ed)?\b|\btoo many requests\b|` +
+		`\bannounc(e|ed|ing) too (fast|often|frequently)\b|\btoo frequent\b|\bmin(imum)? interval\b|\bslow down\b`)},
+	{TrackerFailureLimitReached, regexp.MustCompile(`(?i)\btoo many (torrents|ips|ip addresses|locations|connections|` +
+		`downloads|seeds|leeches|peers|clients)\b|\bmax(imum)? (number of )?(torrents|ips|locations|connections|downloads|` +
+		`slots|clients)\b|\b(ip|torrent|download|slot|connection|location)s? limit\b|\blimit (reached|exceeded)\b|` +
+		`\b(low|bad|poor) ratio\b|\bratio (is )?too low\b`)},
+	{TrackerFailureOverloaded, regexp.MustCompile(`(?i)\bmaintenance\b|\boverloaded\b|\btry again later\b|` +
+		`\btemporarily\b|\bserver (is )?busy\b|\bdatabase (error|unavailable|is down|connection)\b|` +
+		`\binternal (server )?error\b|\btimed out\b`)},
+}
+
+// ParseTrackerFailureReason classifies the failure reason of tracker
+func ParseTrackerFailureReason(reason string) TrackerFailureType {
+	for _, item := range trackerFailurePatterns {
+		if item.Pattern.MatchString(reason) {
+			return item.Type
+		}
+	}
+	return TrackerFailureUnknown
+}
+
+// Type returns the type of the failure reason
+func (e *TrackerFailureError) Type() TrackerFailureType {
+	return ParseTrackerFailureReason(e.Reason)
+}
+
+// Retryable checks if announcing again later may succeed
+func (e *TrackerFailureError) Retryable() bool {
+	return e.Type().Retryable()
+}
+
+// IsTrackerErrorRetryable checks if the announce error may succeed later. The failure reasons are classified by
+// ParseTrackerFailureReason, other errors (network, timeout, ...) are retryable.
+func IsTrackerErrorRetryable(err error) bool {
+	var failureErr *TrackerFailureError
+	if errors.As(err, &failureErr) {
+		return failureErr.Retryable()
+	}
+	return true
+}
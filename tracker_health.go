@@ -0,0 +1,281 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:15:08
+//
+// File Name: tracker_health.go
+// Description:
+//
+//	Probe trackers with a lightweight announce / connect request
+//
+//	Reference:
+//
+//		https://www.bittorrent.org/beps/bep_0003.html
+//		https://www.bittorrent.org/beps/bep_0015.html
+//
+
+package transmission
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors
+var (
+	ErrTrackerProtocol = errors.New("Tracker protocol error")
+)
+
+// DefaultTrackerCheckTimeout defines the default timeout of probing a single tracker
+const DefaultTrackerCheckTimeout = 15 * time.Second
+
+// Tracker error class
+const (
+	TrackerErrorNone        = ""
+	TrackerErrorInvalidURL  = "invalid-url"
+	TrackerErrorUnsupported = "unsupported-scheme"
+	TrackerErrorDNS         = "dns"
+	TrackerErrorTimeout     = "timeout"
+	TrackerErrorRefused     = "refused"
+	TrackerErrorTLS         = "tls"
+	TrackerErrorHTTPStatus  = "http-status"
+	TrackerErrorProtocol    = "protocol"
+	TrackerErrorNetwork     = "network"
+	TrackerErrorFailure     = "failure-reason" // The tracker returned a failure reason
+)
+
+// TrackerHealth defines the probe result of a tracker
+type TrackerHealth struct {
+	URL        string
+	Alive      bool
+	Latency    time.Duration
+	ErrorClass string // One of TrackerErrorXXX
+	Err        error
+}
+
+// CheckTrackers probes the trackers concurrently, the results are in the same order of urls
+func CheckTrackers(ctx context.Context, urls []string, opts ...TrackerCheckOption) []TrackerHealth {
+	results := make([]TrackerHealth, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			results[i] = CheckTracker(ctx, u, opts...)
+		}(i, u)
+	}
+	wg.Wait()
+	return results
+}
+
+// CheckTracker probes a single tracker
+func CheckTracker(ctx context.Context, tracker string, opts ...TrackerCheckOption) TrackerHealth {
+	var option trackerCheckOption
+	for _, opt := range opts {
+		if opt != nil {
+			opt.set(&option)
+		}
+	}
+	client := option.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	health := TrackerHealth{URL: tracker}
+	u, err := url.Parse(tracker)
+	if err != nil || u.Host == "" {
+		if err == nil {
+			err = errors.New("Missing host")
+		}
+		health.ErrorClass = TrackerErrorInvalidURL
+		health.Err = err
+		return health
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultTrackerCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		err = probeHTTPTracker(ctx, client, u)
+	case "udp":
+		err = probeUDPTracker(ctx, u)
+	default:
+		health.ErrorClass = TrackerErrorUnsupported
+		health.Err = fmt.Errorf("Unsupported scheme [%v]", u.Scheme)
+		return health
+	}
+	health.Latency = time.Since(start)
+	if err != nil {
+		health.ErrorClass = classifyTrackerError(err)
+		health.Err = err
+		return health
+	}
+	health.Alive = true
+	return health
+}
+
+func probeHTTPTracker(ctx context.Context, client *http.Client, u *url.URL) error {
+	var infoHash, peerID [20]byte
+	if _, err := rand.Read(infoHash[:]); err != nil {
+		return err
+	}
+	if _, err := rand.Read(peerID[:]); err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("info_hash", string(infoHash[:]))
+	q.Set("peer_id", string(peerID[:]))
+	q.Set("port", "6881")
+	q.Set("uploaded", "0")
+	q.Set("downloaded", "0")
+	q.Set("left", "0")
+	q.Set("compact", "1")
+	q.Set("numwant", "0")
+	probeURL := *u
+	probeURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, probeURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &trackerHTTPStatusError{resp.StatusCode}
+	}
+	// Any bencoded response (even a failure reason for the unknown info hash) means the tracker is alive
+	var buf [1]byte
+	if n, _ := resp.Body.Read(buf[:]); n == 0 || buf[0] != 'd' {
+		return fmt.Errorf("%w: Not a bencoded dictionary", ErrTrackerProtocol)
+	}
+	return nil
+}
+
+// udpTrackerProtocolID defines the magic constant of udp tracker protocol
+const udpTrackerProtocolID = 0x41727101980
+
+func probeUDPTracker(ctx context.Context, u *url.URL) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", u.Host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	var transactionID [4]byte
+	if _, err := rand.Read(transactionID[:]); err != nil {
+		return err
+	}
+	var req [16]byte
+	binary.BigEndian.PutUint64(req[0:8], udpTrackerProtocolID)
+	binary.BigEndian.PutUint32(req[8:12], 0) // Action: connect
+	copy(req[12:16], transactionID[:])
+	if _, err := conn.Write(req[:]); err != nil {
+		return err
+	}
+
+	var resp [16]byte
+	n, err := conn.Read(resp[:])
+	if err != nil {
+		return err
+	}
+	if n < 16 {
+		return fmt.Errorf("%w: Short connect response", ErrTrackerProtocol)
+	}
+	if binary.BigEndian.Uint32(resp[0:4]) != 0 {
+		return fmt.Errorf("%w: Unexpected action", ErrTrackerProtocol)
+	}
+	if string(resp[4:8]) != string(transactionID[:]) {
+		return fmt.Errorf("%w: Transaction id mismatch", ErrTrackerProtocol)
+	}
+	return nil
+}
+
+type trackerHTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *trackerHTTPStatusError) Error() string {
+	return fmt.Sprintf("Unexpected http status [%v]", e.StatusCode)
+}
+
+func classifyTrackerError(err error) string {
+	if err == nil {
+		return TrackerErrorNone
+	}
+	var (
+		dnsErr    *net.DNSError
+		netErr    net.Error
+		statusErr *trackerHTTPStatusError
+		certErr   *tls.CertificateVerificationError
+		recordErr tls.RecordHeaderError
+	)
+	switch {
+	case errors.Is(err, ErrTrackerFailure):
+		return TrackerErrorFailure
+	case errors.Is(err, ErrTrackerProtocol):
+		return TrackerErrorProtocol
+	case errors.As(err, &statusErr):
+		return TrackerErrorHTTPStatus
+	case errors.As(err, &dnsErr):
+		return TrackerErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return TrackerErrorTLS
+	case errors.Is(err, context.DeadlineExceeded):
+		return TrackerErrorTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return TrackerErrorTimeout
+	case isConnRefused(err):
+		return TrackerErrorRefused
+	}
+	return TrackerErrorNetwork
+}
+
+//
+//
+//
+// Options
+//
+//
+//
+
+// TrackerCheckOption defines the tracker check option
+type TrackerCheckOption interface {
+	set(option *trackerCheckOption)
+}
+type trackerCheckOption struct {
+	Client *http.Client
+}
+type trackerCheckOptionSetterFunc func(option *trackerCheckOption)
+type trackerCheckOptionSetter struct {
+	f trackerCheckOptionSetterFunc
+}
+
+func (setter trackerCheckOptionSetter) set(option *trackerCheckOption) {
+	setter.f(option)
+}
+
+// WithTrackerCheckHTTPClientOption defines the http client used to probe http trackers, see NewHTTPClient
+func WithTrackerCheckHTTPClientOption(client *http.Client) TrackerCheckOption {
+	return trackerCheckOptionSetter{
+		func(option *trackerCheckOption) {
+			option.Client = client
+		},
+	}
+}
@@ -0,0 +1,314 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:45:13
+//
+// File Name: tracker_idn.go
+// Description:
+//
+//	Internationalized domain names of tracker (and web seed) urls: punycode for dialing, unicode for display.
+//
+//	Labels are only lowercased before encoding, the full nameprep / UTS 46 mapping is not applied.
+//
+//	Reference:
+//
+//		https://www.rfc-editor.org/rfc/rfc3492
+//		https://www.rfc-editor.org/rfc/rfc5890
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Errors
+var (
+	ErrInvalidHost = errors.New("Invalid host")
+)
+
+// Punycode parameters
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+	punycodePrefix      = "xn--"
+)
+
+// ToASCIIHost converts the host to the ascii form for dialing: non-ascii labels are punycode encoded and all labels
+// are lowercased and validated. IP addresses are returned as is.
+func ToASCIIHost(host string) (string, error) {
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return host, nil
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("%w: Empty host", ErrInvalidHost)
+	}
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if !isASCII(label) {
+			encoded, err := punycodeEncode(label)
+			if err != nil {
+				return "", err
+			}
+			label = punycodePrefix + encoded
+		}
+		if err := validateHostLabel(label); err != nil {
+			return "", err
+		}
+		labels[i] = label
+	}
+	host = strings.Join(labels, ".")
+	if len(host) > 253 {
+		return "", fmt.Errorf("%w: Too long", ErrInvalidHost)
+	}
+	return host, nil
+}
+
+// ToUnicodeHost converts the host to the unicode form for display, the punycode labels are decoded
+func ToUnicodeHost(host string) (string, error) {
+	host, err := ToASCIIHost(host)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return host, nil
+	}
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if strings.HasPrefix(label, punycodePrefix) {
+			decoded, err := punycodeDecode(label[len(punycodePrefix):])
+			if err != nil {
+				return "", err
+			}
+			labels[i] = decoded
+		}
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// TrackerURLForDial returns the normalized tracker url (see NormalizeTrackerURL) with ascii host
+func TrackerURLForDial(s string) (string, error) {
+	return convertTrackerURLHost(s, ToASCIIHost)
+}
+
+// TrackerURLForDisplay returns the normalized tracker url (see NormalizeTrackerURL) with unicode host
+func TrackerURLForDisplay(s string) (string, error) {
+	return convertTrackerURLHost(s, ToUnicodeHost)
+}
+
+func convertTrackerURLHost(s string, convert func(host string) (string, error)) (string, error) {
+	normalized, err := NormalizeTrackerURL(s)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformedTrackerURL, err)
+	}
+	host, err := convert(u.Hostname())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformedTrackerURL, err)
+	}
+	if port := u.Port(); port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6
+		host = "[" + host + "]"
+	}
+	// URL.String escapes the unicode host, so the host is joined manually
+	prefix := u.Scheme + "://"
+	if u.User != nil {
+		prefix += u.User.String() + "@"
+	}
+	u.Scheme, u.User, u.Host = "", nil, ""
+	return prefix + host + u.String(), nil
+}
+
+// validateHostLabel checks the ascii label: letters, digits, hyphens and underscores (which are not in LDH rules, but
+// used in practice), and no leading or trailing hyphen
+func validateHostLabel(label string) error {
+	if label == "" || len(label) > 63 {
+		return fmt.Errorf("%w: Bad label length [%v]", ErrInvalidHost, label)
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("%w: Label starts or ends with hyphen [%v]", ErrInvalidHost, label)
+	}
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("%w: Illegal character in label [%v]", ErrInvalidHost, label)
+		}
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+//
+//
+//
+// Punycode
+//
+//
+//
+
+func punycodeAdapt(delta, numPoints int, first bool) int {
+	if first {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / numPoints
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punycodeTMin
+	case k >= bias+punycodeTMax:
+		return punycodeTMax
+	default:
+		return k - bias
+	}
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punycodeDigitValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 26, true
+	case c >= 'a' && c <= 'z':
+		return int(c - 'a'), true
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A'), true
+	}
+	return 0, false
+}
+
+// punycodeEncode encodes the label without the "xn--" prefix
+func punycodeEncode(label string) (string, error) {
+	if !utf8.ValidString(label) {
+		return "", fmt.Errorf("%w: Invalid utf-8", ErrInvalidHost)
+	}
+	runes := []rune(label)
+	var output []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			output = append(output, byte(r))
+		}
+	}
+	basic := len(output)
+	if basic > 0 {
+		output = append(output, '-')
+	}
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+	for h := basic; h < len(runes); {
+		m := math.MaxInt32
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		delta += (m - n) * (h + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punycodeBase; ; k += punycodeBase {
+				t := punycodeThreshold(k, bias)
+				if q < t {
+					break
+				}
+				output = append(output, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+			output = append(output, punycodeDigit(q))
+			bias = punycodeAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return string(output), nil
+}
+
+// punycodeDecode decodes the label without the "xn--" prefix
+func punycodeDecode(s string) (string, error) {
+	var output []rune
+	pos := 0
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		for j := 0; j < i; j++ {
+			if s[j] >= utf8.RuneSelf {
+				return "", fmt.Errorf("%w: Invalid punycode [%v]", ErrInvalidHost, s)
+			}
+			output = append(output, rune(s[j]))
+		}
+		pos = i + 1
+	}
+	n, i, bias := punycodeInitialN, 0, punycodeInitialBias
+	for pos < len(s) {
+		oldi, w := i, 1
+		for k := punycodeBase; ; k += punycodeBase {
+			if pos >= len(s) {
+				return "", fmt.Errorf("%w: Invalid punycode [%v]", ErrInvalidHost, s)
+			}
+			digit, ok := punycodeDigitValue(s[pos])
+			pos++
+			if !ok || digit > (math.MaxInt32-i)/w {
+				return "", fmt.Errorf("%w: Invalid punycode [%v]", ErrInvalidHost, s)
+			}
+			i += digit * w
+			t := punycodeThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			w *= punycodeBase - t
+		}
+		bias = punycodeAdapt(i-oldi, len(output)+1, oldi == 0)
+		n += i / (len(output) + 1)
+		i %= len(output) + 1
+		if n > utf8.MaxRune {
+			return "", fmt.Errorf("%w: Invalid punycode [%v]", ErrInvalidHost, s)
+		}
+		output = append(output, 0)
+		copy(output[i+1:], output[i:])
+		output[i] = rune(n)
+		i++
+	}
+	return string(output), nil
+}
@@ -0,0 +1,194 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:14:26
+//
+// File Name: tracker_list.go
+// Description:
+//
+//	Fetch public tracker lists (trackerslist-style sources)
+//
+//	Reference:
+//
+//		https://github.com/ngosang/trackerslist
+//
+
+package transmission
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Errors
+var (
+	ErrFetchTrackerList = errors.New("Failed to fetch tracker list")
+)
+
+// DefaultTrackerListTTL defines the default ttl of fetched tracker lists
+const DefaultTrackerListTTL = 6 * time.Hour
+
+// ParseTrackerList parses a newline-separated tracker list.
+// Blank lines and lines starting with "#" are ignored, invalid urls are dropped and duplicated urls are removed.
+func ParseTrackerList(r io.Reader) ([]string, error) {
+	var (
+		trackers []string
+		seen     = make(map[string]bool)
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !IsValidTrackerURL(line) {
+			continue
+		}
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		trackers = append(trackers, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return trackers, nil
+}
+
+// IsValidTrackerURL checks if the url is a valid tracker announce url
+func IsValidTrackerURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "udp", "ws", "wss":
+	default:
+		return false
+	}
+	if u.Host == "" {
+		return false
+	}
+	_, err = ToASCIIHost(u.Hostname())
+	return err == nil
+}
+
+// FetchTrackerList fetches and parses the tracker list from source
+func FetchTrackerList(ctx context.Context, client *http.Client, source string) ([]string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFetchTrackerList, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFetchTrackerList, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: Unexpected status [%v]", ErrFetchTrackerList, resp.Status)
+	}
+	trackers, err := ParseTrackerList(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFetchTrackerList, err)
+	}
+	return trackers, nil
+}
+
+// TrackerListFetcher fetches tracker lists and caches them with ttl
+type TrackerListFetcher struct {
+	Client *http.Client
+	TTL    time.Duration
+	Cache  Cache // An in-memory cache is used if nil
+
+	cacheOnce sync.Once
+}
+
+// NewTrackerListFetcher creates a new TrackerListFetcher
+func NewTrackerListFetcher(ttl time.Duration) *TrackerListFetcher {
+	return &TrackerListFetcher{TTL: ttl}
+}
+
+// Fetch fetches the tracker lists from all sources and returns the merged, deduplicated trackers.
+// Cached lists are returned until they expire. An error is returned only if all sources failed.
+func (f *TrackerListFetcher) Fetch(ctx context.Context, sources ...string) ([]string, error) {
+	var (
+		trackers []string
+		seen     = make(map[string]bool)
+		lastErr  error
+		success  bool
+	)
+	for _, source := range sources {
+		list, err := f.fetch(ctx, source)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		success = true
+		for _, tracker := range list {
+			if !seen[tracker] {
+				seen[tracker] = true
+				trackers = append(trackers, tracker)
+			}
+		}
+	}
+	if !success && lastErr != nil {
+		return nil, lastErr
+	}
+	return trackers, nil
+}
+
+func (f *TrackerListFetcher) fetch(ctx context.Context, source string) ([]string, error) {
+	f.cacheOnce.Do(func() {
+		if f.Cache == nil {
+			f.Cache = NewMemoryCache()
+		}
+	})
+	key := "trackerlist:" + source
+	if data, ok := f.Cache.Get(key); ok {
+		return strings.Split(string(data), "\n"), nil
+	}
+
+	trackers, err := FetchTrackerList(ctx, f.Client, source)
+	if err != nil {
+		return nil, err
+	}
+	ttl := f.TTL
+	if ttl <= 0 {
+		ttl = DefaultTrackerListTTL
+	}
+	if len(trackers) > 0 {
+		// Failing to cache is not fatal
+		f.Cache.Set(key, []byte(strings.Join(trackers, "\n")), ttl)
+	}
+	return trackers, nil
+}
+
+// AddTrackers appends the trackers which are not in Tr yet, returns the number of added trackers
+func (l *MagnetLink) AddTrackers(trackers ...string) int {
+	seen := make(map[string]bool, len(l.Tr))
+	for _, tr := range l.Tr {
+		seen[tr] = true
+	}
+	var n int
+	for _, tracker := range trackers {
+		if !seen[tracker] {
+			seen[tracker] = true
+			l.Tr = append(l.Tr, tracker)
+			n++
+		}
+	}
+	if n > 0 {
+		l.ResetCache()
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:17:41
+//
+// File Name: tracker_rewrite.go
+// Description:
+//
+//	Rewrite tracker urls of torrents and magnet links by rules, e.g. swap passkeys, force https or replace dead
+//	tracker domains
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Errors
+var (
+	ErrMalformedTrackerRewriteRule = errors.New("Malformed tracker rewrite rule")
+)
+
+// TrackerRewriteRule defines a rewrite rule. The matches of Pattern in the tracker url are replaced by Replace, which
+// is a regexp template (e.g. "$1" or "${name}", see regexp.Regexp.Expand). The tracker is dropped if Remove is set.
+type TrackerRewriteRule struct {
+	Name    string // For reporting
+	Pattern *regexp.Regexp
+	Replace string
+	Remove  bool
+}
+
+// NewTrackerRewriteRule creates a rule replacing the matches of pattern by replace
+func NewTrackerRewriteRule(pattern, replace string) (TrackerRewriteRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return TrackerRewriteRule{}, fmt.Errorf("%w: [%v] [%v]", ErrMalformedTrackerRewriteRule, pattern, err)
+	}
+	return TrackerRewriteRule{Pattern: re, Replace: replace}, nil
+}
+
+// NewTrackerRemoveRule creates a rule dropping the trackers matching pattern, e.g. of a dead tracker domain
+func NewTrackerRemoveRule(pattern string) (TrackerRewriteRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return TrackerRewriteRule{}, fmt.Errorf("%w: [%v] [%v]", ErrMalformedTrackerRewriteRule, pattern, err)
+	}
+	return TrackerRewriteRule{Pattern: re, Remove: true}, nil
+}
+
+// ForceHTTPSRule returns the rule rewriting http trackers to https
+func ForceHTTPSRule() TrackerRewriteRule {
+	return TrackerRewriteRule{Name: "force-https", Pattern: regexp.MustCompile(`^(?i)http://`), Replace: "https://"}
+}
+
+// PasskeyRewriteRule returns the rule replacing the passkey (16 or more alphanumerics) of the trackers of host, either
+// in the query (passkey=, authkey= or torrent_pass=) or as a path segment (/<passkey>/announce)
+func PasskeyRewriteRule(host, passkey string) TrackerRewriteRule {
+	return TrackerRewriteRule{
+		Name: "passkey",
+		Pattern: regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*://(?i:` + regexp.QuoteMeta(host) + `)(?::\d+)?/` +
+			`(?:[^#]*?[?&](?:passkey|authkey|torrent_pass)=|(?:[^?#]*/)?))[0-9A-Za-z]{16,}((?:[/?#&].*)?)$`),
+		Replace: "${1}" + passkey + "${2}",
+	}
+}
+
+// TrackerRewriter rewrites tracker urls by the rules in order, every matched rule applies to the result of the
+// previous one
+type TrackerRewriter struct {
+	Rules []TrackerRewriteRule
+}
+
+// Rewrite rewrites the tracker url, false is returned if the tracker is removed
+func (r *TrackerRewriter) Rewrite(tracker string) (string, bool) {
+	for _, rule := range r.Rules {
+		if rule.Pattern == nil || !rule.Pattern.MatchString(tracker) {
+			continue
+		}
+		if rule.Remove {
+			return "", false
+		}
+		tracker = rule.Pattern.ReplaceAllString(tracker, rule.Replace)
+	}
+	return tracker, true
+}
+
+// RewriteList rewrites the trackers, the removed and duplicated ones are dropped
+func (r *TrackerRewriter) RewriteList(trackers []string) []string {
+	var (
+		rewritten []string
+		seen      = make(map[string]bool, len(trackers))
+	)
+	for _, tracker := range trackers {
+		tracker, ok := r.Rewrite(tracker)
+		if ok && !seen[tracker] {
+			seen[tracker] = true
+			rewritten = append(rewritten, tracker)
+		}
+	}
+	return rewritten
+}
+
+// RewriteMagnetLink rewrites the trackers (tr) of magnet link, returns true if any is changed
+func (r *TrackerRewriter) RewriteMagnetLink(l *MagnetLink) bool {
+	rewritten := r.RewriteList(l.Tr)
+	if equalStrings(rewritten, l.Tr) {
+		return false
+	}
+	l.Tr = rewritten
+	l.ResetCache()
+	return true
+}
+
+// RewriteTorrent rewrites announce and the tiers of announce list of torrent, empty tiers are dropped. Returns true
+// if any is changed. Raw (the whole torrent file) is not updated, the info dict and the info hashs don't change.
+func (r *TrackerRewriter) RewriteTorrent(t *TorrentFile) bool {
+	var changed bool
+	if t.Announce != "" {
+		announce, ok := r.Rewrite(t.Announce)
+		if !ok {
+			announce = ""
+		}
+		changed = announce != t.Announce
+		t.Announce = announce
+	}
+	var tiers [][]string
+	for _, tier := range t.AnnounceList {
+		rewritten := r.RewriteList(tier)
+		if !equalStrings(rewritten, tier) {
+			changed = true
+		}
+		if len(rewritten) > 0 {
+			tiers = append(tiers, rewritten)
+		}
+	}
+	if changed {
+		t.AnnounceList = tiers
+		if t.Announce == "" && len(tiers) > 0 {
+			// Clients without BEP 12 support only read announce
+			t.Announce = tiers[0][0]
+		}
+	}
+	return changed
+}
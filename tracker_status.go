@@ -0,0 +1,185 @@
+// Author: lipixun
+// Created Time : 2026-10-15 10:00:23
+//
+// File Name: tracker_status.go
+// Description:
+//
+//	Per-tracker announce statistics, and the mapping to the trackerStats fields of transmission rpc
+//
+//	Reference:
+//
+//		https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md
+//
+
+package transmission
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TrackerStatus defines the announce statistics of a tracker, see (*TrackerAnnouncer).Status
+type TrackerStatus struct {
+	URL                   string
+	Running               bool
+	Announces             int64 // Reported announces
+	Successes             int64
+	Failures              int64
+	ConsecutiveFailures   int
+	LastAnnounceTime      time.Time
+	LastAnnounceSucceeded bool
+	LastAnnounceResult    string             // "Success", the failure reason of tracker, or the error message
+	LastErrorClass        string             // One of TrackerErrorXXX of the last failed announce
+	LastFailureType       TrackerFailureType // The type of the failure reason of the last failed announce
+	LastWarning           string             // The warning message of the last successful announce
+	LastPeerCount         int
+	Seeders               int       // -1 if unknown
+	Leechers              int       // -1 if unknown
+	NextAnnounceTime      time.Time // Zero if no announce is scheduled
+}
+
+// SuccessRate returns the rate of successful announces
+func (s TrackerStatus) SuccessRate() float64 {
+	if s.Announces == 0 {
+		return 0
+	}
+	return float64(s.Successes) / float64(s.Announces)
+}
+
+func (s *TrackerStatus) recordSuccess(now time.Time, resp TrackerAnnounceResponse) {
+	if s.Announces == 0 {
+		s.Seeders, s.Leechers = -1, -1
+	}
+	s.Announces++
+	s.Successes++
+	s.LastAnnounceTime, s.LastAnnounceSucceeded, s.LastAnnounceResult = now, true, "Success"
+	s.LastWarning, s.LastPeerCount = resp.WarningMessage, len(resp.Peers)
+	s.Seeders, s.Leechers = resp.Complete, resp.Incomplete
+}
+
+func (s *TrackerStatus) recordFailure(now time.Time, err error) {
+	if s.Announces == 0 {
+		s.Seeders, s.Leechers = -1, -1
+	}
+	s.Announces++
+	s.Failures++
+	s.LastAnnounceTime, s.LastAnnounceSucceeded = now, false
+	s.LastErrorClass, s.LastAnnounceResult, s.LastFailureType = TrackerErrorNetwork, "Announce failed", TrackerFailureUnknown
+	if err != nil {
+		s.LastErrorClass, s.LastAnnounceResult = classifyTrackerError(err), err.Error()
+		var failureErr *TrackerFailureError
+		if errors.As(err, &failureErr) {
+			s.LastAnnounceResult, s.LastFailureType = failureErr.Reason, failureErr.Type()
+		}
+	}
+}
+
+// Transmission tracker announce state
+const (
+	TransmissionTrackerInactive = 0
+	TransmissionTrackerWaiting  = 1
+	TransmissionTrackerQueued   = 2
+	TransmissionTrackerActive   = 3
+)
+
+// TransmissionTrackerStats defines the trackerStats fields of transmission rpc torrent-get. The scrape fields are not
+// tracked by TrackerStatus and left zero (-1 for counts).
+type TransmissionTrackerStats struct {
+	Announce              string `json:"announce"`
+	AnnounceState         int    `json:"announceState"`
+	DownloadCount         int    `json:"downloadCount"`
+	HasAnnounced          bool   `json:"hasAnnounced"`
+	HasScraped            bool   `json:"hasScraped"`
+	Host                  string `json:"host"`
+	ID                    int    `json:"id"`
+	IsBackup              bool   `json:"isBackup"`
+	LastAnnouncePeerCount int    `json:"lastAnnouncePeerCount"`
+	LastAnnounceResult    string `json:"lastAnnounceResult"`
+	LastAnnounceStartTime int64  `json:"lastAnnounceStartTime"`
+	LastAnnounceSucceeded bool   `json:"lastAnnounceSucceeded"`
+	LastAnnounceTime      int64  `json:"lastAnnounceTime"`
+	LastAnnounceTimedOut  bool   `json:"lastAnnounceTimedOut"`
+	LastScrapeResult      string `json:"lastScrapeResult"`
+	LastScrapeStartTime   int64  `json:"lastScrapeStartTime"`
+	LastScrapeSucceeded   bool   `json:"lastScrapeSucceeded"`
+	LastScrapeTime        int64  `json:"lastScrapeTime"`
+	LastScrapeTimedOut    bool   `json:"lastScrapeTimedOut"`
+	LeecherCount          int    `json:"leecherCount"`
+	NextAnnounceTime      int64  `json:"nextAnnounceTime"`
+	NextScrapeTime        int64  `json:"nextScrapeTime"`
+	Scrape                string `json:"scrape"`
+	ScrapeState           int    `json:"scrapeState"`
+	SeederCount           int    `json:"seederCount"`
+	Sitename              string `json:"sitename"`
+	Tier                  int    `json:"tier"`
+}
+
+// TransmissionTrackerStats returns the trackerStats of transmission rpc with the tracker id and tier. A tracker is
+// a backup if it's not the one in use of its tier.
+func (s TrackerStatus) TransmissionTrackerStats(id, tier int, isBackup bool) TransmissionTrackerStats {
+	stats := TransmissionTrackerStats{
+		Announce:              s.URL,
+		AnnounceState:         TransmissionTrackerInactive,
+		DownloadCount:         -1,
+		HasAnnounced:          s.Announces > 0,
+		ID:                    id,
+		IsBackup:              isBackup,
+		LastAnnouncePeerCount: s.LastPeerCount,
+		LastAnnounceResult:    s.LastAnnounceResult,
+		LastAnnounceSucceeded: s.LastAnnounceSucceeded,
+		LastAnnounceTime:      unixOrZero(s.LastAnnounceTime),
+		LastAnnounceStartTime: unixOrZero(s.LastAnnounceTime),
+		LastAnnounceTimedOut:  s.LastErrorClass == TrackerErrorTimeout && !s.LastAnnounceSucceeded,
+		LeecherCount:          -1,
+		NextAnnounceTime:      unixOrZero(s.NextAnnounceTime),
+		SeederCount:           -1,
+		Tier:                  tier,
+	}
+	if s.Announces > 0 {
+		stats.SeederCount, stats.LeecherCount = s.Seeders, s.Leechers
+	}
+	if s.Running && !isBackup {
+		stats.AnnounceState = TransmissionTrackerWaiting
+	}
+	if u, err := url.Parse(s.URL); err == nil {
+		stats.Host = u.Scheme + "://" + u.Host
+		stats.Sitename = trackerSitename(u.Hostname())
+	}
+	return stats
+}
+
+var trackerSecondLevelDomains = map[string]bool{
+	"ac": true, "co": true, "com": true, "edu": true, "gov": true, "net": true, "or": true, "org": true,
+}
+
+// trackerSitename returns the site name of host like transmission, the label before the public suffix, e.g.
+// "opentrackr" of "tracker.opentrackr.org"
+func trackerSitename(host string) string {
+	domain := trackerRegisteredDomain(host)
+	if net.ParseIP(domain) != nil {
+		return domain
+	}
+	name, _, _ := strings.Cut(domain, ".")
+	return name
+}
+
+// trackerRegisteredDomain returns the registered domain of host, the public suffix with one more label, e.g.
+// "opentrackr.org" of "tracker.opentrackr.org". The public suffix is guessed: two labels for the common second level
+// domains of country code tlds (e.g. "co.uk"), otherwise one. IP addresses are returned as is.
+func trackerRegisteredDomain(host string) string {
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	n := 2
+	if len(labels) >= 3 && len(labels[len(labels)-1]) == 2 && trackerSecondLevelDomains[labels[len(labels)-2]] {
+		n = 3
+	}
+	if len(labels) < n {
+		n = len(labels)
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
@@ -0,0 +1,178 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:21:01
+//
+// File Name: tracker_url.go
+// Description:
+//
+//	Tracker url helpers
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var (
+	ErrMalformedTrackerURL = errors.New("Malformed tracker url")
+)
+
+// trackerDefaultPorts defines the default ports which are stripped by NormalizeTrackerURL
+var trackerDefaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// NormalizeTrackerURL normalizes a tracker url leniently:
+// surrounding spaces are trimmed, illegal characters are percent-encoded, scheme and host are lowercased and default ports are stripped
+func NormalizeTrackerURL(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	schemeEnd := strings.Index(s, "://")
+	if schemeEnd <= 0 {
+		return "", fmt.Errorf("%w: Missing scheme", ErrMalformedTrackerURL)
+	}
+	var (
+		scheme    = strings.ToLower(s[:schemeEnd])
+		rest      = s[schemeEnd+3:]
+		authority = rest
+		tail      string
+	)
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		authority = rest[:i]
+		tail = rest[i:]
+	}
+
+	u, err := url.Parse(scheme + "://" + authority + escapeIllegalURLChars(tail))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrMalformedTrackerURL, err)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("%w: Missing host", ErrMalformedTrackerURL)
+	}
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == trackerDefaultPorts[scheme] {
+		port = ""
+	}
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6
+		u.Host = "[" + host + "]"
+	} else {
+		u.Host = host
+	}
+	return u.String(), nil
+}
+
+// trackerPasskeyParams defines the query parameters carrying passkeys
+var trackerPasskeyParams = []string{"passkey", "authkey", "torrent_pass", "pk"}
+
+// TrackerURL defines the parts of a tracker url
+type TrackerURL struct {
+	Scheme   string // Lower case
+	Host     string // Lower case ascii host (non-ascii labels are punycode encoded) or ip
+	Port     int    // The default port of scheme if not set, zero if there's no default (udp)
+	Path     string
+	Passkey  string // Detected by common patterns, empty if not found
+	Identity string // The tracker identity: the registered domain of host (e.g. "opentrackr.org"), or the ip
+}
+
+// ParseTrackerURL parses the tracker url (see TrackerURLForDial). The passkey is the value of a passkey query
+// parameter (passkey, authkey, torrent_pass or pk), or a path segment of at least 16 alphanumerics.
+// The identity groups the trackers of a site, e.g. "tracker.example.org" and "udp://open.example.org:6969".
+func ParseTrackerURL(s string) (*TrackerURL, error) {
+	normalized, err := TrackerURLForDial(s)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTrackerURL, err)
+	}
+	t := TrackerURL{Scheme: u.Scheme, Host: u.Hostname(), Path: u.Path}
+	port := u.Port()
+	if port == "" {
+		port = trackerDefaultPorts[u.Scheme]
+	}
+	if port != "" {
+		if t.Port, err = strconv.Atoi(port); err != nil || t.Port > 65535 {
+			return nil, fmt.Errorf("%w: Invalid port [%v]", ErrMalformedTrackerURL, port)
+		}
+	}
+	t.Passkey = trackerURLPasskey(u)
+	t.Identity = trackerRegisteredDomain(t.Host)
+	return &t, nil
+}
+
+// TrackerIdentity returns the tracker identity of tracker url (see ParseTrackerURL) to group torrents by tracker,
+// empty if the url is malformed
+func TrackerIdentity(s string) string {
+	t, err := ParseTrackerURL(s)
+	if err != nil {
+		return ""
+	}
+	return t.Identity
+}
+
+// trackerURLPasskey returns the passkey of tracker url by query or path
+func trackerURLPasskey(u *url.URL) string {
+	query := u.Query()
+	for _, param := range trackerPasskeyParams {
+		if passkey := query.Get(param); passkey != "" {
+			return passkey
+		}
+	}
+	for _, segment := range strings.Split(u.Path, "/") {
+		if len(segment) >= 16 && isAlphanumeric(segment) {
+			return segment
+		}
+	}
+	return ""
+}
+
+func isAlphanumeric(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// escapeIllegalURLChars percent-encodes the characters which are illegal in url path and query.
+// Valid percent-encoded sequences are kept as is.
+func escapeIllegalURLChars(s string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '%':
+			if i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2]) {
+				builder.WriteByte(c)
+			} else {
+				builder.WriteString("%25")
+			}
+		case c <= 0x20 || c >= 0x7f || strings.IndexByte("\"<>[]\\^`{|}", c) >= 0:
+			builder.WriteByte('%')
+			builder.WriteByte(hexDigits[c>>4])
+			builder.WriteByte(hexDigits[c&0xf])
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,218 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:16:18
+//
+// File Name: transmission_settings.go
+// Description:
+//
+//	Read and write transmission daemon's settings.json
+//
+//	Reference:
+//
+//		https://github.com/transmission/transmission/blob/main/docs/Editing-Configuration-Files.md
+//
+
+package transmission
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Errors
+var (
+	ErrMalformedTransmissionSettings = errors.New("Malformed transmission settings")
+)
+
+// TransmissionSettings defines the transmission daemon's settings.json.
+// A nil field means the key is absent (so the daemon uses its default), unknown keys are preserved in Extra.
+type TransmissionSettings struct {
+	// Bandwidth
+	AltSpeedDown          *int  `json:"alt-speed-down,omitempty"`
+	AltSpeedEnabled       *bool `json:"alt-speed-enabled,omitempty"`
+	AltSpeedTimeBegin     *int  `json:"alt-speed-time-begin,omitempty"`
+	AltSpeedTimeDay       *int  `json:"alt-speed-time-day,omitempty"`
+	AltSpeedTimeEnabled   *bool `json:"alt-speed-time-enabled,omitempty"`
+	AltSpeedTimeEnd       *int  `json:"alt-speed-time-end,omitempty"`
+	AltSpeedUp            *int  `json:"alt-speed-up,omitempty"`
+	SpeedLimitDown        *int  `json:"speed-limit-down,omitempty"`
+	SpeedLimitDownEnabled *bool `json:"speed-limit-down-enabled,omitempty"`
+	SpeedLimitUp          *int  `json:"speed-limit-up,omitempty"`
+	SpeedLimitUpEnabled   *bool `json:"speed-limit-up-enabled,omitempty"`
+
+	// Files and locations
+	DownloadDir               *string `json:"download-dir,omitempty"`
+	IncompleteDir             *string `json:"incomplete-dir,omitempty"`
+	IncompleteDirEnabled      *bool   `json:"incomplete-dir-enabled,omitempty"`
+	Preallocation             *int    `json:"preallocation,omitempty"`
+	RenamePartialFiles        *bool   `json:"rename-partial-files,omitempty"`
+	StartAddedTorrents        *bool   `json:"start-added-torrents,omitempty"`
+	TrashOriginalTorrentFiles *bool   `json:"trash-original-torrent-files,omitempty"`
+	WatchDir                  *string `json:"watch-dir,omitempty"`
+	WatchDirEnabled           *bool   `json:"watch-dir-enabled,omitempty"`
+	CacheSizeMB               *int    `json:"cache-size-mb,omitempty"`
+
+	// Peers
+	BindAddressIPv4       *string `json:"bind-address-ipv4,omitempty"`
+	BindAddressIPv6       *string `json:"bind-address-ipv6,omitempty"`
+	BlocklistEnabled      *bool   `json:"blocklist-enabled,omitempty"`
+	BlocklistURL          *string `json:"blocklist-url,omitempty"`
+	DHTEnabled            *bool   `json:"dht-enabled,omitempty"`
+	Encryption            *int    `json:"encryption,omitempty"`
+	LPDEnabled            *bool   `json:"lpd-enabled,omitempty"`
+	PeerLimitGlobal       *int    `json:"peer-limit-global,omitempty"`
+	PeerLimitPerTorrent   *int    `json:"peer-limit-per-torrent,omitempty"`
+	PeerPort              *int    `json:"peer-port,omitempty"`
+	PeerPortRandomHigh    *int    `json:"peer-port-random-high,omitempty"`
+	PeerPortRandomLow     *int    `json:"peer-port-random-low,omitempty"`
+	PeerPortRandomOnStart *bool   `json:"peer-port-random-on-start,omitempty"`
+	PEXEnabled            *bool   `json:"pex-enabled,omitempty"`
+	PortForwardingEnabled *bool   `json:"port-forwarding-enabled,omitempty"`
+	UTPEnabled            *bool   `json:"utp-enabled,omitempty"`
+
+	// Queuing and seeding
+	DownloadQueueEnabled    *bool    `json:"download-queue-enabled,omitempty"`
+	DownloadQueueSize       *int     `json:"download-queue-size,omitempty"`
+	SeedQueueEnabled        *bool    `json:"seed-queue-enabled,omitempty"`
+	SeedQueueSize           *int     `json:"seed-queue-size,omitempty"`
+	IdleSeedingLimit        *int     `json:"idle-seeding-limit,omitempty"`
+	IdleSeedingLimitEnabled *bool    `json:"idle-seeding-limit-enabled,omitempty"`
+	RatioLimit              *float64 `json:"ratio-limit,omitempty"`
+	RatioLimitEnabled       *bool    `json:"ratio-limit-enabled,omitempty"`
+
+	// RPC
+	RPCAuthenticationRequired *bool   `json:"rpc-authentication-required,omitempty"`
+	RPCBindAddress            *string `json:"rpc-bind-address,omitempty"`
+	RPCEnabled                *bool   `json:"rpc-enabled,omitempty"`
+	RPCHostWhitelist          *string `json:"rpc-host-whitelist,omitempty"`
+	RPCHostWhitelistEnabled   *bool   `json:"rpc-host-whitelist-enabled,omitempty"`
+	RPCPassword               *string `json:"rpc-password,omitempty"`
+	RPCPort                   *int    `json:"rpc-port,omitempty"`
+	RPCURL                    *string `json:"rpc-url,omitempty"`
+	RPCUsername               *string `json:"rpc-username,omitempty"`
+	RPCWhitelist              *string `json:"rpc-whitelist,omitempty"`
+	RPCWhitelistEnabled       *bool   `json:"rpc-whitelist-enabled,omitempty"`
+
+	// Scripts
+	ScriptTorrentDoneEnabled  *bool   `json:"script-torrent-done-enabled,omitempty"`
+	ScriptTorrentDoneFilename *string `json:"script-torrent-done-filename,omitempty"`
+
+	// Misc
+	MessageLevel *int `json:"message-level,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"` // Unknown keys
+}
+
+// transmissionSettingsFields is used to (un)marshal the known fields without recursion
+type transmissionSettingsFields TransmissionSettings
+
+var (
+	transmissionSettingsKeysOnce sync.Once
+	transmissionSettingsKeys     map[string]bool
+)
+
+func getTransmissionSettingsKeys() map[string]bool {
+	transmissionSettingsKeysOnce.Do(func() {
+		transmissionSettingsKeys = make(map[string]bool)
+		t := reflect.TypeOf(TransmissionSettings{})
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("json")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			transmissionSettingsKeys[strings.Split(tag, ",")[0]] = true
+		}
+	})
+	return transmissionSettingsKeys
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *TransmissionSettings) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var fields transmissionSettingsFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	keys := getTransmissionSettingsKeys()
+	for key, value := range raw {
+		if keys[key] {
+			continue
+		}
+		if fields.Extra == nil {
+			fields.Extra = make(map[string]json.RawMessage)
+		}
+		fields.Extra[key] = value
+	}
+	*s = TransmissionSettings(fields)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (s TransmissionSettings) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(transmissionSettingsFields(s))
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	keys := getTransmissionSettingsKeys()
+	for key, value := range s.Extra {
+		if !keys[key] {
+			raw[key] = value
+		}
+	}
+	// Keys are sorted by encoding/json, the same as transmission writes them
+	return json.Marshal(raw)
+}
+
+// ReadTransmissionSettings reads settings.json
+func ReadTransmissionSettings(path string) (*TransmissionSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var settings TransmissionSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTransmissionSettings, err)
+	}
+	return &settings, nil
+}
+
+// WriteFile writes the settings to path atomically.
+// NOTE: The daemon overwrites settings.json on exit, so it should be stopped (or reloaded by SIGHUP) around writing.
+func (s *TransmissionSettings) WriteFile(path string) error {
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".settings.json.*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// settings.json contains the rpc password
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
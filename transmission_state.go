@@ -0,0 +1,202 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:17:36
+//
+// File Name: transmission_state.go
+// Description:
+//
+//	Read transmission's on-disk state: resume/*.resume and torrents/*.torrent (*.magnet) in its config dir
+//
+
+package transmission
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Errors
+var (
+	ErrMalformedTransmissionResume = errors.New("Malformed transmission resume file")
+)
+
+// transmissionBlockSize defines the block size transmission uses in the progress bitfield
+const transmissionBlockSize = 16 * 1024
+
+// TransmissionResume defines the transmission .resume file
+type TransmissionResume struct {
+	Name          string
+	Destination   string // Download location
+	IncompleteDir string
+	Labels        []string
+	Paused        bool
+	Downloaded    int64
+	Uploaded      int64
+	Corrupt       int64
+	AddedDate     time.Time
+	DoneDate      time.Time
+	ActivityDate  time.Time
+	HaveAll       bool   // All pieces are verified
+	Blocks        []byte // Bitfield of blocks, nil if BlocksAll or HaveAll is set
+	BlocksAll     bool
+
+	Raw map[string]interface{} // All decoded fields
+}
+
+// ParseTransmissionResume parses the content of .resume file
+func ParseTransmissionResume(data []byte) (*TransmissionResume, error) {
+	v, err := DecodeBencode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedTransmissionResume, err)
+	}
+	dict, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: Not a dictionary", ErrMalformedTransmissionResume)
+	}
+	r := TransmissionResume{
+		Name:          bencodeDictString(dict, "name"),
+		Destination:   bencodeDictString(dict, "destination"),
+		IncompleteDir: bencodeDictString(dict, "incomplete-dir"),
+		Labels:        bencodeStringList(bencodeDictList(dict, "labels")),
+		Paused:        bencodeDictInt(dict, "paused") != 0,
+		Downloaded:    bencodeDictInt(dict, "downloaded"),
+		Uploaded:      bencodeDictInt(dict, "uploaded"),
+		Corrupt:       bencodeDictInt(dict, "corrupt"),
+		AddedDate:     unixTimeOrZero(bencodeDictInt(dict, "added-date")),
+		DoneDate:      unixTimeOrZero(bencodeDictInt(dict, "done-date")),
+		ActivityDate:  unixTimeOrZero(bencodeDictInt(dict, "activity-date")),
+		Raw:           dict,
+	}
+	if progress := bencodeDictDict(dict, "progress"); progress != nil {
+		r.HaveAll = bencodeDictString(progress, "have") == "all"
+		switch blocks := bencodeDictString(progress, "blocks"); blocks {
+		case "all":
+			r.BlocksAll = true
+		case "none", "":
+		default:
+			r.Blocks = []byte(blocks)
+		}
+	}
+	return &r, nil
+}
+
+func unixTimeOrZero(sec int64) time.Time {
+	if sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// Progress returns the downloaded fraction [0, 1] of the torrent's content
+func (r *TransmissionResume) Progress(totalLength int64) float64 {
+	if r.HaveAll || r.BlocksAll {
+		return 1
+	}
+	if totalLength <= 0 || len(r.Blocks) == 0 {
+		return 0
+	}
+	blockCount := (totalLength + transmissionBlockSize - 1) / transmissionBlockSize
+	var have int64
+	for _, b := range r.Blocks {
+		have += int64(bits.OnesCount8(b))
+	}
+	if have >= blockCount {
+		return 1
+	}
+	return float64(have) / float64(blockCount)
+}
+
+// TransmissionTorrentState defines the on-disk state of a torrent in transmission's config dir
+type TransmissionTorrentState struct {
+	ID          string // The file name without extension, which is the info hash (or name.hash prefix for transmission < 4)
+	ResumePath  string
+	TorrentPath string
+	Resume      *TransmissionResume
+	Torrent     *TorrentFile // Nil if the torrent was added by magnet link and has no metadata yet
+	MagnetLink  *MagnetLink  // Set for .magnet files
+	Err         error        // The error occurred when reading this torrent
+}
+
+// ReadTransmissionState reads the resume/ and torrents/ directory in transmission's config dir.
+// Errors of individual files are reported in TransmissionTorrentState.Err.
+func ReadTransmissionState(configDir string) ([]TransmissionTorrentState, error) {
+	states := make(map[string]*TransmissionTorrentState)
+	get := func(id string) *TransmissionTorrentState {
+		state, ok := states[id]
+		if !ok {
+			state = &TransmissionTorrentState{ID: id}
+			states[id] = state
+		}
+		return state
+	}
+
+	// Torrents
+	torrentsDir := filepath.Join(configDir, "torrents")
+	entries, err := os.ReadDir(torrentsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		var (
+			name = entry.Name()
+			ext  = filepath.Ext(name)
+			path = filepath.Join(torrentsDir, name)
+		)
+		switch ext {
+		case ".torrent":
+			state := get(strings.TrimSuffix(name, ext))
+			state.TorrentPath = path
+			state.Torrent, err = ReadTorrentFile(path)
+			if err != nil && state.Err == nil {
+				state.Err = err
+			}
+		case ".magnet":
+			state := get(strings.TrimSuffix(name, ext))
+			state.TorrentPath = path
+			var data []byte
+			if data, err = os.ReadFile(path); err == nil {
+				state.MagnetLink, err = ParseMagnetLink(strings.TrimSpace(string(data)))
+			}
+			if err != nil && state.Err == nil {
+				state.Err = err
+			}
+		}
+	}
+
+	// Resumes
+	resumeDir := filepath.Join(configDir, "resume")
+	entries, err = os.ReadDir(resumeDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".resume" {
+			continue
+		}
+		state := get(strings.TrimSuffix(name, ".resume"))
+		state.ResumePath = filepath.Join(resumeDir, name)
+		var data []byte
+		if data, err = os.ReadFile(state.ResumePath); err == nil {
+			state.Resume, err = ParseTransmissionResume(data)
+		}
+		if err != nil && state.Err == nil {
+			state.Err = err
+		}
+	}
+
+	results := make([]TransmissionTorrentState, 0, len(states))
+	for _, state := range states {
+		results = append(results, *state)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results, nil
+}
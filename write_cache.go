@@ -0,0 +1,180 @@
+// Author: lipixun
+// Created Time : 2026-10-15 09:48:59
+//
+// File Name: write_cache.go
+// Description:
+//
+//	Bounded write-back cache coalescing adjacent block writes into large sequential writes
+//
+
+package transmission
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// DefaultWriteCacheSize defines the default size of write cache
+const DefaultWriteCacheSize = 16 << 20
+
+// WriteCacheBackend defines the storage under write cache, e.g. *os.File
+type WriteCacheBackend interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// WriteCacheStats defines the stats of write cache
+type WriteCacheStats struct {
+	Writes        int64 // WriteAt calls
+	BackendWrites int64 // Coalesced writes to backend
+	Reads         int64 // ReadAt calls
+	Hits          int64 // Reads served from cache
+	Flushes       int64
+	Buffered      int64 // Bytes in cache now
+}
+
+// HitRate returns the rate of reads served from cache
+func (s WriteCacheStats) HitRate() float64 {
+	if s.Reads == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Reads)
+}
+
+// WriteCache buffers writes to backend and flushes them when the size is reached, adjacent writes are coalesced
+// into one write. Reads of buffered data are served from cache, other reads flush the cache first so the backend
+// is up to date (e.g. before verifying a piece). Close must be called at shutdown to flush. It's safe for
+// concurrent use.
+type WriteCache struct {
+	Backend WriteCacheBackend
+	Size    int64 // DefaultWriteCacheSize if zero
+
+	mutex    sync.Mutex
+	blocks   map[int64][]byte // Offset -> data
+	buffered int64
+	stats    WriteCacheStats
+}
+
+// NewWriteCache creates a new WriteCache
+func NewWriteCache(backend WriteCacheBackend, size int64) *WriteCache {
+	return &WriteCache{Backend: backend, Size: size, blocks: make(map[int64][]byte)}
+}
+
+// WriteAt implements io.WriterAt. The data is copied, the error of flushing (if the cache is full) is returned.
+func (c *WriteCache) WriteAt(p []byte, off int64) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.blocks == nil {
+		c.blocks = make(map[int64][]byte)
+	}
+	c.stats.Writes++
+	if old, ok := c.blocks[off]; ok && len(old) == len(p) {
+		copy(old, p)
+		return len(p), nil
+	}
+	if c.overlaps(off, int64(len(p))) {
+		// Keep the blocks disjoint
+		if err := c.flush(); err != nil {
+			return 0, err
+		}
+	}
+	c.blocks[off] = append([]byte(nil), p...)
+	c.buffered += int64(len(p))
+	size := c.Size
+	if size <= 0 {
+		size = DefaultWriteCacheSize
+	}
+	if c.buffered >= size {
+		if err := c.flush(); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// ReadAt implements io.ReaderAt
+func (c *WriteCache) ReadAt(p []byte, off int64) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stats.Reads++
+	for start, data := range c.blocks {
+		if off >= start && off+int64(len(p)) <= start+int64(len(data)) {
+			c.stats.Hits++
+			return copy(p, data[off-start:]), nil
+		}
+	}
+	if c.overlaps(off, int64(len(p))) {
+		if err := c.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return c.Backend.ReadAt(p, off)
+}
+
+// Flush writes the buffered data to backend
+func (c *WriteCache) Flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.flush()
+}
+
+// Close flushes the buffered data, the backend is not closed
+func (c *WriteCache) Close() error {
+	return c.Flush()
+}
+
+// Stats returns the stats
+func (c *WriteCache) Stats() WriteCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Buffered = c.buffered
+	return stats
+}
+
+func (c *WriteCache) overlaps(off, length int64) bool {
+	for start, data := range c.blocks {
+		if off < start+int64(len(data)) && start < off+length {
+			return true
+		}
+	}
+	return false
+}
+
+// flush writes the blocks in offset order, the adjacent blocks are written at once
+func (c *WriteCache) flush() error {
+	if len(c.blocks) == 0 {
+		return nil
+	}
+	c.stats.Flushes++
+	offsets := make([]int64, 0, len(c.blocks))
+	for off := range c.blocks {
+		offsets = append(offsets, off)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	for i := 0; i < len(offsets); {
+		start := offsets[i]
+		run := c.blocks[start]
+		j := i + 1
+		for ; j < len(offsets) && offsets[j] == start+int64(len(run)); j++ {
+			if j == i+1 {
+				// Don't modify the block in place
+				run = append([]byte(nil), run...)
+			}
+			run = append(run, c.blocks[offsets[j]]...)
+		}
+		if _, err := c.Backend.WriteAt(run, start); err != nil {
+			// The blocks written are dropped, the others are kept to retry
+			return err
+		}
+		c.stats.BackendWrites++
+		for _, off := range offsets[i:j] {
+			c.buffered -= int64(len(c.blocks[off]))
+			delete(c.blocks, off)
+		}
+		i = j
+	}
+	return nil
+}